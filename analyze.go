@@ -0,0 +1,473 @@
+package jsonschema
+
+import (
+	"fmt"
+	"math/big"
+	"sort"
+	"strings"
+)
+
+// Analyze reports the schemas reachable from s, which no instance can
+// satisfy, the subschemas which can never be valid where they are used,
+// and the constraints which have no effect. Findings are sorted by location.
+//
+// The analysis is conservative: schema is reported unsatisfiable, only if
+// it can be proved without looking at instances. Following rules are used:
+//
+//	contradiction  error    keywords conflict, like minimum greater than maximum.
+//	                        it is warning, if instances of other types may be valid
+//	unsatisfiable  error    no instance is valid against schema
+//	dead-subschema warning  anyOf/oneOf subschema can never be valid, because of its siblings
+//	redundant      warning  keyword has no effect
+func (s *Schema) Analyze() []Finding {
+	a := &analyzer{types: make(map[*Schema]jsonTypes), conflicting: make(map[*Schema]bool)}
+	a.walk(s, make(map[*Schema]bool))
+
+	sort.SliceStable(a.findings, func(i, j int) bool {
+		return a.findings[i].Location < a.findings[j].Location
+	})
+	var list []Finding
+	seen := make(map[Finding]bool)
+	for _, f := range a.findings {
+		if !seen[f] {
+			seen[f] = true
+			list = append(list, f)
+		}
+	}
+	return list
+}
+
+// jsonTypes is set of json types, with number split into integer and fraction.
+type jsonTypes uint8
+
+const (
+	typeNull jsonTypes = 1 << iota
+	typeBoolean
+	typeInteger
+	typeFraction
+	typeString
+	typeArray
+	typeObject
+
+	typeNumber = typeInteger | typeFraction
+	typeAll    = typeNull | typeBoolean | typeNumber | typeString | typeArray | typeObject
+)
+
+func typesOf(t string) jsonTypes {
+	switch t {
+	case "null":
+		return typeNull
+	case "boolean":
+		return typeBoolean
+	case "integer":
+		return typeInteger
+	case "number":
+		return typeNumber
+	case "string":
+		return typeString
+	case "array":
+		return typeArray
+	case "object":
+		return typeObject
+	}
+	return 0
+}
+
+// typeOfValue returns the type of json value v.
+func typeOfValue(v interface{}) jsonTypes {
+	t := jsonType(v)
+	if t == "number" {
+		if num := newNumber(v); num.isInt() {
+			return typeInteger
+		}
+		return typeFraction
+	}
+	return typesOf(t)
+}
+
+func (t jsonTypes) String() string {
+	var names []string
+	for _, name := range []string{"null", "boolean", "integer", "number", "string", "array", "object"} {
+		if tt := typesOf(name); t&tt == tt {
+			if name == "integer" && t&typeNumber == typeNumber {
+				continue
+			}
+			names = append(names, name)
+		} else if name == "number" && t&typeFraction != 0 && t&typeInteger == 0 {
+			names = append(names, "non-integer number")
+		}
+	}
+	return strings.Join(names, " or ")
+}
+
+// keywordTypes lists the keywords, which apply only to instances of specific types.
+var keywordTypes = map[string]jsonTypes{
+	"properties": typeObject, "patternProperties": typeObject, "additionalProperties": typeObject,
+	"required": typeObject, "minProperties": typeObject, "maxProperties": typeObject,
+	"propertyNames": typeObject, "dependentRequired": typeObject, "dependentSchemas": typeObject,
+	"items": typeArray, "prefixItems": typeArray, "additionalItems": typeArray, "contains": typeArray,
+	"minItems": typeArray, "maxItems": typeArray, "uniqueItems": typeArray,
+	"minLength": typeString, "maxLength": typeString, "pattern": typeString,
+	"minimum": typeNumber, "maximum": typeNumber, "exclusiveMinimum": typeNumber,
+	"exclusiveMaximum": typeNumber, "multipleOf": typeNumber,
+}
+
+type analyzer struct {
+	findings    []Finding
+	types       map[*Schema]jsonTypes // types of instances, which may be valid
+	conflicting map[*Schema]bool      // schemas with contradiction reported in own keywords
+}
+
+func (a *analyzer) report(loc string, severity Severity, rule, format string, args ...interface{}) {
+	a.findings = append(a.findings, Finding{loc, severity, rule, fmt.Sprintf(format, args...)})
+}
+
+// contradiction reports that keywords of s conflict, at loc. t is the
+// types of instances, which may still be valid. It is reported as
+// warning, if t is not empty.
+func (a *analyzer) contradiction(s *Schema, loc string, t jsonTypes, format string, args ...interface{}) {
+	if t != 0 {
+		a.report(loc, SeverityWarning, "contradiction", format, args...)
+		return
+	}
+	a.report(loc, SeverityError, "contradiction", format, args...)
+	a.conflicting[s] = true
+}
+
+// walk analyzes s and the schemas reachable from it, excluding metaschemas.
+func (a *analyzer) walk(s *Schema, visited map[*Schema]bool) {
+	if visited[s] || findDraft(s.url()) != nil {
+		return
+	}
+	visited[s] = true
+	a.typesOf(s)
+	for _, sch := range subschemas(s) {
+		a.walk(sch, visited)
+	}
+	for _, key := range sortedKeys(s.extSchemas) {
+		a.walk(s.extSchemas[key], visited)
+	}
+}
+
+// typesOf returns the types of instances, which may be valid against s.
+// It reports the findings of s, on first call.
+func (a *analyzer) typesOf(s *Schema) jsonTypes {
+	if t, ok := a.types[s]; ok {
+		return t
+	}
+	if findDraft(s.url()) != nil {
+		return typeAll
+	}
+	a.types[s] = typeAll // assumed, while s is being analyzed
+	t := a.analyze(s)
+	a.types[s] = t
+	return t
+}
+
+func (a *analyzer) analyze(s *Schema) jsonTypes {
+	if s.Always != nil {
+		if *s.Always {
+			return typeAll
+		}
+		return 0
+	}
+
+	// inherited tells whether types became empty, because of
+	// subschema or contradiction which is already reported.
+	inherited := false
+	intersect := func(t, sub jsonTypes) jsonTypes {
+		if sub == 0 {
+			inherited = true
+		}
+		return t & sub
+	}
+
+	t := typeAll
+	if len(s.Types) > 0 {
+		t = 0
+		for _, name := range s.Types {
+			if typesOf(name) == typeInteger && hasType(s.Types, "number") {
+				a.report(s.Location+"/type", SeverityWarning, "redundant", "integer is redundant, because number is allowed")
+			}
+			t |= typesOf(name)
+		}
+	}
+	if s.Ref != nil {
+		t = intersect(t, a.typesOf(s.Ref))
+	}
+	if s.Not != nil && a.always(s.Not) {
+		// same as false schema, used on purpose
+		t, inherited = 0, true
+	}
+
+	t = a.numbers(s, t)
+	t = a.ranges(s, t)
+	t = a.required(s, t)
+	t = a.values(s, t)
+	if a.conflicting[s] {
+		inherited = true
+	}
+
+	if len(s.AllOf) > 0 {
+		before := t
+		for _, sch := range s.AllOf {
+			t = intersect(t, a.typesOf(sch))
+		}
+		if before != 0 && t == 0 && !inherited {
+			a.report(s.Location+"/"+s.keyword("allOf"), SeverityError, "contradiction", "allOf subschemas have no common type")
+			inherited = true
+		}
+	}
+	for _, branch := range []struct {
+		kw      string
+		schemas []*Schema
+	}{{"anyOf", s.AnyOf}, {"oneOf", s.OneOf}} {
+		if len(branch.schemas) == 0 {
+			continue
+		}
+		var union jsonTypes
+		for _, sch := range branch.schemas {
+			bt := a.typesOf(sch)
+			if bt != 0 && t != 0 && bt&t == 0 {
+				a.report(sch.Location, SeverityWarning, "dead-subschema", "%s subschema can never be valid, because only %s is allowed here", s.keyword(branch.kw), t)
+			}
+			union |= bt
+		}
+		t = intersect(t, union)
+	}
+
+	if t == 0 && !inherited {
+		a.report(s.Location, SeverityError, "unsatisfiable", "no instance is valid against this schema")
+	}
+	a.redundant(s, t)
+	return t
+}
+
+// numbers checks the bounds of numbers.
+func (a *analyzer) numbers(s *Schema, t jsonTypes) jsonTypes {
+	lo, loExcl, loKw := lowerBound(s)
+	hi, hiExcl, hiKw := upperBound(s)
+	if lo == nil || hi == nil {
+		return t
+	}
+	if c := lo.Cmp(hi); c > 0 || (c == 0 && (loExcl || hiExcl)) {
+		t &^= typeNumber
+		a.contradiction(s, s.Location+"/"+loKw, t, "%s %s is not less than %s %s, so no number is valid", loKw, lo.RatString(), hiKw, hi.RatString())
+		return t
+	}
+
+	// smallest and largest integers in range
+	min, max := ceil(lo), floor(hi)
+	if loExcl && min.Cmp(lo) == 0 {
+		min.Add(min, big.NewRat(1, 1))
+	}
+	if hiExcl && max.Cmp(hi) == 0 {
+		max.Sub(max, big.NewRat(1, 1))
+	}
+	if min.Cmp(max) > 0 {
+		integer := t&typeNumber == typeInteger
+		t &^= typeInteger
+		if integer {
+			a.contradiction(s, s.Location+"/"+loKw, t, "no integer is between %s %s and %s %s", loKw, lo.RatString(), hiKw, hi.RatString())
+		}
+	}
+	return t
+}
+
+// lowerBound returns the effective lower bound of numbers in s,
+// and the keyword which specifies it. lo is nil, if not bounded.
+func lowerBound(s *Schema) (lo *big.Rat, excl bool, kw string) {
+	lo, kw = s.Minimum, "minimum"
+	if s.ExclusiveMinimum != nil && (lo == nil || s.ExclusiveMinimum.Cmp(lo) >= 0) {
+		lo, excl, kw = s.ExclusiveMinimum, true, "exclusiveMinimum"
+	}
+	if s.Draft.version <= 4 {
+		kw = "minimum" // exclusiveMinimum is boolean before draft6
+	}
+	return
+}
+
+// upperBound returns the effective upper bound of numbers in s,
+// and the keyword which specifies it. hi is nil, if not bounded.
+func upperBound(s *Schema) (hi *big.Rat, excl bool, kw string) {
+	hi, kw = s.Maximum, "maximum"
+	if s.ExclusiveMaximum != nil && (hi == nil || s.ExclusiveMaximum.Cmp(hi) <= 0) {
+		hi, excl, kw = s.ExclusiveMaximum, true, "exclusiveMaximum"
+	}
+	if s.Draft.version <= 4 {
+		kw = "maximum" // exclusiveMaximum is boolean before draft6
+	}
+	return
+}
+
+func floor(r *big.Rat) *big.Rat {
+	q := new(big.Int).Div(r.Num(), r.Denom()) // euclidean division, as denominator is positive
+	return new(big.Rat).SetInt(q)
+}
+
+func ceil(r *big.Rat) *big.Rat {
+	f := floor(r)
+	if f.Cmp(r) != 0 {
+		f.Add(f, big.NewRat(1, 1))
+	}
+	return f
+}
+
+// ranges checks min and max of lengths and counts.
+func (a *analyzer) ranges(s *Schema, t jsonTypes) jsonTypes {
+	for _, r := range []struct {
+		minKw, maxKw string
+		min, max     int
+		t            jsonTypes
+	}{
+		{"minLength", "maxLength", s.MinLength, s.MaxLength, typeString},
+		{"minItems", "maxItems", s.MinItems, s.MaxItems, typeArray},
+		{"minProperties", "maxProperties", s.MinProperties, s.MaxProperties, typeObject},
+	} {
+		if r.min != -1 && r.max != -1 && r.min > r.max {
+			t &^= r.t
+			a.contradiction(s, s.Location+"/"+r.minKw, t, "%s %d is greater than %s %d, so no %s is valid", r.minKw, r.min, r.maxKw, r.max, r.t)
+		}
+	}
+	if s.Contains != nil {
+		if s.MaxContains != -1 && s.MinContains > s.MaxContains {
+			t &^= typeArray
+			a.contradiction(s, s.Location+"/minContains", t, "minContains %d is greater than maxContains %d, so no array is valid", s.MinContains, s.MaxContains)
+		} else if s.MaxItems != -1 && s.MinContains > s.MaxItems {
+			t &^= typeArray
+			a.contradiction(s, s.Location+"/minContains", t, "minContains %d is greater than maxItems %d, so no array is valid", s.MinContains, s.MaxItems)
+		} else if s.MinContains > 0 && a.typesOf(s.Contains) == 0 {
+			t &^= typeArray
+			a.contradiction(s, s.Location+"/contains", t, "contains can never be valid, so no array is valid")
+		}
+	}
+	return t
+}
+
+// required checks that required properties are allowed.
+func (a *analyzer) required(s *Schema, t jsonTypes) jsonTypes {
+	loc := s.Location + "/required"
+	if s.MaxProperties != -1 && len(s.Required) > s.MaxProperties {
+		t &^= typeObject
+		a.contradiction(s, loc, t, "%d properties are required, but maxProperties is %d, so no object is valid", len(s.Required), s.MaxProperties)
+	}
+	for _, pname := range s.Required {
+		sch, ok := s.Properties[pname]
+		if !ok {
+			for re, psch := range s.PatternProperties {
+				if re.MatchString(pname) {
+					sch, ok = psch, true
+					break
+				}
+			}
+		}
+		if s.Draft.version == 3 {
+			loc = s.Location + "/properties/" + escape(pname) + "/required" // boolean of property in draft3
+		}
+		switch {
+		case ok && a.typesOf(sch) == 0:
+			t &^= typeObject
+			a.contradiction(s, loc, t, "required property %q can never be valid, so no object is valid", pname)
+		case !ok && s.AdditionalProperties == false:
+			t &^= typeObject
+			a.contradiction(s, loc, t, "required property %q is not allowed by additionalProperties, so no object is valid", pname)
+		case !ok && s.AdditionalProperties != nil && s.AdditionalProperties != true:
+			if a.typesOf(s.AdditionalProperties.(*Schema)) == 0 {
+				t &^= typeObject
+				a.contradiction(s, loc, t, "required property %q is not allowed by additionalProperties, so no object is valid", pname)
+			}
+		}
+	}
+	return t
+}
+
+// values checks that const and enum values are of allowed types.
+func (a *analyzer) values(s *Schema, t jsonTypes) jsonTypes {
+	if len(s.Constant) > 0 {
+		vt := typeOfValue(s.Constant[0])
+		if t&vt == 0 {
+			if t != 0 {
+				a.contradiction(s, s.Location+"/const", 0, "const value is %s, but only %s is allowed", vt, t)
+			}
+			return 0
+		}
+		t &= vt
+	}
+	if s.Enum != nil {
+		var et jsonTypes
+		for _, v := range s.Enum {
+			et |= typeOfValue(v)
+		}
+		if t&et == 0 {
+			if t != 0 {
+				a.contradiction(s, s.Location+"/enum", 0, "no enum value is %s", t)
+			}
+			return 0
+		}
+		t &= et
+	}
+	return t
+}
+
+// redundant reports the keywords with no effect.
+func (a *analyzer) redundant(s *Schema, t jsonTypes) {
+	for _, r := range []struct {
+		kw  string
+		min int
+	}{{"minLength", s.MinLength}, {"minItems", s.MinItems}, {"minProperties", s.MinProperties}} {
+		if r.min == 0 {
+			a.report(s.Location+"/"+r.kw, SeverityWarning, "redundant", "%s 0 has no effect", r.kw)
+		}
+	}
+	if s.Draft.version > 4 {
+		if s.Minimum != nil && s.ExclusiveMinimum != nil {
+			kw := "minimum"
+			if s.Minimum.Cmp(s.ExclusiveMinimum) > 0 {
+				kw = "exclusiveMinimum"
+			}
+			a.report(s.Location+"/"+kw, SeverityWarning, "redundant", "%s has no effect, because of stricter bound", kw)
+		}
+		if s.Maximum != nil && s.ExclusiveMaximum != nil {
+			kw := "maximum"
+			if s.Maximum.Cmp(s.ExclusiveMaximum) < 0 {
+				kw = "exclusiveMaximum"
+			}
+			a.report(s.Location+"/"+kw, SeverityWarning, "redundant", "%s has no effect, because of stricter bound", kw)
+		}
+	}
+
+	// keywords, which do not apply to allowed types
+	m, ok := s.doc.(map[string]interface{})
+	if !ok || len(s.Types) == 0 || t == 0 {
+		return
+	}
+	if s.Ref != nil && s.Draft.version < 2019 {
+		return // siblings are ignored
+	}
+	for _, kw := range sortedKeys(m) {
+		if kw == "required" && s.Draft.version == 3 {
+			continue // boolean of property in draft3
+		}
+		if kt, ok := keywordTypes[kw]; ok && kt&t == 0 {
+			a.report(s.Location+"/"+kw, SeverityWarning, "redundant", "%s has no effect, because only %s is allowed", kw, t)
+		}
+	}
+}
+
+// always tells whether s is true schema, or empty schema.
+func (a *analyzer) always(s *Schema) bool {
+	if s.Always != nil {
+		return *s.Always
+	}
+	m, ok := s.doc.(map[string]interface{})
+	return ok && len(m) == 0
+}
+
+func hasType(types []string, t string) bool {
+	for _, tt := range types {
+		if tt == t {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,148 @@
+package jsonschema_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/santhosh-tekuri/jsonschema/v5"
+)
+
+func TestAnalyze(t *testing.T) {
+	tests := []struct {
+		name   string
+		schema string
+		want   []string // location rule
+	}{
+		{
+			name:   "satisfiable",
+			schema: `{"type": "object", "properties": {"a": {"type": "integer", "minimum": 1, "maximum": 1}}, "required": ["a"]}`,
+		},
+		{
+			name:   "minimum",
+			schema: `{"type": "number", "minimum": 5, "exclusiveMaximum": 5}`,
+			want:   []string{"#/minimum contradiction"},
+		},
+		{
+			name:   "integer",
+			schema: `{"type": "integer", "exclusiveMinimum": 1, "maximum": 1.5}`,
+			want:   []string{"#/exclusiveMinimum contradiction"},
+		},
+		{
+			name:   "draft4",
+			schema: `{"$schema": "http://json-schema.org/draft-04/schema#", "minimum": 5, "maximum": 5, "exclusiveMaximum": true}`,
+			want:   []string{"#/minimum contradiction"},
+		},
+		{
+			name:   "length",
+			schema: `{"minLength": 3, "maxLength": 2}`,
+			want:   []string{"#/minLength contradiction"},
+		},
+		{
+			name:   "required",
+			schema: `{"type": "object", "properties": {"a": true}, "required": ["a", "b"], "additionalProperties": false}`,
+			want:   []string{"#/required contradiction"},
+		},
+		{
+			name:   "requiredPattern",
+			schema: `{"required": ["x1"], "patternProperties": {"^x": true}, "additionalProperties": false}`,
+		},
+		{
+			name:   "requiredFalse",
+			schema: `{"properties": {"a": {"$ref": "#/$defs/never"}}, "required": ["a"], "$defs": {"never": {"type": "string", "enum": [1]}}}`,
+			want:   []string{"#/$defs/never/enum contradiction", "#/required contradiction"},
+		},
+		{
+			name:   "enum",
+			schema: `{"type": "integer", "enum": [1.5, "a"]}`,
+			want:   []string{"#/enum contradiction"},
+		},
+		{
+			name:   "allOf",
+			schema: `{"allOf": [{"type": "string"}, {"type": ["number", "null"]}]}`,
+			want:   []string{"#/allOf contradiction"},
+		},
+		{
+			name:   "anyOf",
+			schema: `{"type": "string", "anyOf": [{"type": "number"}, {"minLength": 1}]}`,
+			want:   []string{"#/anyOf/0 dead-subschema"},
+		},
+		{
+			name:   "anyOfNone",
+			schema: `{"type": "string", "anyOf": [{"type": "number"}, {"type": "null"}]}`,
+			want:   []string{"# unsatisfiable", "#/anyOf/0 dead-subschema", "#/anyOf/1 dead-subschema"},
+		},
+		{
+			name:   "redundant",
+			schema: `{"type": ["integer", "number"], "minItems": 0, "minimum": 1, "exclusiveMinimum": 2}`,
+			want:   []string{"#/minItems redundant", "#/minItems redundant", "#/minimum redundant", "#/type redundant"},
+		},
+		{
+			name:   "draft3Required",
+			schema: `{"$schema": "http://json-schema.org/draft-03/schema#", "properties": {"a": {"type": "string", "required": true}}}`,
+		},
+		{
+			name:   "draft3Extends",
+			schema: `{"$schema": "http://json-schema.org/draft-03/schema#", "extends": {"type": "integer"}, "type": "string"}`,
+			want:   []string{"#/extends contradiction"},
+		},
+		{
+			name:   "notEmpty",
+			schema: `{"properties": {"a": {"not": {}}, "b": {"allOf": [{"not": true}]}}}`,
+		},
+		{
+			name:   "recursive",
+			schema: `{"type": "object", "properties": {"child": {"$ref": "#"}}}`,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			c := jsonschema.NewCompiler()
+			url := "schema.json"
+			if err := c.AddResource(url, strings.NewReader(test.schema)); err != nil {
+				t.Fatal(err)
+			}
+			sch, err := c.Compile(url)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			for _, f := range sch.Analyze() {
+				loc := f.Location[strings.IndexByte(f.Location, '#'):]
+				got = append(got, loc+" "+f.Rule)
+			}
+			if strings.Join(got, "\n") != strings.Join(test.want, "\n") {
+				t.Errorf("got %q, want %q", got, test.want)
+			}
+		})
+	}
+}
+
+func TestAnalyzeSeverity(t *testing.T) {
+	tests := []struct {
+		schema string
+		want   jsonschema.Severity
+	}{
+		// other types are valid
+		{`{"minLength": 3, "maxLength": 2}`, jsonschema.SeverityWarning},
+		{`{"minimum": 3, "maximum": 2}`, jsonschema.SeverityWarning},
+		{`{"type": ["integer", "string"], "minimum": 1.2, "maximum": 1.5}`, jsonschema.SeverityWarning},
+		// no type is left
+		{`{"type": "string", "minLength": 3, "maxLength": 2}`, jsonschema.SeverityError},
+		{`{"type": "integer", "minimum": 1.2, "maximum": 1.5}`, jsonschema.SeverityError},
+	}
+	for _, test := range tests {
+		sch, err := jsonschema.CompileString("schema.json", test.schema)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got []jsonschema.Severity
+		for _, f := range sch.Analyze() {
+			if f.Rule == "contradiction" {
+				got = append(got, f.Severity)
+			}
+		}
+		if len(got) != 1 || got[0] != test.want {
+			t.Errorf("%s: got %v, want %v", test.schema, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,105 @@
+package jsonschema
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+)
+
+// ArchiveLoader returns a Loader, which loads the urls under base from
+// the zip or tar archive at given file. tar archive may be gzip compressed.
+// The archive format is detected from its content.
+//
+// The path of url relative to base is used as the name of archive entry.
+// base must end with "/", so that relative references resolve within
+// the archive.
+func ArchiveLoader(base, file string) (Loader, error) {
+	b, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	var files map[string][]byte
+	switch {
+	case bytes.HasPrefix(b, []byte("PK\x03\x04")), bytes.HasPrefix(b, []byte("PK\x05\x06")):
+		files, err = readZip(b)
+	case bytes.HasPrefix(b, []byte("\x1f\x8b")):
+		var r *gzip.Reader
+		if r, err = gzip.NewReader(bytes.NewReader(b)); err == nil {
+			files, err = readTar(r)
+		}
+	default:
+		files, err = readTar(bytes.NewReader(b))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("jsonschema: invalid archive %s: %v", file, err)
+	}
+	return LoaderFunc(func(ctx context.Context, url string) (io.ReadCloser, error) {
+		name, err := relPath(base, url)
+		if err != nil {
+			return nil, err
+		}
+		b, ok := files[name]
+		if !ok {
+			return nil, fmt.Errorf("jsonschema: %s not found in %s: %w", name, file, os.ErrNotExist)
+		}
+		return ioutil.NopCloser(bytes.NewReader(b)), nil
+	}), nil
+}
+
+func readZip(b []byte) (map[string][]byte, error) {
+	zr, err := zip.NewReader(bytes.NewReader(b), int64(len(b)))
+	if err != nil {
+		return nil, err
+	}
+	files := make(map[string][]byte)
+	for _, f := range zr.File {
+		if f.FileInfo().IsDir() {
+			continue
+		}
+		r, err := f.Open()
+		if err != nil {
+			return nil, err
+		}
+		b, err := ioutil.ReadAll(r)
+		r.Close()
+		if err != nil {
+			return nil, err
+		}
+		files[cleanName(f.Name)] = b
+	}
+	return files, nil
+}
+
+func readTar(r io.Reader) (map[string][]byte, error) {
+	tr := tar.NewReader(r)
+	files := make(map[string][]byte)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return files, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		if !hdr.FileInfo().Mode().IsRegular() {
+			continue
+		}
+		b, err := ioutil.ReadAll(tr)
+		if err != nil {
+			return nil, err
+		}
+		files[cleanName(hdr.Name)] = b
+	}
+}
+
+// cleanName normalizes archive entry name, like "./a/b.json" to "a/b.json".
+func cleanName(name string) string {
+	return path.Clean("/" + name)[1:]
+}
@@ -0,0 +1,109 @@
+package jsonschema
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Bundle compiles schema at url, and returns a single draft 2020-12
+// document, with all external resources it depends on embedded under
+// "$defs". Each embedded resource has its canonical url as "$id", so that
+// references resolve to it without loading. Resources of older drafts are
+// converted to draft 2020-12.
+//
+// Note that format assertions are on by default before draft 2019-09,
+// but not in draft 2020-12. Set Compiler.AssertFormat, when compiling
+// bundle of such resources.
+func (c *Compiler) Bundle(url string) (interface{}, error) {
+	sch, err := c.Compile(url)
+	if err != nil {
+		return nil, err
+	}
+
+	b := &bundler{c: c}
+	var root map[string]interface{}
+	defs := make(map[string]interface{})
+	for _, u := range sch.Graph().Resources {
+		doc, err := b.convert(u)
+		if err != nil {
+			return nil, err
+		}
+		m, ok := doc.(map[string]interface{})
+		if !ok {
+			m = map[string]interface{}{}
+			if doc == false {
+				m["not"] = map[string]interface{}{}
+			}
+		}
+		if u == sch.url() {
+			root = m
+			continue
+		}
+		delete(m, "$schema")
+		m["$id"] = u
+		defs[u] = m
+	}
+
+	if _, ok := root["$id"]; !ok && len(defs) > 0 {
+		// relative references must resolve to embedded resources
+		root["$id"] = sch.url()
+	}
+	root["$schema"] = Draft2020.URL()
+	if len(defs) > 0 {
+		rootDefs, ok := root["$defs"].(map[string]interface{})
+		if !ok {
+			rootDefs = make(map[string]interface{})
+			root["$defs"] = rootDefs
+		}
+		for u, doc := range defs {
+			key := u
+			for i := 2; rootDefs[key] != nil; i++ {
+				key = fmt.Sprintf("%s_%d", u, i)
+			}
+			rootDefs[key] = doc
+		}
+	}
+	return root, nil
+}
+
+type bundler struct {
+	c *Compiler
+}
+
+// convert returns the document of resource at url u,
+// converted to draft 2020-12.
+func (b *bundler) convert(u string) (interface{}, error) {
+	r := b.c.Registry.root(u)
+	if r == nil {
+		return nil, fmt.Errorf("jsonschema: resource %s not found", u)
+	}
+	up := &upgrader{draft: r.draft, ref: b.ref}
+	return up.doc(r.doc, r.url), nil
+}
+
+// ref translates json-pointer fragment in ref, if it refers
+// a resource whose draft is older than 2020-12.
+func (b *bundler) ref(base, ref string) string {
+	abs, err := resolveURL(base, ref)
+	if err != nil {
+		return ref
+	}
+	u, f := split(abs)
+	if !strings.HasPrefix(f, "#/") || findDraft(u) != nil {
+		return ref
+	}
+	r := b.c.Registry.root(u)
+	if r == nil || r.draft.version >= 2020 {
+		return ref
+	}
+	sr := r.findResource(u)
+	if sr == nil {
+		return ref
+	}
+	up := &upgrader{draft: r.draft}
+	ptr := up.ptr(sr.doc, f[1:])
+	if i := strings.IndexByte(ref, '#'); i != -1 {
+		ref = ref[:i]
+	}
+	return ref + "#" + ptr
+}
@@ -0,0 +1,125 @@
+package jsonschema_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"testing"
+
+	"github.com/santhosh-tekuri/jsonschema/v5"
+)
+
+func TestBundle(t *testing.T) {
+	docs := map[string]string{
+		"map:///root.json": `{
+			"$schema": "http://json-schema.org/draft-07/schema#",
+			"type": "object",
+			"properties": {
+				"tuple": {"$ref": "draft4.json#/definitions/tuple"},
+				"deps": {"$ref": "draft4.json#/definitions/deps"},
+				"max": {"$ref": "draft4.json#/properties/max"},
+				"min": {"$ref": "draft4.json#/properties/min"},
+				"code": {"$ref": "draft4.json#/definitions/code"},
+				"tree": {"$ref": "tree.json"},
+				"name": {"$ref": "#/definitions/name"},
+				"anchored": {"$ref": "#anchored"}
+			},
+			"definitions": {
+				"name": {"$ref": "common.json#/$defs/name", "type": "integer"},
+				"anchored": {"$id": "#anchored", "const": 1}
+			}
+		}`,
+		"map:///draft4.json": `{
+			"$schema": "http://json-schema.org/draft-04/schema#",
+			"id": "map:///draft4.json",
+			"properties": {
+				"max": {"maximum": 10, "exclusiveMaximum": true},
+				"min": {"minimum": 0, "exclusiveMinimum": true}
+			},
+			"definitions": {
+				"code": {"id": "#code", "type": "string", "pattern": "^[A-Z]+$"},
+				"tuple": {
+					"items": [{"type": "integer"}, {"type": "string"}],
+					"additionalItems": false
+				},
+				"deps": {
+					"dependencies": {
+						"a": ["b"],
+						"c": {"required": ["d"]}
+					}
+				}
+			}
+		}`,
+		"map:///tree.json": `{
+			"$schema": "https://json-schema.org/draft/2019-09/schema",
+			"$recursiveAnchor": true,
+			"type": "object",
+			"properties": {
+				"children": {"type": "array", "items": {"$recursiveRef": "#"}}
+			}
+		}`,
+		"map:///common.json": `{
+			"$defs": {
+				"name": {"type": "string", "minLength": 2}
+			}
+		}`,
+	}
+	instances := []string{
+		`{}`,
+		`{"tuple": [1, "a"]}`,
+		`{"tuple": [1, "a", 3]}`,
+		`{"tuple": ["a"]}`,
+		`{"deps": {"a": 1}}`,
+		`{"deps": {"a": 1, "b": 2}}`,
+		`{"deps": {"c": 1}}`,
+		`{"deps": {"c": 1, "d": 1}}`,
+		`{"max": 9}`,
+		`{"max": 10}`,
+		`{"min": 1}`,
+		`{"min": 0}`,
+		`{"code": "AB"}`,
+		`{"code": "ab"}`,
+		`{"tree": {"children": [{"children": []}]}}`,
+		`{"tree": {"children": [1]}}`,
+		`{"name": "ab"}`,
+		`{"name": "a"}`,
+		`{"name": 12}`,
+		`{"anchored": 1}`,
+		`{"anchored": 2}`,
+	}
+
+	c := jsonschema.NewCompiler()
+	c.Loader = &mapLoader{docs: docs}
+	want, err := c.Compile("map:///root.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	bundle, err := c.Bundle("map:///root.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := json.Marshal(bundle)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c = jsonschema.NewCompiler()
+	c.Loader = jsonschema.LoaderFunc(func(ctx context.Context, url string) (io.ReadCloser, error) {
+		return nil, fmt.Errorf("bundle must not load %s", url)
+	})
+	if err := c.AddResource("bundle.json", bytes.NewReader(b)); err != nil {
+		t.Fatal(err)
+	}
+	got, err := c.Compile("bundle.json")
+	if err != nil {
+		t.Fatalf("%v\n%s", err, b)
+	}
+	for _, instance := range instances {
+		v := decodeString(t, instance)
+		if wantErr, gotErr := want.Validate(v), got.Validate(v); (wantErr == nil) != (gotErr == nil) {
+			t.Errorf("%s: got %v, want %v", instance, gotErr, wantErr)
+		}
+	}
+}
@@ -0,0 +1,127 @@
+package jsonschema
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// Catalog is a Loader, which maps urls to local files, in the spirit
+// of XML catalogs. This is useful to compile schemas referring to remote
+// urls, without network access.
+//
+// Catalog can be unmarshalled from json or yaml:
+//
+//	{
+//	    "strict": true,
+//	    "entries": [
+//	        {"url": "https://example.com/person.json", "path": "person.json"},
+//	        {"prefix": "https://schemas.example.com/common/", "path": "common"}
+//	    ]
+//	}
+type Catalog struct {
+	// Entries maps urls to local paths. Entry with exact url takes
+	// precedence over prefix entries, and longer prefix takes precedence
+	// over shorter one.
+	Entries []CatalogEntry `json:"entries" yaml:"entries"`
+
+	// Strict tells to fail loading the urls not mapped by Entries.
+	Strict bool `json:"strict,omitempty" yaml:"strict,omitempty"`
+
+	// Dir is the directory against which relative paths in Entries
+	// are resolved. If empty, current directory is used.
+	Dir string `json:"-" yaml:"-"`
+
+	// Fallback loads the urls not mapped by Entries, when not Strict.
+	// If nil, package global LoadURL is used.
+	Fallback Loader `json:"-" yaml:"-"`
+}
+
+// CatalogEntry maps either an exact URL or urls with given Prefix
+// to local Path. Path is file for URL, and directory for Prefix.
+type CatalogEntry struct {
+	URL    string `json:"url,omitempty" yaml:"url,omitempty"`
+	Prefix string `json:"prefix,omitempty" yaml:"prefix,omitempty"`
+	Path   string `json:"path" yaml:"path"`
+
+	open func(name string) (io.ReadCloser, error) // opens name relative to Path. nil means os.Open
+}
+
+// LoadCatalog reads Catalog from given json file. Relative paths in the
+// catalog are resolved against the directory of file.
+func LoadCatalog(file string) (*Catalog, error) {
+	return LoadCatalogFunc(file, json.Unmarshal)
+}
+
+// LoadCatalogFunc is like LoadCatalog, but decodes the file using unmarshal.
+// For yaml file, pass yaml.Unmarshal from gopkg.in/yaml.v3.
+func LoadCatalogFunc(file string, unmarshal func([]byte, interface{}) error) (*Catalog, error) {
+	b, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	c := &Catalog{}
+	if err := unmarshal(b, c); err != nil {
+		return nil, fmt.Errorf("jsonschema: invalid catalog %s: %v", file, err)
+	}
+	c.Dir = filepath.Dir(file)
+	return c, nil
+}
+
+// find returns the entry mapping url, and the path of url relative
+// to entry's Path.
+func (c *Catalog) find(url string) (*CatalogEntry, string) {
+	var match *CatalogEntry
+	for i := range c.Entries {
+		e := &c.Entries[i]
+		switch {
+		case e.URL != "":
+			if e.URL == url {
+				return e, ""
+			}
+		case e.Prefix != "":
+			if strings.HasPrefix(url, e.Prefix) && (match == nil || len(e.Prefix) > len(match.Prefix)) {
+				match = e
+			}
+		}
+	}
+	if match == nil {
+		return nil, ""
+	}
+	// clean, so that url cannot escape Path using ".."
+	return match, strings.TrimPrefix(path.Clean("/"+url[len(match.Prefix):]), "/")
+}
+
+// Maps tells whether url is mapped by the catalog.
+func (c *Catalog) Maps(url string) bool {
+	e, _ := c.find(url)
+	return e != nil
+}
+
+// Load implements Loader.
+func (c *Catalog) Load(ctx context.Context, url string) (io.ReadCloser, error) {
+	e, name := c.find(url)
+	if e == nil {
+		if c.Strict {
+			return nil, fmt.Errorf("jsonschema: %s is not mapped in catalog", url)
+		}
+		if c.Fallback != nil {
+			return c.Fallback.Load(ctx, url)
+		}
+		return LoadURL(url)
+	}
+	if e.open != nil {
+		return e.open(name)
+	}
+	file := filepath.Join(e.Path, filepath.FromSlash(name))
+	if !filepath.IsAbs(file) {
+		file = filepath.Join(c.Dir, file)
+	}
+	return os.Open(file)
+}
@@ -0,0 +1,21 @@
+//go:build go1.16
+// +build go1.16
+
+package jsonschema
+
+import (
+	"io"
+	"io/fs"
+	"path"
+)
+
+// MapFS adds an entry, mapping the urls with given prefix to dir in fsys.
+func (c *Catalog) MapFS(prefix string, fsys fs.FS, dir string) {
+	c.Entries = append(c.Entries, CatalogEntry{
+		Prefix: prefix,
+		Path:   dir,
+		open: func(name string) (io.ReadCloser, error) {
+			return fsys.Open(path.Join(dir, name))
+		},
+	})
+}
@@ -0,0 +1,32 @@
+//go:build go1.16
+// +build go1.16
+
+package jsonschema_test
+
+import (
+	"testing"
+	"testing/fstest"
+
+	"github.com/santhosh-tekuri/jsonschema/v5"
+)
+
+func TestCatalogFS(t *testing.T) {
+	fsys := fstest.MapFS{
+		"schemas/schema.json":    {Data: []byte(`{"$ref": "defs/name.json"}`)},
+		"schemas/defs/name.json": {Data: []byte(`{"type": "string"}`)},
+	}
+	cat := &jsonschema.Catalog{Strict: true}
+	cat.MapFS("https://example.com/", fsys, "schemas")
+	c := jsonschema.NewCompiler()
+	c.Loader = cat
+	sch, err := c.Compile("https://example.com/schema.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := sch.Validate(1); err == nil {
+		t.Fatal("error expected")
+	}
+	if _, err := c.Compile("https://other.com/schema.json"); err == nil {
+		t.Fatal("error expected")
+	}
+}
@@ -0,0 +1,80 @@
+package jsonschema_test
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/santhosh-tekuri/jsonschema/v5"
+)
+
+func TestCatalog(t *testing.T) {
+	dir, err := ioutil.TempDir("", "catalog")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	files := map[string]string{
+		"catalog.json": `{
+			"strict": true,
+			"entries": [
+				{"url": "https://example.com/schema.json", "path": "schema.json"},
+				{"prefix": "https://example.com/common/", "path": "common"},
+				{"prefix": "https://example.com/common/v2/", "path": "common2"}
+			]
+		}`,
+		"schema.json":         `{"$ref": "common/v2/name.json"}`,
+		"common/v2/name.json": `{"type": "integer"}`,
+		"common2/name.json":   `{"type": "string"}`,
+		"secret.json":         `{}`,
+	}
+	for name, content := range files {
+		file := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(file, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	cat, err := jsonschema.LoadCatalog(filepath.Join(dir, "catalog.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := jsonschema.NewCompiler()
+	c.Loader = cat
+	sch, err := c.Compile("https://example.com/schema.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// longest prefix wins
+	if err := sch.Validate("abc"); err != nil {
+		t.Fatal(err)
+	}
+
+	// not mapped in strict mode
+	if _, err := c.Compile("https://example.com/other.json"); err == nil {
+		t.Error("error expected")
+	}
+	// custom decoding, like yaml
+	decoded := false
+	cat, err = jsonschema.LoadCatalogFunc(filepath.Join(dir, "catalog.json"), func(b []byte, v interface{}) error {
+		decoded = true
+		return json.Unmarshal(b, v)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !decoded || len(cat.Entries) != 3 || cat.Dir != dir {
+		t.Errorf("got %#v", cat)
+	}
+
+	// must not escape the mapped directory
+	if _, err := cat.Load(context.Background(), "https://example.com/common/../secret.json"); err == nil {
+		t.Error("error expected")
+	}
+}
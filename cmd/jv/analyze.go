@@ -0,0 +1,61 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/santhosh-tekuri/jsonschema/v5"
+)
+
+func analyzeCmd(args []string) int {
+	fs := flag.NewFlagSet("analyze", flag.ExitOnError)
+	format := fs.String("format", "text", "output format. valid values text, json")
+	cf := addCompilerFlags(fs)
+	fs.Usage = func() {
+		fmt.Fprintln(os.Stderr, "jv analyze [-format FORMAT] [-draft INT] [-catalog FILE] [-lockfile FILE] <json-schema>...")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	if fs.NArg() == 0 {
+		fs.Usage()
+		return 1
+	}
+	if *format != "text" && *format != "json" {
+		fmt.Fprintln(os.Stderr, "format must be text or json")
+		return 1
+	}
+
+	compiler, err := cf.compiler()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	exitCode := 0
+	findings := []jsonschema.Finding{}
+	seen := make(map[jsonschema.Finding]bool) // schemas may share resources
+	for _, f := range fs.Args() {
+		schema, err := compiler.Compile(f)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%#v\n", err)
+			exitCode = 1
+			continue
+		}
+		for _, finding := range schema.Analyze() {
+			if !seen[finding] {
+				seen[finding] = true
+				findings = append(findings, finding)
+			}
+		}
+	}
+
+	if err := printFindings(*format, findings); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	if hasErrors(findings) {
+		exitCode = 1
+	}
+	return exitCode
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+)
+
+func bundleCmd(args []string) int {
+	fs := flag.NewFlagSet("bundle", flag.ExitOnError)
+	out := fs.String("o", "", "file to write bundle into. defaults to stdout")
+	cf := addCompilerFlags(fs)
+	fs.Usage = func() {
+		fmt.Fprintln(os.Stderr, "jv bundle [-o FILE] [-draft INT] [-catalog FILE] [-lockfile FILE] <json-schema>")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return 1
+	}
+	compiler, err := cf.compiler()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	bundle, err := compiler.Bundle(fs.Arg(0))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%#v\n", err)
+		return 1
+	}
+	b, err := json.MarshalIndent(bundle, "", "  ")
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	b = append(b, '\n')
+	if *out == "" {
+		_, err = os.Stdout.Write(b)
+	} else {
+		err = ioutil.WriteFile(*out, b, 0644)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	return 0
+}
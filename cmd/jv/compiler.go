@@ -0,0 +1,153 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/santhosh-tekuri/jsonschema/v5"
+	"gopkg.in/yaml.v3"
+)
+
+// compilerFlags are the flags to configure compiler,
+// common to all commands.
+type compilerFlags struct {
+	draft         *int
+	detectDraft   *bool
+	assertFormat  *bool
+	assertContent *bool
+	catalog       *string
+	lockfile      *string
+	writeLock     *bool // nil if command does not write lockfile
+}
+
+func addCompilerFlags(fs *flag.FlagSet) *compilerFlags {
+	return &compilerFlags{
+		draft:         fs.Int("draft", 2020, "draft used when '$schema' attribute is missing. valid values 3, 4, 5, 7, 2019, 2020"),
+		detectDraft:   fs.Bool("detectdraft", false, "infer draft from keywords used, when '$schema' attribute is missing"),
+		assertFormat:  fs.Bool("assertformat", false, "enable format assertions with draft >= 2019"),
+		assertContent: fs.Bool("assertcontent", false, "enable content assertions with draft >= 2019"),
+		catalog:       fs.String("catalog", "", "json or yaml file mapping urls to local files. local files not mapped are loaded directly"),
+		lockfile:      fs.String("lockfile", "", "lockfile to verify loaded documents against"),
+	}
+}
+
+func (f *compilerFlags) compiler() (*jsonschema.Compiler, error) {
+	compiler := jsonschema.NewCompiler()
+	switch *f.draft {
+	case 3:
+		compiler.Draft = jsonschema.Draft3
+	case 4:
+		compiler.Draft = jsonschema.Draft4
+	case 6:
+		compiler.Draft = jsonschema.Draft6
+	case 7:
+		compiler.Draft = jsonschema.Draft7
+	case 2019:
+		compiler.Draft = jsonschema.Draft2019
+	case 2020:
+		compiler.Draft = jsonschema.Draft2020
+	default:
+		return nil, errors.New("draft must be 3, 4, 5, 7, 2019 or 2020")
+	}
+	if *f.detectDraft {
+		compiler.DetectDraft = true
+		compiler.OnDraftWarning = func(url string, draft *jsonschema.Draft, warning string) {
+			fmt.Fprintf(os.Stderr, "warning: %s: %s\n", url, warning)
+		}
+	}
+
+	var loader jsonschema.Loader = jsonschema.LoaderFunc(func(ctx context.Context, s string) (io.ReadCloser, error) {
+		return jsonschema.LoadURL(s)
+	})
+	if *f.catalog != "" {
+		cat, err := loadCatalog(*f.catalog)
+		if err != nil {
+			return nil, err
+		}
+		loader = jsonschema.LoaderFunc(func(ctx context.Context, s string) (io.ReadCloser, error) {
+			if strings.HasPrefix(s, "file:") && !cat.Maps(s) {
+				return jsonschema.LoadURL(s)
+			}
+			return cat.Load(ctx, s)
+		})
+	}
+	compiler.Loader = yamlLoader(loader)
+
+	writeLock := f.writeLock != nil && *f.writeLock
+	if writeLock && *f.lockfile == "" {
+		return nil, errors.New("-writelock requires -lockfile")
+	}
+	if writeLock {
+		compiler.Lockfile = jsonschema.NewLockfile()
+	} else if *f.lockfile != "" {
+		lf, err := readLockfile(*f.lockfile)
+		if err != nil {
+			return nil, err
+		}
+		compiler.Lockfile = lf
+	}
+	compiler.AssertFormat = *f.assertFormat
+	compiler.AssertContent = *f.assertContent
+	return compiler, nil
+}
+
+// yamlLoader wraps l, to convert yaml documents to json.
+func yamlLoader(l jsonschema.Loader) jsonschema.Loader {
+	return jsonschema.LoaderFunc(func(ctx context.Context, s string) (io.ReadCloser, error) {
+		r, err := l.Load(ctx, s)
+		if err != nil {
+			return nil, err
+		}
+		if strings.HasSuffix(s, ".yaml") || strings.HasSuffix(s, ".yml") {
+			defer r.Close()
+			v, err := decodeYAML(r, s)
+			if err != nil {
+				return nil, err
+			}
+			b, err := json.Marshal(v)
+			if err != nil {
+				return nil, err
+			}
+			return ioutil.NopCloser(bytes.NewReader(b)), nil
+		}
+		return r, err
+	})
+}
+
+func loadCatalog(file string) (*jsonschema.Catalog, error) {
+	switch filepath.Ext(file) {
+	case ".yaml", ".yml":
+		return jsonschema.LoadCatalogFunc(file, yaml.Unmarshal)
+	}
+	return jsonschema.LoadCatalog(file)
+}
+
+func readLockfile(file string) (*jsonschema.Lockfile, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return jsonschema.ReadLockfile(f)
+}
+
+func writeLockfile(file string, lf *jsonschema.Lockfile) error {
+	f, err := os.Create(file)
+	if err != nil {
+		return err
+	}
+	if err := lf.Write(f); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/santhosh-tekuri/jsonschema/v5"
+)
+
+func diffCmd(args []string) int {
+	fs := flag.NewFlagSet("diff", flag.ExitOnError)
+	format := fs.String("format", "text", "output format. valid values text, json")
+	mode := fs.String("mode", "backward", "compatibility required. valid values backward, forward, full, none")
+	cf := addCompilerFlags(fs)
+	fs.Usage = func() {
+		fmt.Fprintln(os.Stderr, "jv diff [-mode MODE] [-format FORMAT] [-draft INT] [-catalog FILE] [-lockfile FILE] <old-json-schema> <new-json-schema>")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	if fs.NArg() != 2 {
+		fs.Usage()
+		return 1
+	}
+	if *format != "text" && *format != "json" {
+		fmt.Fprintln(os.Stderr, "format must be text or json")
+		return 1
+	}
+	required := jsonschema.Compatibility(*mode)
+	switch required {
+	case jsonschema.CompatBackward, jsonschema.CompatForward, jsonschema.CompatFull, jsonschema.CompatNone:
+	default:
+		fmt.Fprintln(os.Stderr, "mode must be backward, forward, full or none")
+		return 1
+	}
+
+	// versions may have same $id, so they need separate compilers
+	var schemas [2]*jsonschema.Schema
+	for i, f := range fs.Args() {
+		compiler, err := cf.compiler()
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+		if schemas[i], err = compiler.Compile(f); err != nil {
+			fmt.Fprintf(os.Stderr, "%#v\n", err)
+			return 1
+		}
+	}
+
+	changes := jsonschema.Diff(schemas[0], schemas[1])
+	if *format == "json" {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(struct {
+			Compatibility jsonschema.Compatibility `json:"compatibility"`
+			Changes       []jsonschema.Change      `json:"changes"`
+		}{jsonschema.CompatibilityOf(changes), append([]jsonschema.Change{}, changes...)}); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+	} else {
+		for _, c := range changes {
+			fmt.Println(c)
+		}
+		fmt.Println("compatibility:", jsonschema.CompatibilityOf(changes))
+	}
+
+	for _, c := range changes {
+		if !c.Compatibility.Includes(required) {
+			return 1
+		}
+	}
+	return 0
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+)
+
+func graphCmd(args []string) int {
+	fs := flag.NewFlagSet("graph", flag.ExitOnError)
+	format := fs.String("format", "json", "output format. valid values json, dot")
+	cf := addCompilerFlags(fs)
+	fs.Usage = func() {
+		fmt.Fprintln(os.Stderr, "jv graph [-format FORMAT] [-draft INT] [-catalog FILE] [-lockfile FILE] <json-schema>")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return 1
+	}
+	if *format != "json" && *format != "dot" {
+		fmt.Fprintln(os.Stderr, "format must be json or dot")
+		return 1
+	}
+
+	compiler, err := cf.compiler()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	schema, err := compiler.Compile(fs.Arg(0))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%#v\n", err)
+		return 1
+	}
+
+	g := schema.Graph()
+	if *format == "dot" {
+		err = g.WriteDOT(os.Stdout)
+	} else {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		err = enc.Encode(g)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,130 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/santhosh-tekuri/jsonschema/v5"
+)
+
+func lintCmd(args []string) int {
+	fs := flag.NewFlagSet("lint", flag.ExitOnError)
+	format := fs.String("format", "text", "output format. valid values text, json")
+	examples := fs.Bool("examples", true, "validate default, examples and const values against their schema")
+	cf := addCompilerFlags(fs)
+	fs.Usage = func() {
+		fmt.Fprintln(os.Stderr, "jv lint [-format FORMAT] [-examples=false] [-draft INT] [-catalog FILE] [-lockfile FILE] <json-schema>...")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	if fs.NArg() == 0 {
+		fs.Usage()
+		return 1
+	}
+	if *format != "text" && *format != "json" {
+		fmt.Fprintln(os.Stderr, "format must be text or json")
+		return 1
+	}
+
+	compiler, err := cf.compiler()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	exitCode := 0
+	findings := []jsonschema.Finding{}
+	seen := make(map[jsonschema.Finding]bool) // schemas may share resources
+	for _, f := range fs.Args() {
+		list, err := compiler.Lint(f)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%#v\n", err)
+			exitCode = 1
+			continue
+		}
+		if *examples {
+			schema, err := compiler.Compile(f)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "%#v\n", err)
+				exitCode = 1
+				continue
+			}
+			list = append(list, exampleFindings(schema.CheckExamples())...)
+			sort.SliceStable(list, func(i, j int) bool {
+				return list[i].Location < list[j].Location
+			})
+		}
+		for _, finding := range list {
+			if !seen[finding] {
+				seen[finding] = true
+				findings = append(findings, finding)
+			}
+		}
+	}
+
+	if err := printFindings(*format, findings); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	if hasErrors(findings) {
+		exitCode = 1
+	}
+	return exitCode
+}
+
+// printFindings prints findings to stdout in given format.
+func printFindings(format string, findings []jsonschema.Finding) error {
+	if format == "json" {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(findings)
+	}
+	for _, f := range findings {
+		fmt.Println(f)
+	}
+	return nil
+}
+
+// exampleFindings returns a finding for each leaf error of
+// invalid example values.
+func exampleFindings(errs []*jsonschema.ExampleError) []jsonschema.Finding {
+	var findings []jsonschema.Finding
+	for _, e := range errs {
+		add := func(msg string) {
+			findings = append(findings, jsonschema.Finding{
+				Location: e.KeywordLocation,
+				Severity: jsonschema.SeverityError,
+				Rule:     "invalid-example",
+				Message:  msg,
+			})
+		}
+		ve, ok := e.Err.(*jsonschema.ValidationError)
+		if !ok {
+			add(fmt.Sprintf("value is not valid: %v", e.Err))
+			continue
+		}
+		var leaves func(ve *jsonschema.ValidationError)
+		leaves = func(ve *jsonschema.ValidationError) {
+			if len(ve.Causes) == 0 {
+				add(fmt.Sprintf("value at %q is not valid: %s, at %s", ve.InstanceLocation, ve.Message, ve.AbsoluteKeywordLocation))
+			}
+			for _, cause := range ve.Causes {
+				leaves(cause)
+			}
+		}
+		leaves(ve)
+	}
+	return findings
+}
+
+func hasErrors(findings []jsonschema.Finding) bool {
+	for _, f := range findings {
+		if f.Severity == jsonschema.SeverityError {
+			return true
+		}
+	}
+	return false
+}
@@ -1,15 +1,13 @@
 package main
 
 import (
-	"bytes"
 	"encoding/json"
 	"flag"
 	"fmt"
 	"io"
-	"io/ioutil"
 	"os"
 	"path/filepath"
-	"strings"
+	"sort"
 
 	"github.com/santhosh-tekuri/jsonschema/v5"
 	_ "github.com/santhosh-tekuri/jsonschema/v5/httploader"
@@ -17,15 +15,52 @@ import (
 )
 
 func usage() {
-	fmt.Fprintln(os.Stderr, "jv [-draft INT] [-output FORMAT] [-assertformat] [-assertcontent] <json-schema> [<json-or-yaml-doc>]...")
+	fmt.Fprintln(os.Stderr, "jv [-draft INT] [-detectdraft] [-output FORMAT] [-assertformat] [-assertcontent] [-catalog FILE] [-lockfile FILE [-writelock]] [-strict] <json-schema> [<json-or-yaml-doc>]...")
+	fmt.Fprintln(os.Stderr, "jv <command> [flags] <json-schema>...")
 	flag.PrintDefaults()
+	fmt.Fprintln(os.Stderr, "\ncommands:")
+	for _, name := range sortedCommands() {
+		fmt.Fprintf(os.Stderr, "  %-10s %s\n", name, commands[name].desc)
+	}
+}
+
+// command is a jv subcommand. validation is the default command.
+type command struct {
+	desc string
+	run  func(args []string) int
+}
+
+var commands = map[string]command{
+	"analyze": {"report unsatisfiable subschemas and redundant constraints", analyzeCmd},
+	"bundle":  {"bundle schema into single document", bundleCmd},
+	"diff":    {"report changes between two versions of schema, and their compatibility", diffCmd},
+	"graph":   {"print dependency graph of schema", graphCmd},
+	"lint":    {"report unknown keywords and other mistakes in schema", lintCmd},
+	"migrate": {"convert schema of older draft to draft 2020-12", migrateCmd},
+	"vendor":  {"download remote documents referred by schema", vendorCmd},
+}
+
+func sortedCommands() []string {
+	var names []string
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
 }
 
 func main() {
-	draft := flag.Int("draft", 2020, "draft used when '$schema' attribute is missing. valid values 4, 5, 7, 2019, 2020")
+	if len(os.Args) > 1 {
+		if cmd, ok := commands[os.Args[1]]; ok {
+			os.Exit(cmd.run(os.Args[2:]))
+		}
+	}
+
 	output := flag.String("output", "", "output format. valid values flag, basic, detailed")
-	assertFormat := flag.Bool("assertformat", false, "enable format assertions with draft >= 2019")
-	assertContent := flag.Bool("assertcontent", false, "enable content assertions with draft >= 2019")
+	cf := addCompilerFlags(flag.CommandLine)
+	writeLock := flag.Bool("writelock", false, "write lockfile with digests of loaded documents, instead of verifying")
+	cf.writeLock = writeLock
+	strict := flag.Bool("strict", false, "fail if schema has mistakes reported by lint command")
 	flag.Usage = usage
 	flag.Parse()
 	if len(flag.Args()) == 0 {
@@ -33,26 +68,13 @@ func main() {
 		os.Exit(1)
 	}
 
-	compiler := jsonschema.NewCompiler()
-	switch *draft {
-	case 4:
-		compiler.Draft = jsonschema.Draft4
-	case 6:
-		compiler.Draft = jsonschema.Draft6
-	case 7:
-		compiler.Draft = jsonschema.Draft7
-	case 2019:
-		compiler.Draft = jsonschema.Draft2019
-	case 2020:
-		compiler.Draft = jsonschema.Draft2020
-	default:
-		fmt.Fprintln(os.Stderr, "draft must be 4, 5, 7, 2019 or 2020")
+	compiler, err := cf.compiler()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
 		os.Exit(1)
 	}
 
-	compiler.LoadURL = loadURL
-	compiler.AssertFormat = *assertFormat
-	compiler.AssertContent = *assertContent
+	compiler.Strict = *strict
 
 	var validOutput bool
 	for _, out := range []string{"", "flag", "basic", "detailed"} {
@@ -71,6 +93,12 @@ func main() {
 		fmt.Fprintf(os.Stderr, "%#v\n", err)
 		os.Exit(1)
 	}
+	if *writeLock {
+		if err := writeLockfile(*cf.lockfile, compiler.Lockfile); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+	}
 
 	exitCode := 0
 	for _, f := range flag.Args()[1:] {
@@ -120,26 +148,6 @@ func main() {
 	os.Exit(exitCode)
 }
 
-func loadURL(s string) (io.ReadCloser, error) {
-	r, err := jsonschema.LoadURL(s)
-	if err != nil {
-		return nil, err
-	}
-	if strings.HasSuffix(s, ".yaml") || strings.HasSuffix(s, ".yml") {
-		defer r.Close()
-		v, err := decodeYAML(r, s)
-		if err != nil {
-			return nil, err
-		}
-		b, err := json.Marshal(v)
-		if err != nil {
-			return nil, err
-		}
-		return ioutil.NopCloser(bytes.NewReader(b)), nil
-	}
-	return r, err
-}
-
 func decodeFile(file *os.File) (interface{}, error) {
 	ext := filepath.Ext(file.Name())
 	if ext == ".yaml" || ext == ".yml" {
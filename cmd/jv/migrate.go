@@ -0,0 +1,67 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	"github.com/santhosh-tekuri/jsonschema/v5"
+)
+
+func migrateCmd(args []string) int {
+	fs := flag.NewFlagSet("migrate", flag.ExitOnError)
+	to := fs.Int("to", 2020, "draft to migrate to. valid values 2020")
+	out := fs.String("o", "", "file to write migrated schema into. defaults to stdout")
+	inPlace := fs.Bool("w", false, "write migrated schema back to its file, instead of stdout")
+	cf := addCompilerFlags(fs)
+	fs.Usage = func() {
+		fmt.Fprintln(os.Stderr, "jv migrate [-to 2020] [-o FILE] [-draft INT] [-catalog FILE] [-lockfile FILE] <json-schema>")
+		fmt.Fprintln(os.Stderr, "jv migrate [-to 2020] -w [-draft INT] [-catalog FILE] [-lockfile FILE] <json-schema-file>...")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	if fs.NArg() == 0 || (!*inPlace && fs.NArg() != 1) || (*inPlace && *out != "") {
+		fs.Usage()
+		return 1
+	}
+	if *to != 2020 {
+		fmt.Fprintln(os.Stderr, "to must be 2020")
+		return 1
+	}
+	compiler, err := cf.compiler()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	exitCode := 0
+	for _, f := range fs.Args() {
+		doc, err := compiler.Migrate(f, jsonschema.Draft2020)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%#v\n", err)
+			exitCode = 1
+			continue
+		}
+		b, err := json.MarshalIndent(doc, "", "  ")
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+		b = append(b, '\n')
+		switch {
+		case *inPlace:
+			err = ioutil.WriteFile(f, b, 0644)
+		case *out != "":
+			err = ioutil.WriteFile(*out, b, 0644)
+		default:
+			_, err = os.Stdout.Write(b)
+		}
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+	}
+	return exitCode
+}
@@ -0,0 +1,182 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/santhosh-tekuri/jsonschema/v5"
+)
+
+func vendorCmd(args []string) int {
+	fs := flag.NewFlagSet("vendor", flag.ExitOnError)
+	dir := fs.String("dir", "vendor", "directory to write documents and catalog.json into")
+	cf := addCompilerFlags(fs)
+	fs.Usage = func() {
+		fmt.Fprintln(os.Stderr, "jv vendor [-dir DIR] [-draft INT] [-catalog FILE] [-lockfile FILE] <json-schema>")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return 1
+	}
+	compiler, err := cf.compiler()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	if err := vendor(compiler, fs.Arg(0), *dir, os.Stdout); err != nil {
+		fmt.Fprintf(os.Stderr, "%#v\n", err)
+		return 1
+	}
+	return 0
+}
+
+// vendor compiles schema, and writes the remote documents loaded into
+// dir, mirroring their urls, along with catalog.json mapping the urls.
+// The documents added, changed and removed since last run are reported to w.
+func vendor(compiler *jsonschema.Compiler, schema, dir string, w io.Writer) error {
+	rec := &recordLoader{loader: compiler.Loader, docs: make(map[string][]byte)}
+	if rec.loader == nil {
+		rec.loader = jsonschema.LoaderFunc(func(ctx context.Context, s string) (io.ReadCloser, error) {
+			return jsonschema.LoadURL(s)
+		})
+	}
+	compiler.Loader = rec
+	if _, err := compiler.Compile(schema); err != nil {
+		return err
+	}
+
+	catalogFile := filepath.Join(dir, "catalog.json")
+	old := make(map[string]string) // url to path
+	if _, err := os.Stat(catalogFile); err == nil {
+		cat, err := jsonschema.LoadCatalog(catalogFile)
+		if err != nil {
+			return err
+		}
+		for _, e := range cat.Entries {
+			old[e.URL] = e.Path
+		}
+	}
+
+	cat := &jsonschema.Catalog{Strict: true, Entries: []jsonschema.CatalogEntry{}}
+	paths := make(map[string]string) // path to url
+	for _, u := range rec.urls() {
+		p, err := vendorPath(u)
+		if err != nil {
+			return err
+		}
+		if other, ok := paths[p]; ok {
+			return fmt.Errorf("%s and %s are vendored at same path %s", other, u, p)
+		}
+		paths[p] = u
+		file := filepath.Join(dir, filepath.FromSlash(p))
+		doc := rec.docs[u]
+		if oldPath, ok := old[u]; !ok {
+			fmt.Fprintln(w, "added", u)
+		} else if b, err := ioutil.ReadFile(filepath.Join(dir, filepath.FromSlash(oldPath))); err != nil || !bytes.Equal(b, doc) {
+			fmt.Fprintln(w, "changed", u)
+		}
+		delete(old, u)
+		if err := os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+			return err
+		}
+		if err := ioutil.WriteFile(file, doc, 0644); err != nil {
+			return err
+		}
+		cat.Entries = append(cat.Entries, jsonschema.CatalogEntry{URL: u, Path: p})
+	}
+	var removed []string
+	for u := range old {
+		removed = append(removed, u)
+	}
+	sort.Strings(removed)
+	for _, u := range removed {
+		fmt.Fprintln(w, "removed", u)
+		if _, ok := paths[old[u]]; ok {
+			// path is reused by another url
+			continue
+		}
+		if err := os.Remove(filepath.Join(dir, filepath.FromSlash(old[u]))); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+
+	b, err := json.MarshalIndent(cat, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(catalogFile, append(b, '\n'), 0644)
+}
+
+// vendorPath returns the slash separated path, relative to vendor
+// directory, for document at url s. For example
+// "https://example.com/a/b.json" is vendored at "https/example.com/a/b.json".
+// Query is escaped into the file name, before its extension.
+func vendorPath(s string) (string, error) {
+	u, err := url.Parse(s)
+	if err != nil {
+		return "", err
+	}
+	p := path.Clean("/" + u.Path)
+	if p == "/" {
+		p = "/index.json"
+	}
+	if u.RawQuery != "" {
+		ext := path.Ext(p)
+		p = strings.TrimSuffix(p, ext) + "_" + url.QueryEscape(u.RawQuery) + ext
+	}
+	host := strings.ReplaceAll(u.Host, ":", "_")
+	if host == "" {
+		host = "_"
+	}
+	return u.Scheme + "/" + host + p, nil
+}
+
+// recordLoader records the remote documents loaded by loader.
+// Documents from file and data urls are not recorded.
+type recordLoader struct {
+	loader jsonschema.Loader
+	mu     sync.Mutex
+	docs   map[string][]byte // key is url
+}
+
+func (l *recordLoader) Load(ctx context.Context, url string) (io.ReadCloser, error) {
+	r, err := l.loader.Load(ctx, url)
+	if err != nil || strings.HasPrefix(url, "file:") || strings.HasPrefix(url, "data:") {
+		return r, err
+	}
+	defer r.Close()
+	b, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	l.mu.Lock()
+	l.docs[url] = b
+	l.mu.Unlock()
+	return ioutil.NopCloser(bytes.NewReader(b)), nil
+}
+
+func (l *recordLoader) urls() []string {
+	var urls []string
+	for u := range l.docs {
+		urls = append(urls, u)
+	}
+	sort.Strings(urls)
+	return urls
+}
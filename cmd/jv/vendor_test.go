@@ -0,0 +1,129 @@
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/santhosh-tekuri/jsonschema/v5"
+)
+
+func TestVendor(t *testing.T) {
+	var mu sync.Mutex
+	docs := map[string]string{
+		"/a.json":        `{"$ref": "defs/b.json"}`,
+		"/defs/b.json":   `{"type": "string"}`,
+		"/defs/c.json":   `{"minLength": 2}`,
+		"/defs/new.json": `{}`,
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		doc, ok := docs[r.URL.Path]
+		mu.Unlock()
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte(doc))
+	}))
+	defer server.Close()
+	setDoc := func(path, doc string) {
+		mu.Lock()
+		docs[path] = doc
+		mu.Unlock()
+	}
+
+	dir, err := ioutil.TempDir("", "vendor")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	schema := filepath.Join(dir, "schema.json")
+	writeSchema := func(doc string) {
+		if err := ioutil.WriteFile(schema, []byte(doc), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	writeSchema(`{"allOf": [{"$ref": "` + server.URL + `/a.json"}, {"$ref": "` + server.URL + `/defs/c.json"}]}`)
+
+	vendorDir := filepath.Join(dir, "vendor")
+	run := func() []string {
+		t.Helper()
+		var out bytes.Buffer
+		if err := vendor(jsonschema.NewCompiler(), schema, vendorDir, &out); err != nil {
+			t.Fatal(err)
+		}
+		return strings.Fields(out.String())
+	}
+	check := func(got []string, want ...string) {
+		t.Helper()
+		if strings.Join(got, " ") != strings.Join(want, " ") {
+			t.Fatalf("got %q, want %q", got, want)
+		}
+	}
+
+	check(run(), "added", server.URL+"/a.json", "added", server.URL+"/defs/b.json", "added", server.URL+"/defs/c.json")
+	check(run())
+
+	// update
+	setDoc("/defs/b.json", `{"type": "integer"}`)
+	writeSchema(`{"allOf": [{"$ref": "` + server.URL + `/a.json"}, {"$ref": "` + server.URL + `/defs/new.json"}]}`)
+	check(run(), "changed", server.URL+"/defs/b.json", "added", server.URL+"/defs/new.json", "removed", server.URL+"/defs/c.json")
+	removed, err := vendorPath(server.URL + "/defs/c.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(vendorDir, filepath.FromSlash(removed))); !os.IsNotExist(err) {
+		t.Fatalf("%s is not removed", removed)
+	}
+
+	// compile offline with vendored catalog
+	server.Close()
+	cat, err := jsonschema.LoadCatalog(filepath.Join(vendorDir, "catalog.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cat.Entries) != 3 {
+		t.Fatalf("got %d catalog entries, want 3", len(cat.Entries))
+	}
+	c := jsonschema.NewCompiler()
+	c.Loader = cat
+	if err := c.AddResource("file:///schema.json", strings.NewReader(`{"$ref": "`+server.URL+`/a.json"}`)); err != nil {
+		t.Fatal(err)
+	}
+	sch, err := c.Compile("file:///schema.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := sch.Validate("abc"); err == nil {
+		t.Fatal("error expected")
+	}
+}
+
+func TestVendorPath(t *testing.T) {
+	tests := []struct {
+		url, want string
+	}{
+		{"https://example.com/a/b.json", "https/example.com/a/b.json"},
+		{"https://example.com", "https/example.com/index.json"},
+		{"http://localhost:8080/a.json", "http/localhost_8080/a.json"},
+		{"https://example.com/s.json?v=1", "https/example.com/s_v%3D1.json"},
+		{"https://example.com/s.json?v=2", "https/example.com/s_v%3D2.json"},
+		{"https://example.com/s?v=1", "https/example.com/s_v%3D1"},
+	}
+	for _, test := range tests {
+		got, err := vendorPath(test.url)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != test.want {
+			t.Errorf("%s: got %q, want %q", test.url, got, test.want)
+		}
+	}
+}
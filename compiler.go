@@ -1,22 +1,46 @@
 package jsonschema
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"math/big"
+	"reflect"
 	"regexp"
+	"sort"
 	"strconv"
 	"strings"
 )
 
 // A Compiler represents a json-schema compiler.
+//
+// Compiler is safe for concurrent use, once its fields are set
+// and its extensions are registered.
 type Compiler struct {
 	// Draft represents the draft used when '$schema' attribute is missing.
 	//
 	// This defaults to latest supported draft (currently 2020-12).
-	Draft     *Draft
-	resources map[string]*resource
+	Draft *Draft
+
+	// DraftOverrides maps url prefix to the draft used, when '$schema'
+	// attribute is missing in resources under it. Longest prefix wins.
+	DraftOverrides map[string]*Draft
+
+	// DetectDraft tells whether to infer the draft from the keywords used,
+	// when '$schema' attribute is missing and DraftOverrides do not match.
+	// Draft is used, if keywords do not tell the draft.
+	DetectDraft bool
+
+	// OnDraftWarning, if not nil, is called when keywords used by resource
+	// at url are of different drafts. draft is the draft inferred.
+	OnDraftWarning func(url string, draft *Draft, warning string)
+
+	// Registry holds resources added and loaded by compiler, along
+	// with their compiled schemas. It can be shared by compilers with
+	// same settings.
+	Registry *Registry
 
 	// Extensions is used to register extensions.
 	extensions map[string]extension
@@ -25,11 +49,29 @@ type Compiler struct {
 	// in compiled Schema or not.
 	ExtractAnnotations bool
 
+	// Loader loads the documents referred by the schemas.
+	//
+	// If nil, LoadURL is used.
+	Loader Loader
+
 	// LoadURL loads the document at given absolute URL.
+	// It is used only if Loader is nil.
 	//
 	// If nil, package global LoadURL is used.
 	LoadURL func(s string) (io.ReadCloser, error)
 
+	// Policy restricts the resources referred by the schemas.
+	// If nil, there are no restrictions.
+	Policy *Policy
+
+	// Lockfile, if not nil, is used to verify or record the digests
+	// of used documents.
+	Lockfile *Lockfile
+
+	// Strict makes Compile fail, if Lint finds errors in schema.
+	// Such errors are reported as StrictError.
+	Strict bool
+
 	// Formats can be registered by adding to this map. Key is format name,
 	// value is function that knows how to validate that format.
 	Formats map[string]func(interface{}) bool
@@ -47,6 +89,14 @@ type Compiler struct {
 
 	// AssertContent for specifications >= draft2019-09.
 	AssertContent bool
+
+	// Memoize tells whether results of subschemas are memoized within
+	// single validation, so that the same instance value is not validated
+	// against the same subschema again. This helps schemas which apply
+	// shared definitions via oneOf/anyOf, especially recursive ones.
+	//
+	// Use Schema.MemoStats to find hit rates.
+	Memoize bool
 }
 
 // Compile parses json-schema at given url returns, if successful,
@@ -88,7 +138,7 @@ func MustCompileString(url, schema string) *Schema {
 func NewCompiler() *Compiler {
 	return &Compiler{
 		Draft:      latest,
-		resources:  make(map[string]*resource),
+		Registry:   NewRegistry(),
 		Formats:    make(map[string]func(interface{}) bool),
 		Decoders:   make(map[string]func(string) ([]byte, error)),
 		MediaTypes: make(map[string]func([]byte) error),
@@ -100,12 +150,7 @@ func NewCompiler() *Compiler {
 //
 // Note that url must not have fragment
 func (c *Compiler) AddResource(url string, r io.Reader) error {
-	res, err := newResource(url, r)
-	if err != nil {
-		return err
-	}
-	c.resources[res.url] = res
-	return nil
+	return c.Registry.AddResource(url, r)
 }
 
 // MustCompile is like Compile but panics if the url cannot be compiled to *Schema.
@@ -121,8 +166,18 @@ func (c *Compiler) MustCompile(url string) *Schema {
 // Compile parses json-schema at given url returns, if successful,
 // a Schema object that can be used to match against json.
 //
+// It is safe to call Compile and AddResource from multiple goroutines.
+// Resources are loaded only once, and compilations which do not share
+// resources run in parallel.
+//
 // error returned will be of type *SchemaError
 func (c *Compiler) Compile(url string) (*Schema, error) {
+	return c.CompileContext(context.Background(), url)
+}
+
+// CompileContext is like Compile, but ctx is passed to Loader
+// for loading documents. Loading stops, if ctx is done.
+func (c *Compiler) CompileContext(ctx context.Context, url string) (*Schema, error) {
 	// make url absolute
 	u, err := toAbs(url)
 	if err != nil {
@@ -130,97 +185,406 @@ func (c *Compiler) Compile(url string) (*Schema, error) {
 	}
 	url = u
 
-	sch, err := c.compileURL(url, nil, "#")
-	if err != nil {
-		err = &SchemaError{url, err}
-	}
-	return sch, err
-}
-
-func (c *Compiler) findResource(url string) (*resource, error) {
-	if _, ok := c.resources[url]; !ok {
-		// load resource
-		var rdr io.Reader
-		if sch, ok := vocabSchemas[url]; ok {
-			rdr = strings.NewReader(sch)
-		} else {
-			loadURL := LoadURL
-			if c.LoadURL != nil {
-				loadURL = c.LoadURL
+	cc := &compilation{Compiler: c, ctx: ctx, locked: make(map[*resource]bool), used: make(map[*resource]bool), settings: c.settings()}
+	for {
+		cc.lockAll()
+		sch, err := cc.compileURL(url, nil, "#")
+		restart := cc.restart
+		if err != nil || restart {
+			cc.rollback()
+		}
+		cc.unlockAll()
+		if !restart {
+			if err == nil && c.Strict {
+				if err = c.strict(sch); err != nil {
+					sch = nil
+				}
 			}
-			r, err := loadURL(url)
 			if err != nil {
-				return nil, err
+				err = &SchemaError{url, err}
 			}
-			defer r.Close()
-			rdr = r
+			return sch, err
 		}
-		if err := c.AddResource(url, rdr); err != nil {
+	}
+}
+
+// loadResource returns the resource at url, loading it if necessary.
+// Concurrent calls for same url share single load.
+func (c *Compiler) loadResource(ctx context.Context, url string) (*resource, error) {
+	reg := c.Registry
+	for {
+		if err := ctx.Err(); err != nil {
 			return nil, err
 		}
+		reg.mu.Lock()
+		if r, ok := reg.resources[url]; ok {
+			reg.mu.Unlock()
+			return r, nil
+		}
+		l, ok := reg.loads[url]
+		if !ok {
+			break
+		}
+		reg.mu.Unlock()
+		select {
+		case <-l.done:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+		if l.err != nil && (errors.Is(l.err, context.Canceled) || errors.Is(l.err, context.DeadlineExceeded)) {
+			// context of the other load is done, but not ours
+			continue
+		}
+		return l.res, l.err
 	}
+	l := &load{done: make(chan struct{})}
+	reg.loads[url] = l
+	reg.mu.Unlock()
+
+	l.res, l.err = c.load(ctx, url)
 
-	r := c.resources[url]
-	if r.draft != nil {
-		return r, nil
+	reg.mu.Lock()
+	if l.err == nil {
+		if r, ok := reg.resources[url]; ok {
+			// added by AddResource meanwhile
+			l.res = r
+		} else {
+			reg.add(l.res)
+		}
 	}
+	delete(reg.loads, url)
+	reg.mu.Unlock()
+	close(l.done)
+	return l.res, l.err
+}
 
-	// set draft
-	r.draft = c.Draft
-	if m, ok := r.doc.(map[string]interface{}); ok {
-		if sch, ok := m["$schema"]; ok {
-			sch, ok := sch.(string)
-			if !ok {
-				return nil, fmt.Errorf("jsonschema: invalid $schema in %s", url)
-			}
-			if !isURI(sch) {
-				return nil, fmt.Errorf("jsonschema: $schema must be uri in %s", url)
-			}
-			r.draft = findDraft(sch)
-			if r.draft == nil {
-				sch, _ := split(sch)
-				if sch == url {
-					return nil, fmt.Errorf("jsonschema: unsupported draft in %s", url)
-				}
-				mr, err := c.findResource(sch)
-				if err != nil {
-					return nil, err
-				}
-				r.draft = mr.draft
-			}
+func (c *Compiler) load(ctx context.Context, url string) (*resource, error) {
+	if sch, ok := vocabSchemas[url]; ok {
+		return newResource(url, strings.NewReader(sch))
+	}
+	var r io.ReadCloser
+	var err error
+	switch {
+	case c.Loader != nil:
+		r, err = c.Loader.Load(ctx, url)
+	case c.LoadURL != nil:
+		r, err = c.LoadURL(url)
+	default:
+		r, err = LoadURL(url)
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	var rdr io.Reader = r
+	var sr *sizeReader
+	if c.Policy != nil && c.Policy.MaxSize > 0 {
+		sr = &sizeReader{r: r, max: c.Policy.MaxSize}
+		rdr = sr
+	}
+	res, err := newResource(url, rdr)
+	if sr != nil && sr.n > sr.max {
+		return nil, errTooLarge
+	}
+	return res, err
+}
+
+func (c *compilation) findResource(url string) (*resource, error) {
+	r, err := c.loadResource(c.ctx, url)
+	if err != nil {
+		return nil, err
+	}
+	r.initMu.Lock()
+	defer r.initMu.Unlock()
+	if !r.initialized {
+		if err := c.prepare(r); err != nil {
+			return nil, err
+		}
+		r.initialized = true
+		r.settings = c.settings
+	}
+	return r, nil
+}
+
+// settings returns the settings of c, which affect the compiled schemas.
+// Resources in shared Registry can be used only by compilers with same
+// settings. Functions are compared by their address.
+func (c *Compiler) settings() string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "%v %v %v %v %v %v;", c.Draft, c.DetectDraft, c.AssertFormat, c.AssertContent, c.ExtractAnnotations, c.Memoize)
+	writeMap := func(m interface{}, value func(reflect.Value) string) {
+		v := reflect.ValueOf(m)
+		keys := v.MapKeys()
+		sort.Slice(keys, func(i, j int) bool {
+			return keys[i].String() < keys[j].String()
+		})
+		for _, k := range keys {
+			fmt.Fprintf(&sb, " %q=%s", k.String(), value(v.MapIndex(k)))
 		}
+		sb.WriteByte(';')
 	}
+	funcAddr := func(v reflect.Value) string {
+		return fmt.Sprintf("%#x", v.Pointer())
+	}
+	writeMap(c.DraftOverrides, func(v reflect.Value) string { return fmt.Sprint(v.Interface()) })
+	writeMap(c.Formats, funcAddr)
+	writeMap(c.Decoders, funcAddr)
+	writeMap(c.MediaTypes, funcAddr)
+	writeMap(c.extensions, func(v reflect.Value) string {
+		ext := v.Interface().(extension)
+		return fmt.Sprintf("%p,%#v", ext.meta, ext.compiler)
+	})
+	return sb.String()
+}
+
+// prepare sets the draft of r, and fills its subresources after
+// validating it against metaschema.
+func (c *compilation) prepare(r *resource) error {
+	draft, err := c.resourceDraft(r.url, r.doc, nil)
+	if err != nil {
+		return err
+	}
+	r.draft = draft
 
 	id, err := r.draft.resolveID(r.url, r.doc)
 	if err != nil {
-		return nil, err
+		return err
 	}
 	if id != "" {
 		r.url = id
 	}
 
-	if err := r.fillSubschemas(c, r); err != nil {
+	if err := r.fillSubschemas(c.Compiler, r); err != nil {
+		return err
+	}
+	c.Registry.index(r)
+	return nil
+}
+
+// resourceDraft returns the draft of given doc at url, following
+// the chain of custom metaschemas. seen is used to detect cycles.
+func (c *compilation) resourceDraft(url string, doc interface{}, seen []string) (*Draft, error) {
+	m, ok := doc.(map[string]interface{})
+	if !ok {
+		return c.Draft, nil
+	}
+	sch, ok := m["$schema"]
+	if !ok {
+		return c.draftOf(url, doc), nil
+	}
+	s, ok := sch.(string)
+	if !ok {
+		return nil, fmt.Errorf("jsonschema: invalid $schema in %s", url)
+	}
+	if !isURI(s) {
+		return nil, fmt.Errorf("jsonschema: $schema must be uri in %s", url)
+	}
+	if d := findDraft(s); d != nil {
+		return d, nil
+	}
+	s, _ = split(s)
+	seen = append(seen, url)
+	for _, u := range seen {
+		if s == u {
+			return nil, fmt.Errorf("jsonschema: unsupported draft in %s", url)
+		}
+	}
+	chain := append(append(c.chain[:len(c.chain):len(c.chain)], seen...), s)
+	if err := c.checkRef(chain, url, s); err != nil {
 		return nil, err
 	}
+	mr, err := c.loadResource(c.ctx, s)
+	if err != nil {
+		return nil, c.policyError(chain, err)
+	}
+	if err := c.use(chain, mr); err != nil {
+		return nil, err
+	}
+	return c.resourceDraft(s, mr.doc, seen)
+}
 
-	return r, nil
+// compilation ---
+
+// compilation holds the state of single Compile call.
+//
+// Root resources are locked by compilation before compiling into them.
+// To avoid deadlocks, they are locked in the order of resource.seq.
+// When a resource cannot be locked in order, schemas created so far are
+// rolled back and compilation restarts with that resource locked upfront.
+type compilation struct {
+	*Compiler
+	ctx     context.Context
+	locked  map[*resource]bool
+	want    []*resource // resources to be locked upfront
+	maxSeq  int         // max seq of resources locked
+	created []*resource // resources whose schema is created by this compilation
+	restart bool
+
+	chain []string           // urls of resources being compiled, starting with root
+	roots []*resource        // resources of chain
+	used  map[*resource]bool // resources used, for Policy
+	size  int64              // total size of used resources
+
+	settings string // Compiler.settings
+}
+
+// errRestart is returned when compilation needs to be restarted.
+var errRestart = errors.New("jsonschema: compilation restart")
+
+func (c *compilation) lock(r *resource) error {
+	if c.locked[r] {
+		return nil
+	}
+	if r.seq < c.maxSeq {
+		c.want = append(c.want, r)
+		c.restart = true
+		return errRestart
+	}
+	r.mu.Lock()
+	c.locked[r] = true
+	c.maxSeq = r.seq
+	return nil
+}
+
+func (c *compilation) lockAll() {
+	c.restart = false
+	sort.Slice(c.want, func(i, j int) bool {
+		return c.want[i].seq < c.want[j].seq
+	})
+	for _, r := range c.want {
+		_ = c.lock(r)
+	}
+}
+
+func (c *compilation) unlockAll() {
+	for r := range c.locked {
+		r.mu.Unlock()
+		delete(c.locked, r)
+	}
+	c.maxSeq = 0
+}
+
+// rollback discards schemas created by this compilation,
+// so that partially compiled schemas are not visible to others.
+func (c *compilation) rollback() {
+	for _, r := range c.created {
+		r.schema = nil
+	}
+	c.created = nil
+}
+
+func (c *compilation) newSchema(r *resource, res *resource) *Schema {
+	res.schema = newSchema(r.url, res.floc, r.draft, res.doc)
+	c.created = append(c.created, res)
+	return res.schema
 }
 
-func (c *Compiler) compileURL(url string, stack []schemaRef, ptr string) (*Schema, error) {
+func (c *compilation) compileURL(url string, stack []schemaRef, ptr string) (*Schema, error) {
 	// if url points to a draft, return Draft.meta
 	if d := findDraft(url); d != nil && d.meta != nil {
 		return d.meta, nil
 	}
 
 	b, f := split(url)
-	r, err := c.findResource(b)
-	if err != nil {
+	chain := append(c.chain[:len(c.chain):len(c.chain)], b)
+	if len(c.chain) > 0 {
+		if err := c.checkRef(chain, c.chain[len(c.chain)-1], b); err != nil {
+			return nil, err
+		}
+	}
+
+	// b may be canonical uri of loaded resource or its subresource.
+	// resource at url b takes precedence over $id of other resource.
+	var r *resource
+	if !c.Registry.has(b) {
+		r = c.Registry.root(b)
+	}
+	if r == nil {
+		var err error
+		if r, err = c.findResource(b); err != nil {
+			return nil, c.policyError(chain, err)
+		}
+	} else {
+		f = url
+	}
+	if r.settings != c.settings {
+		return nil, fmt.Errorf("jsonschema: %s is compiled by compiler with different settings", r.key)
+	}
+	if len(c.roots) > 0 {
+		c.Registry.addRef(c.roots[len(c.roots)-1], r)
+	}
+	if err := c.use(chain, r); err != nil {
 		return nil, err
 	}
+	if err := c.lock(r); err != nil {
+		return nil, err
+	}
+	c.chain, c.roots = append(c.chain, r.key), append(c.roots, r)
+	defer func() {
+		c.chain, c.roots = c.chain[:len(c.chain)-1], c.roots[:len(c.roots)-1]
+	}()
 	return c.compileRef(r, stack, ptr, r, f)
 }
 
-func (c *Compiler) compileRef(r *resource, stack []schemaRef, refPtr string, res *resource, ref string) (*Schema, error) {
+// checkRef checks whether Policy allows resource loaded from
+// url from to refer url to.
+func (c *compilation) checkRef(chain []string, from, to string) error {
+	if c.Policy == nil {
+		return nil
+	}
+	if _, ok := vocabSchemas[to]; ok {
+		return nil
+	}
+	return c.Policy.check(chain, from, to)
+}
+
+// use records that r is used by this compilation, and checks
+// it against the limits of Policy and the digests in Lockfile.
+//
+// The resources referred by r in earlier compilations are also used,
+// because schemas already compiled from r are reused without
+// resolving their references again.
+func (c *compilation) use(chain []string, r *resource) error {
+	if (c.Policy == nil && c.Lockfile == nil) || c.used[r] {
+		return nil
+	}
+	c.used[r] = true
+	if c.Lockfile != nil {
+		if err := c.Lockfile.verify(r.key, r.digest); err != nil {
+			return err
+		}
+	}
+	if c.Policy != nil {
+		c.size += r.size
+		if max := c.Policy.MaxResources; max > 0 && len(c.used) > max {
+			return &PolicyError{chain, fmt.Sprintf("more than %d resources", max)}
+		}
+		if max := c.Policy.MaxSize; max > 0 && c.size > max {
+			return &PolicyError{chain, fmt.Sprintf("resources exceed %d bytes", max)}
+		}
+	}
+	for _, ref := range c.Registry.refs(r) {
+		refChain := append(chain[:len(chain):len(chain)], ref.key)
+		if err := c.checkRef(refChain, r.key, ref.key); err != nil {
+			return err
+		}
+		if err := c.use(refChain, ref); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// policyError converts errTooLarge to PolicyError.
+func (c *compilation) policyError(chain []string, err error) error {
+	if err == errTooLarge {
+		return &PolicyError{chain, fmt.Sprintf("resource exceeds %d bytes", c.Policy.MaxSize)}
+	}
+	return err
+}
+
+func (c *compilation) compileRef(r *resource, stack []schemaRef, refPtr string, res *resource, ref string) (*Schema, error) {
 	base := r.baseURL(res.floc)
 	ref, err := resolveURL(base, ref)
 	if err != nil {
@@ -237,13 +601,12 @@ func (c *Compiler) compileRef(r *resource, stack []schemaRef, refPtr string, res
 	// ensure root resource is always compiled first.
 	// this is required to get schema.meta from root resource
 	if r.schema == nil {
-		r.schema = newSchema(r.url, r.floc, r.draft, r.doc)
-		if _, err := c.compile(r, nil, schemaRef{"#", r.schema, false}, r); err != nil {
+		if _, err := c.compile(r, nil, schemaRef{"#", c.newSchema(r, r)}, r); err != nil {
 			return nil, err
 		}
 	}
 
-	sr, err = r.resolveFragment(c, sr, f)
+	sr, err = r.resolveFragment(c.Compiler, sr, f)
 	if err != nil {
 		return nil, err
 	}
@@ -252,17 +615,16 @@ func (c *Compiler) compileRef(r *resource, stack []schemaRef, refPtr string, res
 	}
 
 	if sr.schema != nil {
-		if err := checkLoop(stack, schemaRef{refPtr, sr.schema, false}); err != nil {
+		if err := checkLoop(stack, schemaRef{refPtr, sr.schema}); err != nil {
 			return nil, err
 		}
 		return sr.schema, nil
 	}
 
-	sr.schema = newSchema(r.url, sr.floc, r.draft, sr.doc)
-	return c.compile(r, stack, schemaRef{refPtr, sr.schema, false}, sr)
+	return c.compile(r, stack, schemaRef{refPtr, c.newSchema(r, sr)}, sr)
 }
 
-func (c *Compiler) compileDynamicAnchors(r *resource, res *resource) error {
+func (c *compilation) compileDynamicAnchors(r *resource, res *resource) error {
 	if r.draft.version < 2020 {
 		return nil
 	}
@@ -283,10 +645,13 @@ func (c *Compiler) compileDynamicAnchors(r *resource, res *resource) error {
 	return nil
 }
 
-func (c *Compiler) compile(r *resource, stack []schemaRef, sref schemaRef, res *resource) (*Schema, error) {
+func (c *compilation) compile(r *resource, stack []schemaRef, sref schemaRef, res *resource) (*Schema, error) {
 	if err := c.compileDynamicAnchors(r, res); err != nil {
 		return nil, err
 	}
+	if c.Memoize {
+		res.schema.memo = new(memoStats)
+	}
 
 	switch v := res.doc.(type) {
 	case bool:
@@ -297,7 +662,7 @@ func (c *Compiler) compile(r *resource, stack []schemaRef, sref schemaRef, res *
 	}
 }
 
-func (c *Compiler) compileMap(r *resource, stack []schemaRef, sref schemaRef, res *resource) error {
+func (c *compilation) compileMap(r *resource, stack []schemaRef, sref schemaRef, res *resource) error {
 	m := res.doc.(map[string]interface{})
 
 	if err := checkLoop(stack, sref); err != nil {
@@ -389,7 +754,7 @@ func (c *Compiler) compileMap(r *resource, stack []schemaRef, sref schemaRef, re
 	}
 
 	if r.draft.version < 2019 || r.schema.meta.hasVocab("validation") {
-		if t, ok := m["type"]; ok {
+		if t, ok := m["type"]; ok && r.draft.version >= 4 {
 			switch t := t.(type) {
 			case string:
 				s.Types = []string{t}
@@ -400,6 +765,9 @@ func (c *Compiler) compileMap(r *resource, stack []schemaRef, sref schemaRef, re
 
 		if e, ok := m["enum"]; ok {
 			s.Enum = e.([]interface{})
+			if len(s.Enum) > smallArrayLen {
+				s.enumIndex = newValueIndex(s.Enum)
+			}
 			allPrimitives := true
 			for _, item := range s.Enum {
 				switch jsonType(item) {
@@ -445,11 +813,20 @@ func (c *Compiler) compileMap(r *resource, stack []schemaRef, sref schemaRef, re
 		}
 
 		s.MultipleOf = loadRat("multipleOf")
+		if r.draft.version == 3 {
+			s.MultipleOf = loadRat("divisibleBy")
+		}
+
+		s.minimum, s.exclusiveMinimum = limitDecimal(s.Minimum), limitDecimal(s.ExclusiveMinimum)
+		s.maximum, s.exclusiveMaximum = limitDecimal(s.Maximum), limitDecimal(s.ExclusiveMaximum)
+		s.multipleOf = limitDecimal(s.MultipleOf)
 
-		s.MinProperties, s.MaxProperties = loadInt("minProperties"), loadInt("maxProperties")
+		if r.draft.version >= 4 {
+			s.MinProperties, s.MaxProperties = loadInt("minProperties"), loadInt("maxProperties")
 
-		if req, ok := m["required"]; ok {
-			s.Required = toStrings(req.([]interface{}))
+			if req, ok := m["required"]; ok {
+				s.Required = toStrings(req.([]interface{}))
+			}
 		}
 
 		s.MinItems, s.MaxItems = loadInt("minItems"), loadInt("maxItems")
@@ -507,18 +884,26 @@ func (c *Compiler) compileMap(r *resource, stack []schemaRef, sref schemaRef, re
 		return nil, nil
 	}
 
-	if r.draft.version < 2019 || r.schema.meta.hasVocab("applicator") {
-		if s.Not, err = loadSchema("not", stack); err != nil {
-			return err
-		}
-		if s.AllOf, err = loadSchemas("allOf", stack); err != nil {
+	if r.draft.version == 3 {
+		if err := c.compileDraft3(r, stack, res, compile); err != nil {
 			return err
 		}
-		if s.AnyOf, err = loadSchemas("anyOf", stack); err != nil {
-			return err
-		}
-		if s.OneOf, err = loadSchemas("oneOf", stack); err != nil {
-			return err
+	}
+
+	if r.draft.version < 2019 || r.schema.meta.hasVocab("applicator") {
+		if r.draft.version >= 4 {
+			if s.Not, err = loadSchema("not", stack); err != nil {
+				return err
+			}
+			if s.AllOf, err = loadSchemas("allOf", stack); err != nil {
+				return err
+			}
+			if s.AnyOf, err = loadSchemas("anyOf", stack); err != nil {
+				return err
+			}
+			if s.OneOf, err = loadSchemas("oneOf", stack); err != nil {
+				return err
+			}
 		}
 
 		if props, ok := m["properties"]; ok {
@@ -564,6 +949,8 @@ func (c *Compiler) compileMap(r *resource, stack []schemaRef, sref schemaRef, re
 			s.Dependencies = make(map[string]interface{}, len(deps))
 			for pname, pvalue := range deps {
 				switch pvalue := pvalue.(type) {
+				case string: // draft3
+					s.Dependencies[pname] = []string{pvalue}
 				case []interface{}:
 					s.Dependencies[pname] = toStrings(pvalue)
 				default:
@@ -664,10 +1051,11 @@ func (c *Compiler) compileMap(r *resource, stack []schemaRef, sref schemaRef, re
 	if format, ok := m["format"]; ok {
 		s.Format = format.(string)
 		if r.draft.version < 2019 || c.AssertFormat || r.schema.meta.hasVocab("format-assertion") {
-			if format, ok := c.Formats[s.Format]; ok {
+			name := r.draft.format(s.Format)
+			if format, ok := c.Formats[name]; ok {
 				s.format = format
 			} else {
-				s.format, _ = Formats[s.Format]
+				s.format, _ = Formats[name]
 			}
 		}
 	}
@@ -738,13 +1126,14 @@ func (c *Compiler) compileMap(r *resource, stack []schemaRef, sref schemaRef, re
 	}
 
 	for name, ext := range c.extensions {
-		es, err := ext.compiler.Compile(CompilerContext{c, r, stack, res}, m)
+		es, err := ext.compiler.Compile(CompilerContext{c, r, stack, res, nil}, m)
 		if err != nil {
 			return err
 		}
 		if es != nil {
 			if s.Extensions == nil {
 				s.Extensions = make(map[string]ExtSchema)
+				s.extDoc = m
 			}
 			s.Extensions[name] = es
 		}
@@ -772,6 +1161,99 @@ func (c *Compiler) validateSchema(r *resource, v interface{}, vloc string) error
 	return nil
 }
 
+// compileDraft3 loads the draft3 keywords, which have no equivalent
+// fields in Schema, in terms of other keywords:
+//   - "type" with schemas is loaded into AnyOf
+//   - "disallow" is loaded into Not
+//   - "extends" is loaded into AllOf
+//   - "required": true of a property is loaded into Required of parent
+//
+// Validation errors still report the draft3 keyword locations. see
+// Schema.keyword and Schema.subschemaPath.
+func (c *compilation) compileDraft3(r *resource, stack []schemaRef, res *resource, compile func([]schemaRef, string) (*Schema, error)) error {
+	m := res.doc.(map[string]interface{})
+	s := res.schema
+
+	// union loads the type union in given keyword into sch.
+	// sch is left unconstrained, if the union has "any".
+	union := func(pname string, sch *Schema) error {
+		items, ok := m[pname].([]interface{})
+		if !ok {
+			items = []interface{}{m[pname]}
+		}
+		var types []string
+		var schemas []*Schema
+		var index []int
+		for i, item := range items {
+			if t, ok := item.(string); ok {
+				if t == "any" {
+					return nil
+				}
+				types = append(types, t)
+				continue
+			}
+			sub, err := compile(stack, pname+"/"+strconv.Itoa(i))
+			if err != nil {
+				return err
+			}
+			schemas = append(schemas, sub)
+			index = append(index, i)
+		}
+		if len(schemas) == 0 {
+			sch.Types = types
+			return nil
+		}
+		if len(types) > 0 {
+			typeSchema := newSchema(sch.Location, "", r.draft, nil)
+			typeSchema.Types = types
+			schemas = append([]*Schema{typeSchema}, schemas...)
+			index = append([]int{-1}, index...)
+		}
+		sch.AnyOf, sch.typeIndex = schemas, index
+		return nil
+	}
+
+	if _, ok := m["type"]; ok {
+		if err := union("type", s); err != nil {
+			return err
+		}
+	}
+
+	if _, ok := m["disallow"]; ok {
+		s.Not = newSchema(r.url, res.floc+"/disallow", r.draft, nil)
+		s.Not.disallow = true
+		if err := union("disallow", s.Not); err != nil {
+			return err
+		}
+	}
+
+	switch m["extends"].(type) {
+	case map[string]interface{}:
+		sch, err := compile(stack, "extends")
+		if err != nil {
+			return err
+		}
+		s.AllOf, s.extendsSchema = []*Schema{sch}, true
+	case []interface{}:
+		for i := range m["extends"].([]interface{}) {
+			sch, err := compile(stack, "extends/"+strconv.Itoa(i))
+			if err != nil {
+				return err
+			}
+			s.AllOf = append(s.AllOf, sch)
+		}
+	}
+
+	if props, ok := m["properties"].(map[string]interface{}); ok {
+		for _, pname := range sortedKeys(props) {
+			if prop, ok := props[pname].(map[string]interface{}); ok && prop["required"] == true {
+				s.Required = append(s.Required, pname)
+			}
+		}
+	}
+	return nil
+}
+
 func toStrings(arr []interface{}) []string {
 	s := make([]string, len(arr))
 	for i, v := range arr {
@@ -782,9 +1264,8 @@ func toStrings(arr []interface{}) []string {
 
 // SchemaRef captures schema and the path referring to it.
 type schemaRef struct {
-	path    string  // relative-json-pointer to schema
-	schema  *Schema // target schema
-	discard bool    // true when scope left
+	path   string  // relative-json-pointer to schema
+	schema *Schema // target schema
 }
 
 func (sr schemaRef) String() string {
@@ -799,14 +1280,3 @@ func checkLoop(stack []schemaRef, sref schemaRef) error {
 	}
 	return nil
 }
-
-func keywordLocation(stack []schemaRef, path string) string {
-	var loc string
-	for _, ref := range stack[1:] {
-		loc += "/" + ref.path
-	}
-	if path != "" {
-		loc = loc + "/" + path
-	}
-	return loc
-}
@@ -0,0 +1,160 @@
+package jsonschema_test
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/santhosh-tekuri/jsonschema/v5"
+)
+
+func TestCompilerConcurrent(t *testing.T) {
+	docs := map[string]string{
+		"map:///a.json": `{"properties": {"b": {"$ref": "b.json"}, "c": {"$ref": "c.json#/$defs/c"}}}`,
+		"map:///b.json": `{"properties": {"a": {"$ref": "a.json"}, "n": {"type": "number"}}}`,
+		"map:///c.json": `{"$defs": {"c": {"items": {"$ref": "a.json"}, "minItems": 1}}}`,
+		"map:///d.json": `{"$ref": "c.json#/$defs/c"}`,
+	}
+	var loads int32
+	c := jsonschema.NewCompiler()
+	c.LoadURL = func(s string) (io.ReadCloser, error) {
+		doc, ok := docs[s]
+		if !ok {
+			return nil, errors.New("unsupported schema")
+		}
+		atomic.AddInt32(&loads, 1)
+		time.Sleep(time.Millisecond)
+		return ioutil.NopCloser(strings.NewReader(doc)), nil
+	}
+	// added upfront, so that compilations need to lock resources out of order
+	if err := c.AddResource("map:///e.json", strings.NewReader(`{"$ref": "d.json"}`)); err != nil {
+		t.Fatal(err)
+	}
+
+	urls := []string{"map:///a.json", "map:///b.json", "map:///c.json#/$defs/c", "map:///d.json", "map:///e.json"}
+	const n = 10
+	schemas := make([][]*jsonschema.Schema, n)
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		schemas[i] = make([]*jsonschema.Schema, len(urls))
+		for j := range urls {
+			wg.Add(1)
+			go func(i, j int) {
+				defer wg.Done()
+				j = (i + j) % len(urls)
+				sch, err := c.Compile(urls[j])
+				if err != nil {
+					t.Error(err)
+					return
+				}
+				schemas[i][j] = sch
+			}(i, j)
+		}
+	}
+	wg.Wait()
+	if t.Failed() {
+		return
+	}
+
+	if got := atomic.LoadInt32(&loads); got != int32(len(docs)) {
+		t.Errorf("loads: got %d, want %d", got, len(docs))
+	}
+	for i := 1; i < n; i++ {
+		for j := range urls {
+			if schemas[i][j] != schemas[0][j] {
+				t.Errorf("%s: got different schemas", urls[j])
+			}
+		}
+	}
+	a := [2]string{`{"b": {"n": 1}, "c": [{}]}`, `{"b": {"a": {"c": []}}}`}
+	instances := map[string][2]string{ // valid and invalid instance
+		"map:///a.json":          a,
+		"map:///b.json":          {`{"a": {"c": [{}]}, "n": 1}`, `{"a": {"b": {"n": "1"}}}`},
+		"map:///c.json#/$defs/c": {"[" + a[0] + "]", "[" + a[1] + "]"},
+		"map:///d.json":          {"[" + a[0] + "]", "[]"},
+		"map:///e.json":          {"[" + a[0] + "]", "[]"},
+	}
+	for j, u := range urls {
+		sch := schemas[0][j]
+		if err := sch.Validate(decodeString(t, instances[u][0])); err != nil {
+			t.Errorf("%s: %v", u, err)
+		}
+		if err := sch.Validate(decodeString(t, instances[u][1])); err == nil {
+			t.Errorf("%s: error expected", u)
+		}
+	}
+}
+
+func TestCompilerParallel(t *testing.T) {
+	release := make(chan struct{})
+	c := jsonschema.NewCompiler()
+	c.LoadURL = func(s string) (io.ReadCloser, error) {
+		switch s {
+		case "map:///slow.json":
+			<-release
+			return ioutil.NopCloser(strings.NewReader(`{"type": "string"}`)), nil
+		case "map:///x.json":
+			return ioutil.NopCloser(strings.NewReader(`{"$ref": "slow.json"}`)), nil
+		case "map:///y.json":
+			return ioutil.NopCloser(strings.NewReader(`{"type": "number"}`)), nil
+		}
+		return nil, errors.New("unsupported schema")
+	}
+
+	done := make(chan error)
+	go func() {
+		_, err := c.Compile("map:///x.json")
+		done <- err
+	}()
+
+	// y.json does not depend on x.json, so it must not wait
+	// for x.json compilation to finish
+	compiled := make(chan error)
+	go func() {
+		_, err := c.Compile("map:///y.json")
+		compiled <- err
+	}()
+	select {
+	case err := <-compiled:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("compilation of independent resource is blocked")
+	}
+	close(release)
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestCompilerRollback(t *testing.T) {
+	c := jsonschema.NewCompiler()
+	if err := c.AddResource("a.json", strings.NewReader(`{"properties": {"b": {"$ref": "b.json"}}}`)); err != nil {
+		t.Fatal(err)
+	}
+	c.LoadURL = func(s string) (io.ReadCloser, error) {
+		return nil, fmt.Errorf("%s not available", s)
+	}
+	if _, err := c.Compile("a.json"); err == nil {
+		t.Fatal("error expected")
+	}
+
+	// partially compiled schema must not be returned
+	if err := c.AddResource("b.json", strings.NewReader(`{"type": "string"}`)); err != nil {
+		t.Fatal(err)
+	}
+	sch, err := c.Compile("a.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := sch.Validate(decodeString(t, `{"b": 1}`)); err == nil {
+		t.Fatal("error expected")
+	}
+}
@@ -0,0 +1,206 @@
+package jsonschema
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+)
+
+// Dereference returns the document of s, with each $ref replaced by
+// the document of its target. This is useful for tools which do not
+// understand $ref.
+//
+// Recursive references are kept as $ref, pointing to the location in
+// the returned document, where the target is already inlined. Keywords
+// which are of no use after inlining, like $id, $anchor and $defs, are
+// removed.
+//
+// $dynamicRef and $recursiveRef are replaced by their statically
+// resolved target, which may not be same at validation. warnings
+// lists such locations.
+//
+// If the targets use different drafts, the returned document is
+// converted to draft 2020-12, like in Compiler.Bundle.
+func (s *Schema) Dereference() (doc interface{}, warnings []string, err error) {
+	if s.doc == nil {
+		return nil, nil, errors.New("jsonschema: source document not available")
+	}
+	d := &dereferencer{index: make(map[string]*Schema), draft: s.Draft}
+	d.addIndex(s)
+	for _, loc := range sortedKeys(d.index) {
+		if d.index[loc].Draft != s.Draft {
+			d.draft = Draft2020
+			break
+		}
+	}
+	if d.draft != s.Draft {
+		d.warnings = append(d.warnings, fmt.Sprintf("%s is converted from %s to %s, because its references use other drafts", s.Location, s.Draft, d.draft))
+	}
+	doc = d.schema(s, "")
+	if m, ok := doc.(map[string]interface{}); ok {
+		if src, ok := s.doc.(map[string]interface{}); ok {
+			// keep identity of root
+			id := make(map[string]interface{})
+			for _, kw := range []string{"$schema", s.Draft.id} {
+				if v, ok := src[kw]; ok {
+					id[kw] = v
+				}
+			}
+			if d.draft != s.Draft {
+				id = (&upgrader{draft: s.Draft}).schema(id, s.Location, false).(map[string]interface{})
+				id["$schema"] = d.draft.URL()
+			}
+			for kw, v := range id {
+				m[kw] = v
+			}
+		}
+	}
+	return doc, d.warnings, nil
+}
+
+type dereferencer struct {
+	index    map[string]*Schema // key is location
+	draft    *Draft             // draft of output document
+	stack    []derefEntry       // schemas being inlined
+	warnings []string
+}
+
+type derefEntry struct {
+	s   *Schema
+	ptr string // json-pointer in output, where s is inlined
+}
+
+func (d *dereferencer) addIndex(s *Schema) {
+	if _, ok := d.index[s.Location]; ok {
+		return
+	}
+	d.index[s.Location] = s
+	for _, sch := range subschemas(s) {
+		d.addIndex(sch)
+	}
+	for _, key := range sortedKeys(s.extSchemas) {
+		d.addIndex(s.extSchemas[key])
+	}
+}
+
+// derefOmit lists the keywords, which are removed after inlining.
+var derefOmit = map[string]bool{
+	"$schema": true, "$id": true, "$anchor": true, "$dynamicAnchor": true,
+	"$recursiveAnchor": true, "$defs": true, "definitions": true,
+	"$ref": true, "$dynamicRef": true, "$recursiveRef": true,
+}
+
+// schema returns the document of s, inlined at json-pointer ptr.
+func (d *dereferencer) schema(s *Schema, ptr string) interface{} {
+	for _, e := range d.stack {
+		if e.s == s {
+			return map[string]interface{}{"$ref": "#" + e.ptr}
+		}
+	}
+	d.stack = append(d.stack, derefEntry{s, ptr})
+	defer func() { d.stack = d.stack[:len(d.stack)-1] }()
+
+	m, ok := s.doc.(map[string]interface{})
+	if !ok {
+		return cloneJSON(s.doc)
+	}
+	if s.Ref != nil && s.Draft.version <= 7 {
+		// siblings of $ref are ignored
+		return d.schema(s.Ref, ptr)
+	}
+
+	// schema of other draft is converted to d.draft, which is
+	// draft 2020-12 in that case. ptrOf translates the json-pointer
+	// rel in m, to its json-pointer in output.
+	var up *upgrader
+	ptrOf := func(rel string) string { return ptr + rel }
+	if s.Draft != d.draft {
+		up = &upgrader{draft: s.Draft}
+		ptrOf = func(rel string) string { return ptr + up.ptr(m, rel) }
+	}
+
+	out := make(map[string]interface{})
+	for kw, v := range m {
+		if derefOmit[kw] || kw == s.Draft.id {
+			continue
+		}
+		pos, ok := s.Draft.subschemas[kw]
+		if !ok {
+			out[kw] = cloneJSON(v)
+			continue
+		}
+		out[kw] = d.position(s.Location+"/"+kw, v, pos, "/"+kw, ptrOf, up != nil)
+	}
+	if up != nil {
+		out = up.schema(out, s.Location, false).(map[string]interface{})
+	}
+
+	var refs []*Schema
+	if s.Ref != nil {
+		refs = append(refs, s.Ref)
+	}
+	for _, ref := range []struct {
+		kw  string
+		sch *Schema
+	}{{"$recursiveRef", s.RecursiveRef}, {"$dynamicRef", s.DynamicRef}} {
+		if ref.sch != nil {
+			d.warnings = append(d.warnings, fmt.Sprintf("%s/%s is inlined statically", s.Location, ref.kw))
+			refs = append(refs, ref.sch)
+		}
+	}
+	if len(refs) == 1 && len(out) == 0 {
+		return d.schema(refs[0], ptr)
+	}
+	if len(refs) > 0 {
+		allOf, _ := out["allOf"].([]interface{})
+		for _, ref := range refs {
+			allOf = append(allOf, d.schema(ref, ptr+"/allOf/"+strconv.Itoa(len(allOf))))
+		}
+		out["allOf"] = allOf
+	}
+	return out
+}
+
+// position returns the document v at given position with subschemas
+// inlined. loc is the location of v, and rel is its json-pointer in
+// parent schema, which ptrOf translates to json-pointer in output.
+// convert tells to mark the subschemas as converted to d.draft.
+func (d *dereferencer) position(loc string, v interface{}, pos position, rel string, ptrOf func(string) string, convert bool) interface{} {
+	child := func(loc string, v interface{}, rel string) interface{} {
+		s, ok := d.index[loc]
+		if !ok {
+			return cloneJSON(v)
+		}
+		out := d.schema(s, ptrOf(rel))
+		if convert {
+			return converted{out, v}
+		}
+		return out
+	}
+	switch v := v.(type) {
+	case map[string]interface{}:
+		if pos&self != 0 {
+			return child(loc, v, rel)
+		}
+		if pos&prop != 0 {
+			out := make(map[string]interface{}, len(v))
+			for name, sch := range v {
+				out[name] = child(loc+"/"+escape(name), sch, rel+"/"+escape(name))
+			}
+			return out
+		}
+	case []interface{}:
+		if pos&item != 0 {
+			out := make([]interface{}, len(v))
+			for i, sch := range v {
+				out[i] = child(loc+"/"+strconv.Itoa(i), sch, rel+"/"+strconv.Itoa(i))
+			}
+			return out
+		}
+	case bool:
+		if pos&self != 0 {
+			return child(loc, v, rel)
+		}
+	}
+	return cloneJSON(v)
+}
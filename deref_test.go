@@ -0,0 +1,175 @@
+package jsonschema_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/santhosh-tekuri/jsonschema/v5"
+)
+
+func TestDereference(t *testing.T) {
+	tests := []struct {
+		name      string
+		docs      map[string]string
+		want      string // expected dereferenced document
+		warnings  int
+		instances []string
+	}{
+		{
+			name: "siblings",
+			docs: map[string]string{
+				"map:///root.json": `{
+					"properties": {
+						"name": {"$ref": "common.json#/$defs/name", "minLength": 2},
+						"age": {"$ref": "#/$defs/age"}
+					},
+					"$defs": {"age": {"type": "integer"}}
+				}`,
+				"map:///common.json": `{"$defs": {"name": {"type": "string"}}}`,
+			},
+			want: `{
+				"properties": {
+					"name": {"minLength": 2, "allOf": [{"type": "string"}]},
+					"age": {"type": "integer"}
+				}
+			}`,
+			instances: []string{`{"name": "ab"}`, `{"name": "a"}`, `{"name": 1}`, `{"age": 1}`, `{"age": "1"}`},
+		},
+		{
+			name: "draft7",
+			docs: map[string]string{
+				"map:///root.json": `{
+					"$schema": "http://json-schema.org/draft-07/schema#",
+					"items": {"$ref": "#/definitions/s", "type": "integer"},
+					"definitions": {"s": {"type": "string"}}
+				}`,
+			},
+			want: `{
+				"$schema": "http://json-schema.org/draft-07/schema#",
+				"items": {"type": "string"}
+			}`,
+			instances: []string{`["a"]`, `[1]`},
+		},
+		{
+			name: "recursive",
+			docs: map[string]string{
+				"map:///root.json": `{
+					"properties": {"tree": {"$ref": "tree.json"}}
+				}`,
+				"map:///tree.json": `{
+					"$id": "map:///tree.json",
+					"type": "object",
+					"properties": {
+						"children": {"items": {"$ref": "#"}}
+					}
+				}`,
+			},
+			want: `{
+				"properties": {
+					"tree": {
+						"type": "object",
+						"properties": {
+							"children": {"items": {"$ref": "#/properties/tree"}}
+						}
+					}
+				}
+			}`,
+			instances: []string{`{"tree": {"children": [{"children": []}]}}`, `{"tree": {"children": [1]}}`},
+		},
+		{
+			name: "dynamicRef",
+			docs: map[string]string{
+				"map:///root.json": `{
+					"$dynamicAnchor": "node",
+					"items": {"$dynamicRef": "#node"},
+					"type": "array"
+				}`,
+			},
+			want: `{
+				"items": {"$ref": "#"},
+				"type": "array"
+			}`,
+			warnings:  1,
+			instances: []string{`[[]]`, `[1]`},
+		},
+		{
+			name: "draft4Target",
+			docs: map[string]string{
+				"map:///root.json": `{
+					"properties": {
+						"a": {"$ref": "draft4.json"},
+						"n": {"$ref": "draft4.json#/definitions/n"}
+					}
+				}`,
+				"map:///draft4.json": `{
+					"$schema": "http://json-schema.org/draft-04/schema#",
+					"items": [{"type": "string"}],
+					"additionalItems": false,
+					"definitions": {"n": {"minimum": 1, "exclusiveMinimum": true}}
+				}`,
+			},
+			want: `{
+				"properties": {
+					"a": {"prefixItems": [{"type": "string"}], "items": false},
+					"n": {"exclusiveMinimum": 1}
+				}
+			}`,
+			instances: []string{`{"a": ["x"]}`, `{"a": ["x", "y"]}`, `{"a": [1]}`, `{"n": 1}`, `{"n": 2}`},
+		},
+		{
+			name: "draft7Root",
+			docs: map[string]string{
+				"map:///root.json": `{
+					"$schema": "http://json-schema.org/draft-07/schema#",
+					"properties": {"a": {"$ref": "draft2020.json"}},
+					"dependencies": {"b": ["c"]}
+				}`,
+				"map:///draft2020.json": `{
+					"$schema": "https://json-schema.org/draft/2020-12/schema",
+					"prefixItems": [{"type": "integer"}]
+				}`,
+			},
+			want: `{
+				"$schema": "https://json-schema.org/draft/2020-12/schema",
+				"properties": {"a": {"prefixItems": [{"type": "integer"}]}},
+				"dependentRequired": {"b": ["c"]}
+			}`,
+			warnings:  1,
+			instances: []string{`{"a": [1]}`, `{"a": ["x"]}`, `{"b": 1}`, `{"b": 1, "c": 2}`},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			c := jsonschema.NewCompiler()
+			c.Loader = &mapLoader{docs: test.docs}
+			sch, err := c.Compile("map:///root.json")
+			if err != nil {
+				t.Fatal(err)
+			}
+			doc, warnings, err := sch.Dereference()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(warnings) != test.warnings {
+				t.Errorf("got warnings %v, want %d", warnings, test.warnings)
+			}
+			got, _ := json.Marshal(doc)
+			want, _ := json.Marshal(decodeString(t, test.want))
+			if !bytes.Equal(got, want) {
+				t.Fatalf("got %s\nwant %s", got, want)
+			}
+
+			derefSch, err := jsonschema.CompileString("deref.json", string(got))
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, instance := range test.instances {
+				v := decodeString(t, instance)
+				if wantErr, gotErr := sch.Validate(v), derefSch.Validate(v); (wantErr == nil) != (gotErr == nil) {
+					t.Errorf("%s: got %v, want %v", instance, gotErr, wantErr)
+				}
+			}
+		})
+	}
+}
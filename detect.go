@@ -0,0 +1,221 @@
+package jsonschema
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// draftOf returns the draft of doc at url, which is missing '$schema'.
+func (c *compilation) draftOf(url string, doc interface{}) *Draft {
+	var draft *Draft
+	prefix := ""
+	for p, d := range c.DraftOverrides {
+		if strings.HasPrefix(url, p) && len(p) >= len(prefix) {
+			draft, prefix = d, p
+		}
+	}
+	if draft != nil {
+		return draft
+	}
+	if !c.DetectDraft {
+		return c.Draft
+	}
+	draft, warning := detectDraft(doc, c.Draft)
+	if warning != "" && c.OnDraftWarning != nil {
+		c.OnDraftWarning(url, draft, warning)
+	}
+	return draft
+}
+
+// draftSet is set of drafts, indexed as in drafts.
+type draftSet uint8
+
+const (
+	set3 draftSet = 1 << iota
+	set4
+	set6
+	set7
+	set2019
+	set2020
+
+	setAll = set3 | set4 | set6 | set7 | set2019 | set2020
+)
+
+func (s draftSet) String() string {
+	var names []string
+	for i, d := range drafts {
+		if s&(1<<uint(i)) != 0 {
+			names = append(names, d.String())
+		}
+	}
+	return strings.Join(names, " or ")
+}
+
+// keywordDrafts lists the keywords, which are supported only by some drafts.
+var keywordDrafts = map[string]draftSet{
+	"extends":               set3,
+	"disallow":              set3,
+	"divisibleBy":           set3,
+	"not":                   set4 | set6 | set7 | set2019 | set2020,
+	"allOf":                 set4 | set6 | set7 | set2019 | set2020,
+	"anyOf":                 set4 | set6 | set7 | set2019 | set2020,
+	"oneOf":                 set4 | set6 | set7 | set2019 | set2020,
+	"multipleOf":            set4 | set6 | set7 | set2019 | set2020,
+	"minProperties":         set4 | set6 | set7 | set2019 | set2020,
+	"maxProperties":         set4 | set6 | set7 | set2019 | set2020,
+	"$id":                   set6 | set7 | set2019 | set2020,
+	"const":                 set6 | set7 | set2019 | set2020,
+	"contains":              set6 | set7 | set2019 | set2020,
+	"propertyNames":         set6 | set7 | set2019 | set2020,
+	"if":                    set7 | set2019 | set2020,
+	"then":                  set7 | set2019 | set2020,
+	"else":                  set7 | set2019 | set2020,
+	"$comment":              set7 | set2019 | set2020,
+	"contentMediaType":      set7 | set2019 | set2020,
+	"contentEncoding":       set7 | set2019 | set2020,
+	"$defs":                 set2019 | set2020,
+	"$anchor":               set2019 | set2020,
+	"$vocabulary":           set2019 | set2020,
+	"dependentRequired":     set2019 | set2020,
+	"dependentSchemas":      set2019 | set2020,
+	"unevaluatedItems":      set2019 | set2020,
+	"unevaluatedProperties": set2019 | set2020,
+	"minContains":           set2019 | set2020,
+	"maxContains":           set2019 | set2020,
+	"$recursiveRef":         set2019,
+	"$recursiveAnchor":      set2019,
+	"prefixItems":           set2020,
+	"$dynamicRef":           set2020,
+	"$dynamicAnchor":        set2020,
+	"additionalItems":       set3 | set4 | set6 | set7 | set2019,
+	"dependencies":          set3 | set4 | set6 | set7,
+}
+
+// draftSignal is a keyword in document, which tells its draft.
+type draftSignal struct {
+	loc    string // json-pointer of keyword
+	drafts draftSet
+}
+
+// detectDraft infers the draft of doc from the keywords used.
+// fallback is returned, if keywords do not tell the draft, or
+// if it is one of the drafts they tell. Otherwise latest draft
+// they tell is returned. warning is non-empty, if keywords
+// contradict each other.
+func detectDraft(doc interface{}, fallback *Draft) (draft *Draft, warning string) {
+	var signals []draftSignal
+	collectSignals(doc, "", &signals)
+
+	candidates := setAll
+	var conflicts []draftSignal
+	for _, s := range signals {
+		if candidates&s.drafts == 0 {
+			conflicts = append(conflicts, s)
+			continue
+		}
+		candidates &= s.drafts
+	}
+
+	draft = fallback
+	if candidates != setAll {
+		for i, d := range drafts {
+			if candidates&(1<<uint(i)) == 0 {
+				continue
+			}
+			if d == fallback {
+				draft = fallback
+				break
+			}
+			draft = d
+		}
+	}
+	if len(conflicts) > 0 {
+		c := conflicts[0]
+		warning = fmt.Sprintf("keywords used are of different drafts: %q is only in %s, but %s is used", c.loc, c.drafts, draft)
+		if len(conflicts) > 1 {
+			warning += fmt.Sprintf(" (%d more)", len(conflicts)-1)
+		}
+	}
+	return draft, warning
+}
+
+// collectSignals appends the signals in schema v at json-pointer loc.
+func collectSignals(v interface{}, loc string, signals *[]draftSignal) {
+	m, ok := v.(map[string]interface{})
+	if !ok {
+		if _, ok := v.(bool); ok && loc != "" {
+			*signals = append(*signals, draftSignal{loc, set6 | set7 | set2019 | set2020})
+		}
+		return
+	}
+	if _, ok := m["$schema"]; ok && loc != "" {
+		return // subresource with its own draft
+	}
+	for _, kw := range sortedKeys(m) {
+		kwLoc := loc + "/" + escape(kw)
+		if set, ok := keywordDrafts[kw]; ok {
+			*signals = append(*signals, draftSignal{kwLoc, set})
+		}
+		switch kw {
+		case "exclusiveMinimum", "exclusiveMaximum":
+			if _, ok := m[kw].(bool); ok {
+				*signals = append(*signals, draftSignal{kwLoc, set3 | set4})
+			} else if jsonType(m[kw]) == "number" {
+				*signals = append(*signals, draftSignal{kwLoc, set6 | set7 | set2019 | set2020})
+			}
+		case "id":
+			if _, ok := m[kw].(string); ok && m["$id"] == nil {
+				*signals = append(*signals, draftSignal{kwLoc, set3 | set4})
+			}
+		case "items":
+			if _, ok := m[kw].([]interface{}); ok {
+				*signals = append(*signals, draftSignal{kwLoc, set3 | set4 | set6 | set7 | set2019})
+			}
+		case "required":
+			switch m[kw].(type) {
+			case bool:
+				*signals = append(*signals, draftSignal{kwLoc, set3})
+			case []interface{}:
+				*signals = append(*signals, draftSignal{kwLoc, set4 | set6 | set7 | set2019 | set2020})
+			}
+		case "type":
+			types, ok := m[kw].([]interface{})
+			if !ok {
+				types = []interface{}{m[kw]}
+			}
+			for _, t := range types {
+				if _, ok := t.(string); !ok || t == "any" {
+					*signals = append(*signals, draftSignal{kwLoc, set3})
+					break
+				}
+			}
+		}
+
+		var pos position
+		for _, d := range drafts {
+			pos |= d.subschemas[kw]
+		}
+		switch val := m[kw].(type) {
+		case map[string]interface{}:
+			if pos&self != 0 {
+				collectSignals(val, kwLoc, signals)
+			} else if pos&prop != 0 {
+				for _, name := range sortedKeys(val) {
+					collectSignals(val[name], kwLoc+"/"+escape(name), signals)
+				}
+			}
+		case []interface{}:
+			if pos&item != 0 {
+				for i, item := range val {
+					collectSignals(item, kwLoc+"/"+strconv.Itoa(i), signals)
+				}
+			}
+		case bool:
+			// draft4 allows booleans only for additionalProperties and additionalItems
+			if pos&self != 0 && kw != "additionalProperties" && kw != "additionalItems" {
+				collectSignals(val, kwLoc, signals)
+			}
+		}
+	}
+}
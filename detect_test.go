@@ -0,0 +1,71 @@
+package jsonschema_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/santhosh-tekuri/jsonschema/v5"
+)
+
+func TestDetectDraft(t *testing.T) {
+	tests := []struct {
+		name    string
+		url     string
+		schema  string
+		want    *jsonschema.Draft
+		warning bool
+	}{
+		{"none", "http://a.com/none.json", `{"type": "string", "minLength": 1}`, jsonschema.Draft7, false},
+		{"draft4", "http://a.com/draft4.json", `{"id": "http://a.com/draft4.json", "maximum": 5, "exclusiveMaximum": true}`, jsonschema.Draft4, false},
+		{"draft3", "http://a.com/draft3.json", `{"properties": {"a": {"type": ["string", {"type": "integer"}], "required": true}}}`, jsonschema.Draft3, false},
+		{"itemsArray", "http://a.com/items.json", `{"items": [{"type": "string"}], "if": {"minItems": 2}}`, jsonschema.Draft7, false},
+		{"draft2019", "http://a.com/draft2019.json", `{"$defs": {"a": {"$recursiveAnchor": true}}}`, jsonschema.Draft2019, false},
+		{"draft2020", "http://a.com/draft2020.json", `{"properties": {"a": {"prefixItems": [true]}}}`, jsonschema.Draft2020, false},
+		{"conflict", "http://a.com/conflict.json", `{"$id": "http://a.com/conflict.json", "items": [true], "prefixItems": [true]}`, jsonschema.Draft7, true},
+		{"override", "http://legacy.com/schemas/a.json", `{"properties": {"a": {"prefixItems": [true]}}}`, jsonschema.Draft2019, false},
+		{"longestOverride", "http://legacy.com/schemas/v4/a.json", `{"maximum": 5, "exclusiveMaximum": true}`, jsonschema.Draft4, false},
+		{"schema", "http://a.com/schema.json", `{"$schema": "http://json-schema.org/draft-06/schema#", "prefixItems": 1}`, jsonschema.Draft6, false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var warnings []string
+			c := jsonschema.NewCompiler()
+			c.Draft = jsonschema.Draft7
+			c.DetectDraft = true
+			c.DraftOverrides = map[string]*jsonschema.Draft{
+				"http://legacy.com/":           jsonschema.Draft2019,
+				"http://legacy.com/schemas/v4": jsonschema.Draft4,
+			}
+			c.OnDraftWarning = func(url string, draft *jsonschema.Draft, warning string) {
+				warnings = append(warnings, warning)
+			}
+			if err := c.AddResource(test.url, strings.NewReader(test.schema)); err != nil {
+				t.Fatal(err)
+			}
+			sch, err := c.Compile(test.url)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if sch.Draft != test.want {
+				t.Errorf("got %v, want %v", sch.Draft, test.want)
+			}
+			if got := len(warnings) > 0; got != test.warning {
+				t.Errorf("got warnings %q", warnings)
+			}
+		})
+	}
+
+	// exclusiveMaximum must be applied as in draft4
+	c := jsonschema.NewCompiler()
+	c.DetectDraft = true
+	if err := c.AddResource("schema.json", strings.NewReader(`{"maximum": 5, "exclusiveMaximum": true}`)); err != nil {
+		t.Fatal(err)
+	}
+	sch, err := c.Compile("schema.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := sch.Validate(decodeString(t, "5")); err == nil {
+		t.Error("5 must be invalid")
+	}
+}
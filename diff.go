@@ -0,0 +1,702 @@
+package jsonschema
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"sort"
+	"strconv"
+)
+
+// Compatibility tells whether data valid against one version of a schema
+// is valid against the other.
+type Compatibility string
+
+const (
+	CompatFull     Compatibility = "full"     // data valid against either version is valid against the other
+	CompatBackward Compatibility = "backward" // data valid against old version is valid against new version
+	CompatForward  Compatibility = "forward"  // data valid against new version is valid against old version
+	CompatNone     Compatibility = "none"     // neither
+)
+
+// Includes tells whether c guarantees compatibility of given mode.
+func (c Compatibility) Includes(mode Compatibility) bool {
+	return c == CompatFull || c == mode || mode == CompatNone
+}
+
+// Change is a difference between two versions of a schema, reported by Diff.
+type Change struct {
+	Kind          string        `json:"kind"`        // like "required-added"
+	OldLocation   string        `json:"oldLocation"` // absolute keyword location in old version
+	NewLocation   string        `json:"newLocation"` // absolute keyword location in new version
+	Compatibility Compatibility `json:"compatibility"`
+	Message       string        `json:"message"`
+}
+
+func (c Change) String() string {
+	return fmt.Sprintf("%s: %s: %s [%s]", c.NewLocation, c.Compatibility, c.Message, c.Kind)
+}
+
+// CompatibilityOf returns the compatibility, which all of changes guarantee.
+func CompatibilityOf(changes []Change) Compatibility {
+	backward, forward := true, true
+	for _, c := range changes {
+		backward = backward && c.Compatibility.Includes(CompatBackward)
+		forward = forward && c.Compatibility.Includes(CompatForward)
+	}
+	switch {
+	case backward && forward:
+		return CompatFull
+	case backward:
+		return CompatBackward
+	case forward:
+		return CompatForward
+	}
+	return CompatNone
+}
+
+// Diff compares the old and new versions of a schema, and returns the
+// changes which affect validation, sorted by location in new version.
+//
+// Each change is classified as backward compatible, if it only widens
+// the set of valid instances, and forward compatible if it only narrows
+// the set. The comparison is structural: subschemas are compared at same
+// location, and $ref is followed. Annotations are ignored.
+func Diff(old, new *Schema) []Change {
+	d := &differ{
+		empty: newSchema("", "", new.Draft, nil),
+		never: newSchema("", "", new.Draft, nil),
+		seen:  make(map[diffKey]bool),
+	}
+	never := false
+	d.never.Always = &never
+	d.schema(diffNode{old, old.Location}, diffNode{new, new.Location}, "schema", false)
+	sort.SliceStable(d.changes, func(i, j int) bool {
+		return d.changes[i].NewLocation < d.changes[j].NewLocation
+	})
+	return d.changes
+}
+
+type differ struct {
+	empty   *Schema // schema, which allows everything
+	never   *Schema // schema, which allows nothing
+	seen    map[diffKey]bool
+	changes []Change
+}
+
+type diffKey struct {
+	old, new *Schema
+	inv      bool
+}
+
+// diffNode is schema, along with its location. location of empty and
+// never schemas is the location where they are implied.
+type diffNode struct {
+	s   *Schema
+	loc string
+}
+
+// kw returns the location of keyword kw in n. see Schema.keyword.
+func (n diffNode) kw(kw string) string {
+	return n.loc + "/" + n.s.keyword(kw)
+}
+
+// add records change. inv tells whether the schemas being compared are
+// under not keyword, where compatibility is inverted.
+func (d *differ) add(kind string, o, n string, c Compatibility, inv bool, format string, args ...interface{}) {
+	if inv {
+		switch c {
+		case CompatBackward:
+			c = CompatForward
+		case CompatForward:
+			c = CompatBackward
+		}
+	}
+	d.changes = append(d.changes, Change{kind, o, n, c, fmt.Sprintf(format, args...)})
+}
+
+// resolve returns node, with $ref followed if it is the only assertion.
+// true and false schemas are replaced with empty and never schemas.
+func (d *differ) resolve(n diffNode) diffNode {
+	for i := 0; n.s != nil && i < 32; i++ {
+		s := n.s
+		if s.Always != nil {
+			if *s.Always {
+				return diffNode{d.empty, n.loc}
+			}
+			return diffNode{d.never, n.loc}
+		}
+		if s.Ref == nil || (s.Draft.version >= 2019 && !onlyRef(s)) {
+			break
+		}
+		n = diffNode{s.Ref, s.Ref.Location}
+	}
+	if n.s == nil {
+		n.s = d.empty
+	}
+	return n
+}
+
+// annotationKeywords are the keywords, which do not affect validation.
+var annotationKeywords = map[string]bool{
+	"$schema": true, "$id": true, "id": true, "$anchor": true, "$dynamicAnchor": true, "$recursiveAnchor": true,
+	"$defs": true, "definitions": true, "$comment": true, "$vocabulary": true, "title": true, "description": true,
+	"default": true, "examples": true, "readOnly": true, "writeOnly": true, "deprecated": true,
+}
+
+// onlyRef tells whether $ref is the only assertion in s.
+func onlyRef(s *Schema) bool {
+	m, ok := s.doc.(map[string]interface{})
+	if !ok {
+		return false
+	}
+	for kw := range m {
+		if kw != "$ref" && !annotationKeywords[kw] {
+			return false
+		}
+	}
+	return true
+}
+
+func (d *differ) schema(o, n diffNode, what string, inv bool) {
+	o, n = d.resolve(o), d.resolve(n)
+	key := diffKey{o.s, n.s, inv}
+	if d.seen[key] {
+		return
+	}
+	d.seen[key] = true
+
+	switch {
+	case o.s == d.never && n.s == d.never:
+		return
+	case o.s == d.never:
+		d.add(what+"-opened", o.loc, n.loc, CompatBackward, inv, "%s opened", what)
+		return
+	case n.s == d.never:
+		d.add(what+"-closed", o.loc, n.loc, CompatForward, inv, "%s closed", what)
+		return
+	}
+
+	d.types(o, n, inv)
+	d.values(o, n, inv)
+	d.numbers(o, n, inv)
+	d.strings(o, n, inv)
+	d.objects(o, n, inv)
+	d.arrays(o, n, inv)
+	d.applicators(o, n, inv)
+}
+
+func schemaTypes(s *Schema) jsonTypes {
+	if len(s.Types) == 0 {
+		return typeAll
+	}
+	var t jsonTypes
+	for _, name := range s.Types {
+		t |= typesOf(name)
+	}
+	return t
+}
+
+func (t jsonTypes) describe() string {
+	if t == typeAll {
+		return "any"
+	}
+	return t.String()
+}
+
+func (d *differ) types(o, n diffNode, inv bool) {
+	ot, nt := schemaTypes(o.s), schemaTypes(n.s)
+	switch {
+	case ot == nt:
+	case nt&ot == ot:
+		d.add("type-widened", o.kw("type"), n.kw("type"), CompatBackward, inv, "type widened from %s to %s", ot.describe(), nt.describe())
+	case nt&ot == nt:
+		d.add("type-narrowed", o.kw("type"), n.kw("type"), CompatForward, inv, "type narrowed from %s to %s", ot.describe(), nt.describe())
+	default:
+		d.add("type-changed", o.kw("type"), n.kw("type"), CompatNone, inv, "type changed from %s to %s", ot.describe(), nt.describe())
+	}
+}
+
+// values compares const and enum.
+func (d *differ) values(o, n diffNode, inv bool) {
+	oc, nc := o.s.Constant, n.s.Constant
+	switch {
+	case len(oc) == 0 && len(nc) > 0:
+		d.add("const-added", o.kw("const"), n.kw("const"), CompatForward, inv, "const added")
+	case len(oc) > 0 && len(nc) == 0:
+		d.add("const-removed", o.kw("const"), n.kw("const"), CompatBackward, inv, "const removed")
+	case len(oc) > 0 && !equals(oc[0], nc[0]):
+		d.add("const-changed", o.kw("const"), n.kw("const"), CompatNone, inv, "const changed")
+	}
+
+	oe, ne := o.s.Enum, n.s.Enum
+	switch {
+	case oe == nil && ne != nil:
+		d.add("enum-added", o.kw("enum"), n.kw("enum"), CompatForward, inv, "enum added")
+	case oe != nil && ne == nil:
+		d.add("enum-removed", o.kw("enum"), n.kw("enum"), CompatBackward, inv, "enum removed")
+	case oe != nil:
+		added, removed := valuesNotIn(ne, oe), valuesNotIn(oe, ne)
+		switch {
+		case added == 0 && removed == 0:
+		case removed == 0:
+			d.add("enum-widened", o.kw("enum"), n.kw("enum"), CompatBackward, inv, "enum widened with %d value(s)", added)
+		case added == 0:
+			d.add("enum-narrowed", o.kw("enum"), n.kw("enum"), CompatForward, inv, "enum narrowed by %d value(s)", removed)
+		default:
+			d.add("enum-changed", o.kw("enum"), n.kw("enum"), CompatNone, inv, "enum changed, %d value(s) added and %d removed", added, removed)
+		}
+	}
+}
+
+// valuesNotIn returns the number of values in a, which are not in b.
+func valuesNotIn(a, b []interface{}) int {
+	count := 0
+	for _, v := range a {
+		found := false
+		for _, w := range b {
+			if equals(v, w) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			count++
+		}
+	}
+	return count
+}
+
+func (d *differ) numbers(o, n diffNode, inv bool) {
+	olo, oex, okw := lowerBound(o.s)
+	nlo, nex, nkw := lowerBound(n.s)
+	d.bound(o.kw(okw), n.kw(nkw), "minimum", olo, nlo, oex, nex, true, inv)
+	ohi, oex, okw := upperBound(o.s)
+	nhi, nex, nkw := upperBound(n.s)
+	d.bound(o.kw(okw), n.kw(nkw), "maximum", ohi, nhi, oex, nex, false, inv)
+
+	om, nm := o.s.MultipleOf, n.s.MultipleOf
+	oloc, nloc := o.kw("multipleOf"), n.kw("multipleOf")
+	switch {
+	case om == nil && nm == nil:
+	case om == nil:
+		d.add("multipleOf-added", oloc, nloc, CompatForward, inv, "multipleOf %s added", nm.RatString())
+	case nm == nil:
+		d.add("multipleOf-removed", oloc, nloc, CompatBackward, inv, "multipleOf %s removed", om.RatString())
+	case om.Cmp(nm) == 0:
+	case new(big.Rat).Quo(nm, om).IsInt():
+		d.add("multipleOf-changed", oloc, nloc, CompatForward, inv, "multipleOf changed from %s to %s", om.RatString(), nm.RatString())
+	case new(big.Rat).Quo(om, nm).IsInt():
+		d.add("multipleOf-changed", oloc, nloc, CompatBackward, inv, "multipleOf changed from %s to %s", om.RatString(), nm.RatString())
+	default:
+		d.add("multipleOf-changed", oloc, nloc, CompatNone, inv, "multipleOf changed from %s to %s", om.RatString(), nm.RatString())
+	}
+}
+
+// bound compares bounds of numbers. lower tells whether they are lower bounds.
+func (d *differ) bound(oloc, nloc, name string, ov, nv *big.Rat, oex, nex, lower, inv bool) {
+	describe := func(v *big.Rat, excl bool) string {
+		if excl {
+			return "exclusive " + v.RatString()
+		}
+		return v.RatString()
+	}
+	switch {
+	case ov == nil && nv == nil:
+	case ov == nil:
+		d.add(name+"-added", oloc, nloc, CompatForward, inv, "%s %s added", name, describe(nv, nex))
+	case nv == nil:
+		d.add(name+"-removed", oloc, nloc, CompatBackward, inv, "%s %s removed", name, describe(ov, oex))
+	default:
+		c := nv.Cmp(ov)
+		if !lower {
+			c = -c
+		}
+		if c == 0 && oex != nex {
+			c = 1 // exclusive is tighter
+			if oex {
+				c = -1
+			}
+		}
+		if c == 0 {
+			return
+		}
+		raised := nv.Cmp(ov) > 0 || (nv.Cmp(ov) == 0 && nex == lower)
+		kind := name + "-lowered"
+		if raised {
+			kind = name + "-raised"
+		}
+		compat := CompatBackward
+		if c > 0 {
+			compat = CompatForward
+		}
+		d.add(kind, oloc, nloc, compat, inv, "%s changed from %s to %s", name, describe(ov, oex), describe(nv, nex))
+	}
+}
+
+// limit compares limits on length or count. -1 means not specified.
+func (d *differ) limit(o, n diffNode, kw string, ov, nv int, min, inv bool) {
+	effective := func(v int) int {
+		if v != -1 {
+			return v
+		}
+		if min {
+			return 0
+		}
+		return math.MaxInt32
+	}
+	oe, ne := effective(ov), effective(nv)
+	if oe == ne {
+		return
+	}
+	describe := func(v int) string {
+		if v == -1 {
+			return "none"
+		}
+		return strconv.Itoa(v)
+	}
+	kind := kw + "-lowered"
+	switch {
+	case ov == -1:
+		kind = kw + "-added"
+	case nv == -1:
+		kind = kw + "-removed"
+	case ne > oe:
+		kind = kw + "-raised"
+	}
+	compat := CompatBackward
+	if (min && ne > oe) || (!min && ne < oe) {
+		compat = CompatForward
+	}
+	d.add(kind, o.kw(kw), n.kw(kw), compat, inv, "%s changed from %s to %s", kw, describe(ov), describe(nv))
+}
+
+func (d *differ) strings(o, n diffNode, inv bool) {
+	d.limit(o, n, "minLength", o.s.MinLength, n.s.MinLength, true, inv)
+	d.limit(o, n, "maxLength", o.s.MaxLength, n.s.MaxLength, false, inv)
+
+	var op, np string
+	if o.s.Pattern != nil {
+		op = o.s.Pattern.String()
+	}
+	if n.s.Pattern != nil {
+		np = n.s.Pattern.String()
+	}
+	d.assertion(o, n, "pattern", op, np, inv)
+	d.assertion(o, n, "format", o.s.Format, n.s.Format, inv)
+}
+
+// assertion compares keyword whose value cannot be ordered,
+// like pattern. empty string means not specified.
+func (d *differ) assertion(o, n diffNode, kw, ov, nv string, inv bool) {
+	switch {
+	case ov == nv:
+	case ov == "":
+		d.add(kw+"-added", o.kw(kw), n.kw(kw), CompatForward, inv, "%s %q added", kw, nv)
+	case nv == "":
+		d.add(kw+"-removed", o.kw(kw), n.kw(kw), CompatBackward, inv, "%s %q removed", kw, ov)
+	default:
+		d.add(kw+"-changed", o.kw(kw), n.kw(kw), CompatNone, inv, "%s changed from %q to %q", kw, ov, nv)
+	}
+}
+
+func (d *differ) objects(o, n diffNode, inv bool) {
+	d.limit(o, n, "minProperties", o.s.MinProperties, n.s.MinProperties, true, inv)
+	d.limit(o, n, "maxProperties", o.s.MaxProperties, n.s.MaxProperties, false, inv)
+
+	for _, name := range stringsNotIn(n.s.Required, o.s.Required) {
+		d.add("required-added", o.kw("required"), n.kw("required"), CompatForward, inv, "required property %q added", name)
+	}
+	for _, name := range stringsNotIn(o.s.Required, n.s.Required) {
+		d.add("required-removed", o.kw("required"), n.kw("required"), CompatBackward, inv, "required property %q removed", name)
+	}
+
+	odeps, ndeps := dependentRequired(o.s), dependentRequired(n.s)
+	for _, pname := range unionKeys(odeps, ndeps) {
+		oloc, nloc := o.kw(depKeyword(o.s, "dependentRequired")+"/"+escape(pname)), n.kw(depKeyword(n.s, "dependentRequired")+"/"+escape(pname))
+		for _, name := range stringsNotIn(ndeps[pname], odeps[pname]) {
+			d.add("dependentRequired-added", oloc, nloc, CompatForward, inv, "property %q required along with %q", name, pname)
+		}
+		for _, name := range stringsNotIn(odeps[pname], ndeps[pname]) {
+			d.add("dependentRequired-removed", oloc, nloc, CompatBackward, inv, "property %q no longer required along with %q", name, pname)
+		}
+	}
+	odeps2, ndeps2 := dependentSchemas(o.s), dependentSchemas(n.s)
+	for _, pname := range unionKeys(odeps2, ndeps2) {
+		oloc, nloc := depKeyword(o.s, "dependentSchemas")+"/"+escape(pname), depKeyword(n.s, "dependentSchemas")+"/"+escape(pname)
+		d.schema(d.child(o, odeps2[pname], oloc), d.child(n, ndeps2[pname], nloc), "schema", inv)
+	}
+
+	for _, name := range unionKeys(o.s.Properties, n.s.Properties) {
+		d.schema(d.property(o, name), d.property(n, name), "property", inv)
+	}
+
+	opatterns, npatterns := patterns(o.s), patterns(n.s)
+	for _, pattern := range unionKeys(opatterns, npatterns) {
+		loc := "patternProperties/" + escape(pattern)
+		on, nn := d.child(o, opatterns[pattern], loc), d.child(n, npatterns[pattern], loc)
+		if opatterns[pattern] == nil {
+			on = d.additional(o)
+		}
+		if npatterns[pattern] == nil {
+			nn = d.additional(n)
+		}
+		d.schema(on, nn, "patternProperties", inv)
+	}
+	d.schema(d.additional(o), d.additional(n), "additionalProperties", inv)
+
+	loc := "propertyNames"
+	d.schema(d.child(o, o.s.PropertyNames, loc), d.child(n, n.s.PropertyNames, loc), "propertyNames", inv)
+}
+
+// child returns node for subschema s of parent at location loc.
+// nil s is treated as empty schema.
+func (d *differ) child(parent diffNode, s *Schema, loc string) diffNode {
+	if s == nil {
+		return diffNode{d.empty, parent.kw(loc)}
+	}
+	return diffNode{s, s.Location}
+}
+
+// property returns the schema applied to property name.
+func (d *differ) property(n diffNode, name string) diffNode {
+	if s, ok := n.s.Properties[name]; ok {
+		return diffNode{s, s.Location}
+	}
+	for _, pattern := range sortedPatterns(n.s) {
+		if re := n.s.pattern(pattern); re.MatchString(name) {
+			s := n.s.PatternProperties[re]
+			return diffNode{s, s.Location}
+		}
+	}
+	additional := d.additional(n)
+	if additional.s == d.empty {
+		additional.loc = n.kw("properties/" + escape(name))
+	}
+	return additional
+}
+
+// additional returns the schema applied to additional properties.
+func (d *differ) additional(n diffNode) diffNode {
+	return d.boolOrSchema(n, n.s.AdditionalProperties, "additionalProperties")
+}
+
+func (d *differ) boolOrSchema(n diffNode, v interface{}, kw string) diffNode {
+	switch v := v.(type) {
+	case *Schema:
+		return diffNode{v, v.Location}
+	case bool:
+		if !v {
+			return diffNode{d.never, n.kw(kw)}
+		}
+	}
+	return diffNode{d.empty, n.kw(kw)}
+}
+
+func (d *differ) arrays(o, n diffNode, inv bool) {
+	d.limit(o, n, "minItems", o.s.MinItems, n.s.MinItems, true, inv)
+	d.limit(o, n, "maxItems", o.s.MaxItems, n.s.MaxItems, false, inv)
+	switch {
+	case !o.s.UniqueItems && n.s.UniqueItems:
+		d.add("uniqueItems-added", o.kw("uniqueItems"), n.kw("uniqueItems"), CompatForward, inv, "uniqueItems added")
+	case o.s.UniqueItems && !n.s.UniqueItems:
+		d.add("uniqueItems-removed", o.kw("uniqueItems"), n.kw("uniqueItems"), CompatBackward, inv, "uniqueItems removed")
+	}
+
+	oprefix, orest := d.items(o)
+	nprefix, nrest := d.items(n)
+	for i := 0; i < len(oprefix) || i < len(nprefix); i++ {
+		on, nn := orest, nrest
+		if i < len(oprefix) {
+			on = oprefix[i]
+		}
+		if i < len(nprefix) {
+			nn = nprefix[i]
+		}
+		d.schema(on, nn, "item", inv)
+	}
+	d.schema(orest, nrest, "items", inv)
+
+	switch oc, nc := o.s.Contains, n.s.Contains; {
+	case oc == nil && nc != nil:
+		d.add("contains-added", o.kw("contains"), n.kw("contains"), CompatForward, inv, "contains added")
+	case oc != nil && nc == nil:
+		d.add("contains-removed", o.kw("contains"), n.kw("contains"), CompatBackward, inv, "contains removed")
+	case oc != nil:
+		d.schema(diffNode{oc, oc.Location}, diffNode{nc, nc.Location}, "contains", inv)
+		d.limit(o, n, "minContains", o.s.MinContains, n.s.MinContains, true, inv)
+		d.limit(o, n, "maxContains", o.s.MaxContains, n.s.MaxContains, false, inv)
+	}
+}
+
+// items returns the schemas applied to array items, at each index
+// and after them.
+func (d *differ) items(n diffNode) (prefix []diffNode, rest diffNode) {
+	s := n.s
+	if len(s.PrefixItems) > 0 || s.Items2020 != nil {
+		for _, sch := range s.PrefixItems {
+			prefix = append(prefix, diffNode{sch, sch.Location})
+		}
+		return prefix, d.child(n, s.Items2020, "items")
+	}
+	switch items := s.Items.(type) {
+	case *Schema:
+		return nil, diffNode{items, items.Location}
+	case []*Schema:
+		for _, sch := range items {
+			prefix = append(prefix, diffNode{sch, sch.Location})
+		}
+		return prefix, d.boolOrSchema(n, s.AdditionalItems, "additionalItems")
+	}
+	return nil, diffNode{d.empty, n.kw("items")}
+}
+
+func (d *differ) applicators(o, n diffNode, inv bool) {
+	for _, ref := range []struct {
+		kw       string
+		old, new *Schema
+	}{{"$ref", o.s.Ref, n.s.Ref}, {"$recursiveRef", o.s.RecursiveRef, n.s.RecursiveRef}, {"$dynamicRef", o.s.DynamicRef, n.s.DynamicRef}} {
+		if ref.old != nil || ref.new != nil {
+			d.schema(d.child(o, ref.old, ref.kw), d.child(n, ref.new, ref.kw), "schema", inv)
+		}
+	}
+
+	switch on, nn := o.s.Not, n.s.Not; {
+	case on == nil && nn != nil:
+		d.add("not-added", o.kw("not"), n.kw("not"), CompatForward, inv, "not added")
+	case on != nil && nn == nil:
+		d.add("not-removed", o.kw("not"), n.kw("not"), CompatBackward, inv, "not removed")
+	case on != nil:
+		d.schema(diffNode{on, on.Location}, diffNode{nn, nn.Location}, "schema", !inv)
+	}
+
+	for _, group := range []struct {
+		kw       string
+		old, new []*Schema
+		added    Compatibility // of adding subschema to nonempty list
+	}{
+		{"allOf", o.s.AllOf, n.s.AllOf, CompatForward},
+		{"anyOf", o.s.AnyOf, n.s.AnyOf, CompatBackward},
+		{"oneOf", o.s.OneOf, n.s.OneOf, CompatNone},
+	} {
+		for i := 0; i < len(group.old) && i < len(group.new); i++ {
+			d.schema(diffNode{group.old[i], group.old[i].Location}, diffNode{group.new[i], group.new[i].Location}, "schema", inv)
+		}
+		ol, nl := len(group.old), len(group.new)
+		if ol == nl {
+			continue
+		}
+		oloc, nloc := o.kw(group.kw), n.kw(group.kw)
+		switch {
+		case ol == 0:
+			d.add(group.kw+"-added", oloc, nloc, CompatForward, inv, "%s added", group.kw)
+		case nl == 0:
+			d.add(group.kw+"-removed", oloc, nloc, CompatBackward, inv, "%s removed", group.kw)
+		case nl > ol:
+			d.add(group.kw+"-widened", oloc, nloc, group.added, inv, "%d subschema(s) added to %s", nl-ol, group.kw)
+		default:
+			compat := group.added
+			switch compat {
+			case CompatForward:
+				compat = CompatBackward
+			case CompatBackward:
+				compat = CompatForward
+			}
+			d.add(group.kw+"-narrowed", oloc, nloc, compat, inv, "%d subschema(s) removed from %s", ol-nl, group.kw)
+		}
+	}
+
+	switch oi, ni := o.s.If, n.s.If; {
+	case oi == nil && ni != nil:
+		d.add("if-added", o.kw("if"), n.kw("if"), CompatForward, inv, "if added")
+	case oi != nil && ni == nil:
+		d.add("if-removed", o.kw("if"), n.kw("if"), CompatBackward, inv, "if removed")
+	case oi != nil:
+		// changes in if affect which branch applies
+		before := len(d.changes)
+		d.schema(diffNode{oi, oi.Location}, diffNode{ni, ni.Location}, "schema", inv)
+		for i := before; i < len(d.changes); i++ {
+			d.changes[i].Compatibility = CompatNone
+		}
+		d.schema(d.child(o, o.s.Then, "then"), d.child(n, n.s.Then, "then"), "then", inv)
+		d.schema(d.child(o, o.s.Else, "else"), d.child(n, n.s.Else, "else"), "else", inv)
+	}
+}
+
+// stringsNotIn returns the strings in a, which are not in b.
+func stringsNotIn(a, b []string) []string {
+	var list []string
+	for _, s := range a {
+		found := false
+		for _, t := range b {
+			if s == t {
+				found = true
+				break
+			}
+		}
+		if !found {
+			list = append(list, s)
+		}
+	}
+	return list
+}
+
+// unionKeys returns sorted keys of maps a and b.
+func unionKeys(a, b interface{}) []string {
+	keys := sortedKeys(a)
+	for _, k := range sortedKeys(b) {
+		i := sort.SearchStrings(keys, k)
+		if i == len(keys) || keys[i] != k {
+			keys = append(keys, "")
+			copy(keys[i+1:], keys[i:])
+			keys[i] = k
+		}
+	}
+	return keys
+}
+
+// dependentRequired returns dependentRequired of s, along with
+// dependencies whose value is array.
+func dependentRequired(s *Schema) map[string][]string {
+	m := make(map[string][]string)
+	for pname, names := range s.DependentRequired {
+		m[pname] = names
+	}
+	for pname, v := range s.Dependencies {
+		if names, ok := v.([]string); ok {
+			m[pname] = names
+		}
+	}
+	return m
+}
+
+// dependentSchemas returns dependentSchemas of s, along with
+// dependencies whose value is schema.
+func dependentSchemas(s *Schema) map[string]*Schema {
+	m := make(map[string]*Schema)
+	for pname, sch := range s.DependentSchemas {
+		m[pname] = sch
+	}
+	for pname, v := range s.Dependencies {
+		if sch, ok := v.(*Schema); ok {
+			m[pname] = sch
+		}
+	}
+	return m
+}
+
+// depKeyword returns kw, or dependencies if it is not supported by draft of s.
+func depKeyword(s *Schema, kw string) string {
+	if s.Draft.version < 2019 {
+		return "dependencies"
+	}
+	return kw
+}
+
+func patterns(s *Schema) map[string]*Schema {
+	m := make(map[string]*Schema)
+	for re, sch := range s.PatternProperties {
+		m[re.String()] = sch
+	}
+	return m
+}
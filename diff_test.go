@@ -0,0 +1,155 @@
+package jsonschema_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/santhosh-tekuri/jsonschema/v5"
+)
+
+func TestDiff(t *testing.T) {
+	tests := []struct {
+		name     string
+		old, new string
+		want     []string // location kind compatibility
+	}{
+		{
+			name: "same",
+			old:  `{"type": "object", "properties": {"a": {"type": "string"}}, "title": "old"}`,
+			new:  `{"properties": {"a": {"type": "string"}}, "type": "object", "title": "new"}`,
+		},
+		{
+			name: "required",
+			old:  `{"required": ["a", "b"]}`,
+			new:  `{"required": ["b", "c"]}`,
+			want: []string{"#/required required-added forward", "#/required required-removed backward"},
+		},
+		{
+			name: "enum",
+			old:  `{"properties": {"a": {"enum": [1, 2, 3]}, "b": {"enum": [1]}}}`,
+			new:  `{"properties": {"a": {"enum": [1, 2]}, "b": {"enum": [1, 2]}}}`,
+			want: []string{"#/properties/a/enum enum-narrowed forward", "#/properties/b/enum enum-widened backward"},
+		},
+		{
+			name: "type",
+			old:  `{"properties": {"a": {"type": "integer"}, "b": {"type": "string"}, "c": {"type": "string"}}}`,
+			new:  `{"properties": {"a": {"type": "number"}, "b": {"type": "boolean"}, "c": {}}}`,
+			want: []string{"#/properties/a/type type-widened backward", "#/properties/b/type type-changed none", "#/properties/c/type type-widened backward"},
+		},
+		{
+			name: "bounds",
+			old:  `{"properties": {"a": {"maximum": 10}, "b": {"minimum": 1}, "c": {"exclusiveMaximum": 5}, "d": {"maxLength": 3}}}`,
+			new:  `{"properties": {"a": {"maximum": 5}, "b": {"exclusiveMinimum": 1}, "c": {"maximum": 5}, "d": {}}}`,
+			want: []string{
+				"#/properties/a/maximum maximum-lowered forward",
+				"#/properties/b/exclusiveMinimum minimum-raised forward",
+				"#/properties/c/maximum maximum-raised backward",
+				"#/properties/d/maxLength maxLength-removed backward",
+			},
+		},
+		{
+			name: "additionalProperties",
+			old:  `{"properties": {"a": true}}`,
+			new:  `{"properties": {"a": true}, "additionalProperties": false}`,
+			want: []string{"#/additionalProperties additionalProperties-closed forward"},
+		},
+		{
+			name: "propertyAdded",
+			old:  `{"properties": {"a": true}, "additionalProperties": {"type": "string"}}`,
+			new:  `{"properties": {"a": true, "b": {"type": ["string", "null"]}}, "additionalProperties": {"type": "string"}}`,
+			want: []string{"#/properties/b/type type-widened backward"},
+		},
+		{
+			name: "ref",
+			old:  `{"properties": {"a": {"$ref": "#/$defs/a"}}, "$defs": {"a": {"minItems": 1}}}`,
+			new:  `{"properties": {"a": {"minItems": 2}}}`,
+			want: []string{"#/properties/a/minItems minItems-raised forward"},
+		},
+		{
+			name: "items",
+			old:  `{"$schema": "http://json-schema.org/draft-07/schema#", "items": [{"type": "string"}], "additionalItems": false}`,
+			new:  `{"prefixItems": [{"type": "string"}], "items": {"type": "integer"}}`,
+			want: []string{"#/items items-opened backward"},
+		},
+		{
+			name: "draft3",
+			old:  `{"$schema": "http://json-schema.org/draft-03/schema#", "divisibleBy": 2, "extends": [{"type": "string"}]}`,
+			new:  `{"$schema": "http://json-schema.org/draft-03/schema#", "disallow": "null", "type": ["string", {"minLength": 1}]}`,
+			want: []string{
+				"#/disallow not-added forward",
+				"#/divisibleBy multipleOf-removed backward",
+				"#/extends allOf-removed backward",
+				"#/type anyOf-added forward",
+			},
+		},
+		{
+			name: "not",
+			old:  `{"not": {"enum": [1, 2]}}`,
+			new:  `{"not": {"enum": [1]}}`,
+			want: []string{"#/not/enum enum-narrowed backward"},
+		},
+		{
+			name: "anyOf",
+			old:  `{"anyOf": [{"type": "string"}]}`,
+			new:  `{"anyOf": [{"type": "string"}, {"type": "null"}]}`,
+			want: []string{"#/anyOf anyOf-widened backward"},
+		},
+		{
+			name: "recursive",
+			old:  `{"properties": {"child": {"$ref": "#"}, "n": {"type": "integer"}}}`,
+			new:  `{"properties": {"child": {"$ref": "#"}, "n": {"type": "integer", "minimum": 0}}}`,
+			want: []string{"#/properties/n/minimum minimum-added forward"},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			c := jsonschema.NewCompiler()
+			if err := c.AddResource("old.json", strings.NewReader(test.old)); err != nil {
+				t.Fatal(err)
+			}
+			if err := c.AddResource("new.json", strings.NewReader(test.new)); err != nil {
+				t.Fatal(err)
+			}
+			old, err := c.Compile("old.json")
+			if err != nil {
+				t.Fatal(err)
+			}
+			new, err := c.Compile("new.json")
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			for _, ch := range jsonschema.Diff(old, new) {
+				loc := ch.NewLocation[strings.IndexByte(ch.NewLocation, '#'):]
+				got = append(got, loc+" "+ch.Kind+" "+string(ch.Compatibility))
+			}
+			if strings.Join(got, "\n") != strings.Join(test.want, "\n") {
+				t.Errorf("got %q, want %q", got, test.want)
+			}
+		})
+	}
+}
+
+func TestCompatibilityOf(t *testing.T) {
+	changes := func(compats ...jsonschema.Compatibility) []jsonschema.Change {
+		var list []jsonschema.Change
+		for _, c := range compats {
+			list = append(list, jsonschema.Change{Compatibility: c})
+		}
+		return list
+	}
+	tests := []struct {
+		changes []jsonschema.Change
+		want    jsonschema.Compatibility
+	}{
+		{nil, jsonschema.CompatFull},
+		{changes(jsonschema.CompatBackward), jsonschema.CompatBackward},
+		{changes(jsonschema.CompatForward, jsonschema.CompatFull), jsonschema.CompatForward},
+		{changes(jsonschema.CompatForward, jsonschema.CompatBackward), jsonschema.CompatNone},
+	}
+	for i, test := range tests {
+		if got := jsonschema.CompatibilityOf(test.changes); got != test.want {
+			t.Errorf("#%d: got %s, want %s", i, got, test.want)
+		}
+	}
+}
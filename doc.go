@@ -2,7 +2,7 @@
 Package jsonschema provides json-schema compilation and validation.
 
 Features:
-  - implements draft 2020-12, 2019-09, draft-7, draft-6, draft-4
+  - implements draft 2020-12, 2019-09, draft-7, draft-6, draft-4, draft-3
   - fully compliant with JSON-Schema-Test-Suite, (excluding some optional)
   - list of optional tests that are excluded can be found in schema_test.go(variable skipTests)
   - validates schemas against meta-schema
@@ -18,7 +18,7 @@ Features:
   - supports user-defined keywords via extensions
   - implements following formats (supports user-defined)
   - date-time, date, time, duration (supports leap-second)
-  - uuid, hostname, email
+  - uuid, hostname, host-name (draft3 only), email
   - ip-address, ipv4, ipv6
   - uri, uriref, uri-template(limited validation)
   - json-pointer, relative-json-pointer
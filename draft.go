@@ -15,6 +15,7 @@ type Draft struct {
 	vocab        []string // built-in vocab
 	defaultVocab []string // vocabs when $vocabulary is not used
 	subschemas   map[string]position
+	formats      map[string]string // format name to its name in Formats, if they differ.
 }
 
 func (d *Draft) URL() string {
@@ -29,6 +30,8 @@ func (d *Draft) URL() string {
 		return "https://json-schema.org/draft-06/schema"
 	case 4:
 		return "https://json-schema.org/draft-04/schema"
+	case 3:
+		return "https://json-schema.org/draft-03/schema"
 	}
 	return ""
 }
@@ -37,6 +40,16 @@ func (d *Draft) String() string {
 	return fmt.Sprintf("Draft%d", d.version)
 }
 
+// format returns name of the format in Formats.
+func (d *Draft) format(name string) string {
+	if d != nil {
+		if f, ok := d.formats[name]; ok {
+			return f
+		}
+	}
+	return name
+}
+
 func (d *Draft) loadMeta(url, schema string) {
 	c := NewCompiler()
 	c.AssertFormat = true
@@ -183,6 +196,7 @@ const (
 
 // supported drafts
 var (
+	Draft3    = &Draft{version: 3, id: "id", boolSchema: false, formats: map[string]string{"host-name": "hostname"}}
 	Draft4    = &Draft{version: 4, id: "id", boolSchema: false}
 	Draft6    = &Draft{version: 6, id: "$id", boolSchema: true}
 	Draft7    = &Draft{version: 7, id: "$id", boolSchema: true}
@@ -249,11 +263,29 @@ func findDraft(url string) *Draft {
 		return Draft6
 	case "https://json-schema.org/draft-04/schema":
 		return Draft4
+	case "https://json-schema.org/draft-03/schema":
+		return Draft3
 	}
 	return nil
 }
 
 func init() {
+	Draft3.subschemas = map[string]position{
+		// type agnostic
+		"definitions": prop,
+		"extends":     self | item,
+		"type":        item,
+		"disallow":    item,
+		// object
+		"properties":           prop,
+		"additionalProperties": self,
+		"patternProperties":    prop,
+		// array
+		"items":           self | item,
+		"additionalItems": self,
+		"dependencies":    prop,
+	}
+
 	subschemas := map[string]position{
 		// type agnostic
 		"definitions": prop,
@@ -290,6 +322,149 @@ func init() {
 	subschemas["prefixItems"] = item
 	Draft2020.subschemas = clone(subschemas)
 
+	Draft3.loadMeta("http://json-schema.org/draft-03/schema", `{
+		"$schema": "http://json-schema.org/draft-03/schema#",
+		"id": "http://json-schema.org/draft-03/schema#",
+		"type": "object",
+		"properties": {
+			"type": {
+				"type": ["string", "array"],
+				"items": {
+					"type": ["string", {"$ref": "#"}]
+				},
+				"uniqueItems": true,
+				"default": "any"
+			},
+			"properties": {
+				"type": "object",
+				"additionalProperties": {"$ref": "#"},
+				"default": {}
+			},
+			"patternProperties": {
+				"type": "object",
+				"additionalProperties": {"$ref": "#"},
+				"default": {}
+			},
+			"additionalProperties": {
+				"type": [{"$ref": "#"}, "boolean"],
+				"default": {}
+			},
+			"items": {
+				"type": [{"$ref": "#"}, "array"],
+				"items": {"$ref": "#"},
+				"default": {}
+			},
+			"additionalItems": {
+				"type": [{"$ref": "#"}, "boolean"],
+				"default": {}
+			},
+			"required": {
+				"type": "boolean",
+				"default": false
+			},
+			"dependencies": {
+				"type": "object",
+				"additionalProperties": {
+					"type": ["string", "array", {"$ref": "#"}],
+					"items": {
+						"type": "string"
+					}
+				},
+				"default": {}
+			},
+			"minimum": {
+				"type": "number"
+			},
+			"maximum": {
+				"type": "number"
+			},
+			"exclusiveMinimum": {
+				"type": "boolean",
+				"default": false
+			},
+			"exclusiveMaximum": {
+				"type": "boolean",
+				"default": false
+			},
+			"minItems": {
+				"type": "integer",
+				"minimum": 0,
+				"default": 0
+			},
+			"maxItems": {
+				"type": "integer",
+				"minimum": 0
+			},
+			"uniqueItems": {
+				"type": "boolean",
+				"default": false
+			},
+			"pattern": {
+				"type": "string",
+				"format": "regex"
+			},
+			"minLength": {
+				"type": "integer",
+				"minimum": 0,
+				"default": 0
+			},
+			"maxLength": {
+				"type": "integer"
+			},
+			"enum": {
+				"type": "array",
+				"minItems": 1,
+				"uniqueItems": true
+			},
+			"default": {
+				"type": "any"
+			},
+			"title": {
+				"type": "string"
+			},
+			"description": {
+				"type": "string"
+			},
+			"format": {
+				"type": "string"
+			},
+			"divisibleBy": {
+				"type": "number",
+				"minimum": 0,
+				"exclusiveMinimum": true,
+				"default": 1
+			},
+			"disallow": {
+				"type": ["string", "array"],
+				"items": {
+					"type": ["string", {"$ref": "#"}]
+				},
+				"uniqueItems": true
+			},
+			"extends": {
+				"type": [{"$ref": "#"}, "array"],
+				"items": {"$ref": "#"},
+				"default": {}
+			},
+			"id": {
+				"type": "string",
+				"format": "uri-reference"
+			},
+			"$ref": {
+				"type": "string",
+				"format": "uri-reference"
+			},
+			"$schema": {
+				"type": "string",
+				"format": "uri"
+			}
+		},
+		"dependencies": {
+			"exclusiveMinimum": "minimum",
+			"exclusiveMaximum": "maximum"
+		},
+		"default": {}
+	}`)
 	Draft4.loadMeta("http://json-schema.org/draft-04/schema", `{
 		"$schema": "http://json-schema.org/draft-04/schema#",
 		"description": "Core schema meta-schema",
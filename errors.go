@@ -71,9 +71,13 @@ type ValidationError struct {
 	InstanceLocation        string             // location of the json value within the instance being validated
 	Message                 string             // describes error
 	Causes                  []*ValidationError // nested validation errors
+	quiet                   bool               // reported in quiet mode, see validator.quietError
 }
 
 func (ve *ValidationError) add(causes ...error) error {
+	if ve.quiet {
+		return ve
+	}
 	for _, cause := range causes {
 		ve.Causes = append(ve.Causes, cause.(*ValidationError))
 	}
@@ -81,6 +85,9 @@ func (ve *ValidationError) add(causes ...error) error {
 }
 
 func (ve *ValidationError) causes(err error) error {
+	if ve.quiet {
+		return ve
+	}
 	if err := err.(*ValidationError); err.Message == "" {
 		ve.Causes = err.Causes
 	} else {
@@ -0,0 +1,101 @@
+package jsonschema
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+)
+
+// ExampleError is a default, examples or const value, which is not
+// valid against the schema it is declared in.
+type ExampleError struct {
+	KeywordLocation string // absolute location of value. for example "schema.json#/properties/a/examples/1"
+	Value           interface{}
+
+	// Err is the validation error. It is usually *ValidationError,
+	// whose InstanceLocation is relative to Value.
+	Err error
+}
+
+func (e *ExampleError) Error() string {
+	return fmt.Sprintf("jsonschema: %s is not valid: %v", e.KeywordLocation, e.Err)
+}
+
+func (e *ExampleError) Unwrap() error {
+	return e.Err
+}
+
+// CheckExamples validates the default, examples and const values of s,
+// and of schemas reachable from it, against the schema they are declared in.
+// It returns the values, which are not valid, sorted by location.
+//
+// Values are taken from the source document. If s is loaded from
+// snapshot, values captured with Compiler.ExtractAnnotations are used.
+func (s *Schema) CheckExamples() []*ExampleError {
+	var errs []*ExampleError
+	visited := make(map[*Schema]bool)
+	var walk func(s *Schema)
+	walk = func(s *Schema) {
+		if visited[s] || findDraft(s.url()) != nil {
+			return
+		}
+		visited[s] = true
+		errs = append(errs, s.checkExamples()...)
+		for _, sch := range subschemas(s) {
+			walk(sch)
+		}
+		for _, key := range sortedKeys(s.extSchemas) {
+			walk(s.extSchemas[key])
+		}
+	}
+	walk(s)
+	sort.SliceStable(errs, func(i, j int) bool {
+		return errs[i].KeywordLocation < errs[j].KeywordLocation
+	})
+	return errs
+}
+
+// checkExamples validates the values declared in s.
+func (s *Schema) checkExamples() []*ExampleError {
+	if s.Ref != nil && s.Draft.version <= 7 {
+		// siblings of $ref are ignored
+		return nil
+	}
+
+	type value struct {
+		kw string
+		v  interface{}
+	}
+	var values []value
+	if m, ok := s.doc.(map[string]interface{}); ok {
+		if v, ok := m["default"]; ok {
+			values = append(values, value{"default", v})
+		}
+		if examples, ok := m["examples"].([]interface{}); ok {
+			for i, v := range examples {
+				values = append(values, value{"examples/" + strconv.Itoa(i), v})
+			}
+		}
+		if v, ok := m["const"]; ok {
+			values = append(values, value{"const", v})
+		}
+	} else {
+		if s.Default != nil {
+			values = append(values, value{"default", s.Default})
+		}
+		for i, v := range s.Examples {
+			values = append(values, value{"examples/" + strconv.Itoa(i), v})
+		}
+		if len(s.Constant) > 0 {
+			values = append(values, value{"const", s.Constant[0]})
+		}
+	}
+
+	var errs []*ExampleError
+	for _, v := range values {
+		if err := s.Validate(v.v); err != nil {
+			errs = append(errs, &ExampleError{s.Location + "/" + v.kw, v.v, err})
+		}
+	}
+	return errs
+}
@@ -0,0 +1,67 @@
+package jsonschema_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/santhosh-tekuri/jsonschema/v5"
+)
+
+func TestCheckExamples(t *testing.T) {
+	schema := `{
+		"type": "object",
+		"default": {"name": "x", "age": 1},
+		"examples": [{"name": "john"}, {"age": "1"}],
+		"properties": {
+			"name": {"type": "string", "minLength": 2, "examples": ["ab", "c"], "default": "ab"},
+			"age": {"type": "integer", "default": 1.5},
+			"kind": {"enum": ["a", "b"], "const": "c"},
+			"ref": {"$ref": "#/$defs/positive", "examples": [-1]}
+		},
+		"$defs": {
+			"positive": {"minimum": 0, "examples": [0, -2]}
+		}
+	}`
+	want := []struct {
+		loc, instanceLoc string
+	}{
+		{"#/$defs/positive/examples/1", ""},
+		{"#/default", "/name"},
+		{"#/examples/1", "/age"},
+		{"#/properties/age/default", ""},
+		{"#/properties/kind/const", ""},
+		{"#/properties/name/examples/1", ""},
+		{"#/properties/ref/examples/0", ""},
+	}
+
+	c := jsonschema.NewCompiler()
+	if err := c.AddResource("schema.json", strings.NewReader(schema)); err != nil {
+		t.Fatal(err)
+	}
+	sch, err := c.Compile("schema.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	errs := sch.CheckExamples()
+	if len(errs) != len(want) {
+		t.Fatalf("got %d errors, want %d: %v", len(errs), len(want), errs)
+	}
+	for i, err := range errs {
+		loc := err.KeywordLocation[strings.IndexByte(err.KeywordLocation, '#'):]
+		if loc != want[i].loc {
+			t.Errorf("error %d: got location %s, want %s", i, loc, want[i].loc)
+			continue
+		}
+		ve, ok := err.Err.(*jsonschema.ValidationError)
+		if !ok {
+			t.Fatalf("error %d: got %T, want *ValidationError", i, err.Err)
+		}
+		leaf := ve
+		for len(leaf.Causes) > 0 {
+			leaf = leaf.Causes[0]
+		}
+		if leaf.InstanceLocation != want[i].instanceLoc {
+			t.Errorf("%s: got instance location %q, want %q", loc, leaf.InstanceLocation, want[i].instanceLoc)
+		}
+	}
+}
@@ -33,10 +33,11 @@ func (c *Compiler) RegisterExtension(name string, meta *Schema, ext ExtCompiler)
 
 // CompilerContext provides additional context required in compiling for extension.
 type CompilerContext struct {
-	c     *Compiler
+	c     *compilation
 	r     *resource
 	stack []schemaRef
 	res   *resource
+	sch   *Schema // non-nil, when loading sch from snapshot
 }
 
 // Compile compiles given value at ptr into *Schema. This is useful in implementing
@@ -47,11 +48,23 @@ type CompilerContext struct {
 // applicableOnSameInstance tells whether current schema and the given schema
 // are applied on same instance value. this is used to detect infinite loop in schema.
 func (ctx CompilerContext) Compile(schPath string, applicableOnSameInstance bool) (*Schema, error) {
+	if ctx.sch != nil {
+		return ctx.sch.extSchema(ctx.sch.extSchemas, schPath)
+	}
 	var stack []schemaRef
 	if applicableOnSameInstance {
 		stack = ctx.stack
 	}
-	return ctx.c.compileRef(ctx.r, stack, schPath, ctx.res, ctx.r.url+ctx.res.floc+"/"+schPath)
+	sch, err := ctx.c.compileRef(ctx.r, stack, schPath, ctx.res, ctx.r.url+ctx.res.floc+"/"+schPath)
+	if err != nil {
+		return nil, err
+	}
+	s := ctx.res.schema
+	if s.extSchemas == nil {
+		s.extSchemas = make(map[string]*Schema)
+	}
+	s.extSchemas[schPath] = sch
+	return sch, nil
 }
 
 // CompileRef compiles the schema referenced by ref uri
@@ -61,31 +74,45 @@ func (ctx CompilerContext) Compile(schPath string, applicableOnSameInstance bool
 // applicableOnSameInstance tells whether current schema and the given schema
 // are applied on same instance value. this is used to detect infinite loop in schema.
 func (ctx CompilerContext) CompileRef(ref string, refPath string, applicableOnSameInstance bool) (*Schema, error) {
+	if ctx.sch != nil {
+		return ctx.sch.extSchema(ctx.sch.extRefs, ref)
+	}
 	var stack []schemaRef
 	if applicableOnSameInstance {
 		stack = ctx.stack
 	}
-	return ctx.c.compileRef(ctx.r, stack, refPath, ctx.res, ref)
+	sch, err := ctx.c.compileRef(ctx.r, stack, refPath, ctx.res, ref)
+	if err != nil {
+		return nil, err
+	}
+	s := ctx.res.schema
+	if s.extRefs == nil {
+		s.extRefs = make(map[string]*Schema)
+	}
+	s.extRefs[ref] = sch
+	return sch, nil
 }
 
 // ValidationContext ---
 
 // ValidationContext provides additional context required in validating for extension.
 type ValidationContext struct {
-	result          validationResult
-	validate        func(sch *Schema, schPath string, v interface{}, vpath string) error
-	validateInplace func(sch *Schema, schPath string) error
-	validationError func(keywordPath string, format string, a ...interface{}) *ValidationError
+	vd *validator
+	f  *frame
 }
 
 // EvaluatedProp marks given property of object as evaluated.
 func (ctx ValidationContext) EvaluatedProp(prop string) {
-	delete(ctx.result.unevalProps, prop)
+	if ctx.f.track {
+		ctx.f.eval.addProp(prop)
+	}
 }
 
 // EvaluatedItem marks given index of array as evaluated.
 func (ctx ValidationContext) EvaluatedItem(index int) {
-	delete(ctx.result.unevalItems, index)
+	if ctx.f.track {
+		ctx.f.eval.items.set(index)
+	}
 }
 
 // Validate validates schema s with value v. Extension must use this method instead of
@@ -96,16 +123,16 @@ func (ctx ValidationContext) EvaluatedItem(index int) {
 // vpath is relative-json-pointer to v.
 func (ctx ValidationContext) Validate(s *Schema, spath string, v interface{}, vpath string) error {
 	if vpath == "" {
-		return ctx.validateInplace(s, spath)
+		return ctx.vd.validateInplace(ctx.f, s, spath, token{})
 	}
-	return ctx.validate(s, spath, v, vpath)
+	return ctx.vd.validate(s, spath, token{}, v, rawToken(vpath))
 }
 
 // Error used to construct validation error by extensions.
 //
 // keywordPath is relative-json-pointer to keyword.
 func (ctx ValidationContext) Error(keywordPath string, format string, a ...interface{}) *ValidationError {
-	return ctx.validationError(keywordPath, format, a...)
+	return ctx.vd.error(keywordPath, format, a...)
 }
 
 // Group is used by extensions to group multiple errors as causes to parent error.
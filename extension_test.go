@@ -47,3 +47,42 @@ func TestPowerOfExt(t *testing.T) {
 		})
 	})
 }
+
+// mutateSchema changes the error it reports, as extensions may do.
+type mutateSchema struct{ t *testing.T }
+
+func (s mutateSchema) Validate(ctx jsonschema.ValidationContext, v interface{}) error {
+	err := ctx.Error("mutate", "mutate failed")
+	if len(err.Causes) > 0 || err.Message == "changed" {
+		s.t.Errorf("error reported is changed by earlier validation: %#v", err)
+	}
+	err.Message = "changed"
+	err.Causes = append(err.Causes, &jsonschema.ValidationError{Message: "injected"})
+	return err
+}
+
+type mutateCompiler struct{ t *testing.T }
+
+func (c mutateCompiler) Compile(ctx jsonschema.CompilerContext, m map[string]interface{}) (jsonschema.ExtSchema, error) {
+	if _, ok := m["mutate"]; ok {
+		return mutateSchema{c.t}, nil
+	}
+	return nil, nil
+}
+
+func TestExtMutatesQuietError(t *testing.T) {
+	c := jsonschema.NewCompiler()
+	c.RegisterExtension("mutate", jsonschema.MustCompileString("mutate.json", `{}`), mutateCompiler{t})
+	if err := c.AddResource("test.json", strings.NewReader(`{"not": {"mutate": true}}`)); err != nil {
+		t.Fatal(err)
+	}
+	sch, err := c.Compile("test.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 3; i++ {
+		if err := sch.Validate(1); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
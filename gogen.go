@@ -0,0 +1,1160 @@
+package jsonschema
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"go/format"
+	"io"
+	"math/big"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// GoGenerator generates go source code for validators specialized to
+// compiled schemas. The generated validators do not interpret Schema at
+// runtime, but report same errors as Schema.Validate.
+//
+// The generated source contains its own runtime, so it must be placed in
+// a package of its own. It uses Formats, Decoders and MediaTypes of this
+// package, so user-defined formats and content used by the schemas must
+// be registered there, rather than in Compiler.
+//
+// Schemas with extensions are not supported.
+type GoGenerator struct {
+	pkg     string
+	ids     map[*Schema]int
+	schemas []*Schema
+	funcs   []goFunc
+	types   []goType
+	tnames  map[*Schema]string // struct type names
+}
+
+type goFunc struct {
+	name string
+	sch  int
+}
+
+type goType struct {
+	name   string
+	sch    *Schema
+	fields []goField
+}
+
+type goField struct {
+	name, prop, typ string
+	required        bool
+}
+
+// NewGoGenerator returns GoGenerator which generates code in package pkg.
+func NewGoGenerator(pkg string) *GoGenerator {
+	return &GoGenerator{
+		pkg:    pkg,
+		ids:    make(map[*Schema]int),
+		tnames: make(map[*Schema]string),
+	}
+}
+
+// Add adds validator function with given name for sch.
+// The generated function has following signature:
+//
+//	func name(v interface{}) error
+//
+// where v is json value as accepted by Schema.Validate.
+func (g *GoGenerator) Add(name string, sch *Schema) error {
+	if err := g.addSchema(sch); err != nil {
+		return err
+	}
+	g.funcs = append(g.funcs, goFunc{name, g.ids[sch]})
+	return nil
+}
+
+// AddType adds go struct type with given name for sch, which must be
+// an object schema with properties. Properties whose schema is object
+// with properties are mapped to nested struct types. Properties which
+// are not required are mapped to pointers or nil-able types.
+//
+// The generated type has method Validate, which validates the struct
+// value, without converting it into json text.
+func (g *GoGenerator) AddType(name string, sch *Schema) error {
+	if err := g.addSchema(sch); err != nil {
+		return err
+	}
+	if typeSchema(sch) == nil {
+		return fmt.Errorf("jsonschema: cannot generate struct type for %s: not object schema with properties", sch.Location)
+	}
+	g.addType(name, sch)
+	return nil
+}
+
+// typeSchema returns the schema with properties, which sch represents.
+// It returns nil, if sch does not represent object with properties.
+func typeSchema(sch *Schema) *Schema {
+	for sch != nil && len(sch.Properties) == 0 {
+		sch = sch.Ref
+	}
+	if sch == nil {
+		return nil
+	}
+	for _, t := range sch.Types {
+		if t != "object" {
+			return nil
+		}
+	}
+	return sch
+}
+
+func (g *GoGenerator) addType(name string, sch *Schema) string {
+	if tname, ok := g.tnames[sch]; ok {
+		return tname
+	}
+	g.tnames[sch] = name
+	i := len(g.types)
+	g.types = append(g.types, goType{name: name, sch: sch})
+
+	ts := typeSchema(sch)
+	required := make(map[string]bool)
+	for _, pname := range ts.Required {
+		required[pname] = true
+	}
+	var fields []goField
+	names := make(map[string]bool)
+	for _, pname := range sortedKeys(ts.Properties) {
+		fname := goName(pname)
+		for names[fname] {
+			fname += "_"
+		}
+		names[fname] = true
+		fields = append(fields, goField{
+			name:     fname,
+			prop:     pname,
+			typ:      g.fieldType(name+fname, ts.Properties[pname], required[pname]),
+			required: required[pname],
+		})
+	}
+	g.types[i].fields = fields
+	return name
+}
+
+// fieldType returns go type for property with schema sch. name is used
+// for struct type, if one is generated for sch.
+func (g *GoGenerator) fieldType(name string, sch *Schema, required bool) string {
+	for sch.Ref != nil && len(sch.Types) == 0 && len(sch.Properties) == 0 {
+		sch = sch.Ref
+	}
+	if typeSchema(sch) != nil {
+		if tname, ok := g.tnames[sch]; ok {
+			name = tname
+		}
+		return "*" + g.addType(name, sch)
+	}
+	var t string
+	switch types := strings.Join(sch.Types, ","); types {
+	case "string":
+		t = "string"
+	case "integer":
+		t = "int64"
+	case "number":
+		t = "json.Number"
+	case "boolean":
+		t = "bool"
+	case "array":
+		switch items := sch.Items.(type) {
+		case *Schema:
+			return "[]" + g.fieldType(name+"Item", items, true)
+		}
+		if sch.Items2020 != nil && len(sch.PrefixItems) == 0 {
+			return "[]" + g.fieldType(name+"Item", sch.Items2020, true)
+		}
+		return "[]interface{}"
+	case "object":
+		return "map[string]interface{}"
+	default:
+		return "interface{}"
+	}
+	if !required {
+		t = "*" + t
+	}
+	return t
+}
+
+func (g *GoGenerator) addSchema(sch *Schema) error {
+	if _, ok := g.ids[sch]; ok {
+		return nil
+	}
+	if len(sch.Extensions) > 0 {
+		return fmt.Errorf("jsonschema: cannot generate code for %s: extensions are not supported", sch.Location)
+	}
+	if sch.format != nil {
+		if _, ok := Formats[sch.Draft.format(sch.Format)]; !ok {
+			return fmt.Errorf("jsonschema: cannot generate code for %s: format %q is not registered in jsonschema.Formats", sch.Location, sch.Format)
+		}
+	}
+	if sch.decoder != nil {
+		if _, ok := Decoders[sch.ContentEncoding]; !ok {
+			return fmt.Errorf("jsonschema: cannot generate code for %s: contentEncoding %q is not registered in jsonschema.Decoders", sch.Location, sch.ContentEncoding)
+		}
+	}
+	if sch.mediaType != nil {
+		if _, ok := MediaTypes[sch.ContentMediaType]; !ok {
+			return fmt.Errorf("jsonschema: cannot generate code for %s: contentMediaType %q is not registered in jsonschema.MediaTypes", sch.Location, sch.ContentMediaType)
+		}
+	}
+	g.ids[sch] = len(g.schemas)
+	g.schemas = append(g.schemas, sch)
+	for _, sub := range subschemas(sch) {
+		if err := g.addSchema(sub); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// subschemas returns the schemas which may be applied by sch.
+func subschemas(sch *Schema) []*Schema {
+	var list []*Schema
+	add := func(schemas ...*Schema) {
+		for _, s := range schemas {
+			if s != nil {
+				list = append(list, s)
+			}
+		}
+	}
+	add(sch.Ref, sch.RecursiveRef, sch.DynamicRef, sch.Not, sch.If, sch.Then, sch.Else)
+	add(sch.dynamicAnchors...)
+	add(sch.AllOf...)
+	add(sch.AnyOf...)
+	add(sch.OneOf...)
+	for _, pname := range sortedKeys(sch.Properties) {
+		add(sch.Properties[pname])
+	}
+	add(sch.PropertyNames)
+	for _, pattern := range sortedPatterns(sch) {
+		add(sch.PatternProperties[sch.pattern(pattern)])
+	}
+	if s, ok := sch.AdditionalProperties.(*Schema); ok {
+		add(s)
+	}
+	for _, dname := range sortedKeys(sch.Dependencies) {
+		if s, ok := sch.Dependencies[dname].(*Schema); ok {
+			add(s)
+		}
+	}
+	for _, dname := range sortedKeys(sch.DependentSchemas) {
+		add(sch.DependentSchemas[dname])
+	}
+	add(sch.UnevaluatedProperties)
+	switch items := sch.Items.(type) {
+	case *Schema:
+		add(items)
+	case []*Schema:
+		add(items...)
+	}
+	if s, ok := sch.AdditionalItems.(*Schema); ok {
+		add(s)
+	}
+	add(sch.PrefixItems...)
+	add(sch.Items2020, sch.Contains, sch.UnevaluatedItems, sch.ContentSchema)
+	return list
+}
+
+// WriteTo writes the generated go source into w.
+func (g *GoGenerator) WriteTo(w io.Writer) (int64, error) {
+	gw := &goWriter{g: g, buf: new(bytes.Buffer)}
+	gw.write()
+	src, err := format.Source(gw.buf.Bytes())
+	if err != nil {
+		return 0, fmt.Errorf("jsonschema: formatting generated code: %v", err)
+	}
+	n, err := w.Write(src)
+	return int64(n), err
+}
+
+type goWriter struct {
+	g    *GoGenerator
+	buf  *bytes.Buffer
+	vars bytes.Buffer // package level variables
+	nvar int
+}
+
+func (w *goWriter) p(format string, a ...interface{}) {
+	fmt.Fprintf(w.buf, format, a...)
+	w.buf.WriteByte('\n')
+}
+
+// newVar declares package level variable with given value
+// and returns its name.
+func (w *goWriter) newVar(value string) string {
+	name := "v" + strconv.Itoa(w.nvar)
+	w.nvar++
+	fmt.Fprintf(&w.vars, "\t%s = %s\n", name, value)
+	return name
+}
+
+func (w *goWriter) write() {
+	g := w.g
+	w.p("// Code generated by jsonschema.GoGenerator. DO NOT EDIT.")
+	w.p("")
+	w.p("package %s", g.pkg)
+	w.p("")
+	w.p("import (")
+	for _, pkg := range []string{"bytes", "encoding/json", "fmt", "math/big", "net/url", "regexp", "strconv", "strings", "unicode/utf8", "", "github.com/santhosh-tekuri/jsonschema/v5"} {
+		if pkg == "" {
+			w.p("")
+		} else {
+			w.p("\t%q", pkg)
+		}
+	}
+	w.p(")")
+	w.p("")
+	w.p("var (")
+	w.p("\t_ = bytes.NewReader")
+	w.p("\t_ = json.Number(\"\")")
+	w.p("\t_ = regexp.MustCompile")
+	w.p("\t_ = utf8.RuneCountInString")
+	w.p(")")
+	for _, f := range g.funcs {
+		w.p("")
+		w.p("// %s validates v against %s.", f.name, g.schemas[f.sch].Location)
+		w.p("func %s(v interface{}) error {", f.name)
+		w.p("\treturn validateRoot(%d, v)", f.sch)
+		w.p("}")
+	}
+	for _, t := range g.types {
+		w.writeType(t)
+	}
+
+	w.p("")
+	w.p("var schemas = [...]schemaInfo{")
+	for _, sch := range g.schemas {
+		var anchors []string
+		for _, da := range sch.dynamicAnchors {
+			anchors = append(anchors, strconv.Itoa(g.ids[da]))
+		}
+		w.p("\t{loc: %q, url: %q, ptr: %q, recursiveAnchor: %v, dynamicAnchor: %q, dynamicAnchors: []int{%s}},",
+			sch.Location, sch.url(), sch.loc(), sch.RecursiveAnchor, sch.DynamicAnchor, strings.Join(anchors, ", "))
+	}
+	w.p("}")
+	w.p("")
+	w.p("func init() {")
+	for i := range g.schemas {
+		w.p("\tschemas[%d].validate = (*validator).s%d", i, i)
+	}
+	w.p("}")
+
+	for i, sch := range g.schemas {
+		w.p("")
+		w.writeSchema(i, sch)
+	}
+
+	if w.vars.Len() > 0 {
+		w.p("")
+		w.p("var (")
+		w.buf.Write(w.vars.Bytes())
+		w.p(")")
+	}
+	w.buf.WriteString(goRuntime)
+}
+
+func (w *goWriter) writeType(t goType) {
+	w.p("")
+	w.p("// %s represents %s.", t.name, t.sch.Location)
+	w.p("type %s struct {", t.name)
+	for _, f := range t.fields {
+		tag := f.prop
+		if !f.required {
+			tag += ",omitempty"
+		}
+		w.p("\t%s %s `json:%q`", f.name, f.typ, tag)
+	}
+	w.p("}")
+	w.p("")
+	w.p("// Validate validates t against %s.", t.sch.Location)
+	w.p("func (t *%s) Validate() error {", t.name)
+	w.p("\treturn validateRoot(%d, t.jsonValue())", w.g.ids[t.sch])
+	w.p("}")
+	w.p("")
+	w.p("func (t *%s) jsonValue() interface{} {", t.name)
+	w.p("\tif t == nil {")
+	w.p("\t\treturn nil")
+	w.p("\t}")
+	w.p("\tm := make(map[string]interface{}, %d)", len(t.fields))
+	for _, f := range t.fields {
+		w.writeField(f)
+	}
+	w.p("\treturn m")
+	w.p("}")
+}
+
+// writeField writes code to add field f of struct t into map m.
+func (w *goWriter) writeField(f goField) {
+	nilable := strings.HasPrefix(f.typ, "*") || strings.HasPrefix(f.typ, "[]") || strings.HasPrefix(f.typ, "map[") || f.typ == "interface{}"
+	val := "t." + f.name
+	if nilable {
+		w.p("\tif %s != nil {", val)
+		defer w.p("\t}")
+	}
+	w.p("\tm[%q] = %s", f.prop, w.jsonValue(val, f.typ))
+}
+
+// jsonValue returns expression, which converts go value val of type
+// typ into json value.
+func (w *goWriter) jsonValue(val, typ string) string {
+	switch {
+	case strings.HasPrefix(typ, "[]") && typ != "[]interface{}":
+		elem := typ[2:]
+		return fmt.Sprintf("func() []interface{} { arr := make([]interface{}, len(%s)); for i, item := range %s { arr[i] = %s }; return arr }()", val, val, w.jsonValue("item", elem))
+	case strings.HasPrefix(typ, "*"):
+		if _, ok := w.typeNamed(typ[1:]); ok {
+			return fmt.Sprintf("%s.jsonValue()", val)
+		}
+		return "*" + val
+	}
+	return val
+}
+
+func (w *goWriter) typeNamed(name string) (goType, bool) {
+	for _, t := range w.g.types {
+		if t.name == name {
+			return t, true
+		}
+	}
+	return goType{}, false
+}
+
+func (w *goWriter) id(sch *Schema) int {
+	return w.g.ids[sch]
+}
+
+func (w *goWriter) ids(schemas []*Schema) string {
+	var ids []string
+	for _, sch := range schemas {
+		ids = append(ids, strconv.Itoa(w.id(sch)))
+	}
+	return "[]int{" + strings.Join(ids, ", ") + "}"
+}
+
+// goToken returns go expression for t, which is empty or index token.
+func goToken(t token) string {
+	if t.kind == tokIndex {
+		return fmt.Sprintf("indexToken(%d)", t.i)
+	}
+	return "token{}"
+}
+
+func (w *goWriter) appendErr(call string) {
+	w.p("if err := %s; err != nil {", call)
+	w.p("errors = append(errors, err)")
+	w.p("}")
+}
+
+func (w *goWriter) writeSchema(i int, s *Schema) {
+	w.p("// s%d validates with %s", i, s.Location)
+	w.p("func (vd *validator) s%d(f *frame) error {", i)
+	w.p("v := f.v")
+	w.p("switch v.(type) {")
+	w.p("case map[string]interface{}:")
+	if s.UnevaluatedProperties != nil {
+		w.p("f.track = true")
+	}
+	w.p("case []interface{}:")
+	if s.UnevaluatedItems != nil {
+		w.p("f.track = true")
+	}
+	w.p("default:")
+	w.p("f.track = false")
+	w.p("}")
+	w.p("if f.track {")
+	w.p("f.eval.reset()")
+	w.p("}")
+
+	if s.Always != nil {
+		if !*s.Always {
+			w.p("return vd.error(\"\", \"not allowed\")")
+		} else {
+			w.p("return nil")
+		}
+		w.p("}")
+		return
+	}
+
+	if len(s.Types) > 0 {
+		var conds []string
+		for _, t := range s.Types {
+			if t == "integer" {
+				conds = append(conds, `vType == "number" && isInt(v)`)
+			} else {
+				conds = append(conds, fmt.Sprintf("vType == %q", t))
+			}
+		}
+		w.p("if vType := jsonType(v); !(%s) {", strings.Join(conds, " || "))
+		w.p("return vd.error(\"type\", \"expected %%s, but got %%s\", %q, vType)", strings.Join(s.Types, " or "))
+		w.p("}")
+	}
+
+	w.p("var errors []error")
+
+	if len(s.Constant) > 0 {
+		c := w.newVar("interface{}(" + goLiteral(s.Constant[0]) + ")")
+		w.p("if !equals(v, %s) {", c)
+		switch jsonType(s.Constant[0]) {
+		case "object", "array":
+			w.p("errors = append(errors, vd.error(\"const\", \"const failed\"))")
+		default:
+			w.p("errors = append(errors, vd.error(\"const\", \"value must be %%s\", %q))", fmt.Sprintf("%#v", s.Constant[0]))
+		}
+		w.p("}")
+	}
+
+	if len(s.Enum) > 0 {
+		e := w.newVar(goLiteral(s.Enum))
+		w.p("if !contains(%s, v) {", e)
+		w.p("errors = append(errors, vd.error(\"enum\", %q))", s.enumError)
+		w.p("}")
+	}
+
+	if s.format != nil {
+		w.p("if !jsonschema.Formats[%q](v) {", s.Draft.format(s.Format))
+		w.p("var val = v")
+		w.p("if v, ok := v.(string); ok {")
+		w.p("val = quote(v)")
+		w.p("}")
+		w.p("errors = append(errors, vd.error(\"format\", \"%%v is not valid %%s\", val, %q))", quote(s.Format))
+		w.p("}")
+	}
+
+	body := func(f func()) string {
+		buf := w.buf
+		w.buf = new(bytes.Buffer)
+		f()
+		buf, w.buf = w.buf, buf
+		return buf.String()
+	}
+	cases := []struct {
+		typ  string
+		body string
+	}{
+		{"map[string]interface{}", body(func() { w.writeObject(s) })},
+		{"[]interface{}", body(func() { w.writeArray(s) })},
+		{"string", body(func() { w.writeString(s) })},
+		{"json.Number, float32, float64, int, int8, int32, int64, uint, uint8, uint32, uint64", body(func() { w.writeNumber(s) })},
+	}
+	var switchWritten, vUsed bool
+	for _, c := range cases {
+		vUsed = vUsed || identV.MatchString(c.body)
+	}
+	for _, c := range cases {
+		if c.body == "" {
+			continue
+		}
+		if !switchWritten {
+			if vUsed {
+				w.p("switch v := v.(type) {")
+			} else {
+				w.p("switch v.(type) {")
+			}
+			switchWritten = true
+		}
+		w.p("case %s:", c.typ)
+		w.buf.WriteString(c.body)
+	}
+	if switchWritten {
+		w.p("}")
+	}
+
+	// $ref + $recursiveRef + $dynamicRef
+	if s.Ref != nil {
+		w.appendErr(fmt.Sprintf("vd.validateRef(f, %d, \"$ref\")", w.id(s.Ref)))
+	}
+	if s.RecursiveRef != nil {
+		sch := strconv.Itoa(w.id(s.RecursiveRef))
+		if s.RecursiveRef.RecursiveAnchor {
+			sch = "vd.recursiveRef(" + sch + ")"
+		}
+		w.appendErr(fmt.Sprintf("vd.validateRef(f, %s, \"$recursiveRef\")", sch))
+	}
+	if s.DynamicRef != nil {
+		sch := strconv.Itoa(w.id(s.DynamicRef))
+		if s.dynamicRefAnchor != "" && s.DynamicRef.DynamicAnchor == s.dynamicRefAnchor {
+			sch = "vd.dynamicRef(" + sch + ")"
+		}
+		w.appendErr(fmt.Sprintf("vd.validateRef(f, %s, \"$dynamicRef\")", sch))
+	}
+
+	if s.Not != nil {
+		kw := s.keyword("not")
+		w.p("if vd.validateQuiet(f, %d, %q, token{}) == nil {", w.id(s.Not), kw)
+		w.p("errors = append(errors, vd.error(%q, \"%s failed\"))", kw, kw)
+		w.p("}")
+	}
+
+	for i, sch := range s.AllOf {
+		kw, tok := s.subschemaPath("allOf", i)
+		w.p("if err := vd.validateInplace(f, %d, %q, %s); err != nil {", w.id(sch), kw, goToken(tok))
+		w.p("errors = append(errors, add(vd.error(%q, \"%s failed\"), err))", joinToken(kw, tok), kw)
+		w.p("}")
+	}
+
+	if len(s.AnyOf) > 0 && s.typeIndex != nil {
+		// draft3 type union, whose schemas are not at anyOf/i
+		w.p("{")
+		w.p("matched := false")
+		for i, sch := range s.AnyOf {
+			kw, tok := s.subschemaPath("anyOf", i)
+			w.p("if vd.validateQuiet(f, %d, %q, %s) == nil {", w.id(sch), kw, goToken(tok))
+			w.p("matched = true")
+			w.p("}")
+		}
+		w.p("if !matched {")
+		w.p("var causes []error")
+		w.p("if !vd.quiet {")
+		for i, sch := range s.AnyOf {
+			kw, tok := s.subschemaPath("anyOf", i)
+			w.p("if err := vd.validateInplace(f, %d, %q, %s); err != nil {", w.id(sch), kw, goToken(tok))
+			w.p("causes = append(causes, err)")
+			w.p("}")
+		}
+		w.p("}")
+		kw := s.keyword("anyOf")
+		w.p("errors = append(errors, add(vd.error(%q, \"%s failed\"), causes...))", kw, kw)
+		w.p("}")
+		w.p("}")
+	} else if len(s.AnyOf) > 0 {
+		w.p("{")
+		w.p("matched := false")
+		w.p("for i, sch := range %s {", w.ids(s.AnyOf))
+		w.p("if vd.validateQuiet(f, sch, \"anyOf\", indexToken(i)) == nil {")
+		w.p("matched = true")
+		w.p("}")
+		w.p("}")
+		w.p("if !matched {")
+		w.p("errors = append(errors, add(vd.error(\"anyOf\", \"anyOf failed\"), vd.causes(f, \"anyOf\", %s)...))", w.ids(s.AnyOf))
+		w.p("}")
+		w.p("}")
+	}
+
+	if len(s.OneOf) > 0 {
+		w.p("{")
+		w.p("matched := -1")
+		w.p("for i, sch := range %s {", w.ids(s.OneOf))
+		w.p("if vd.validateQuiet(f, sch, \"oneOf\", indexToken(i)) == nil {")
+		w.p("if matched == -1 {")
+		w.p("matched = i")
+		w.p("} else {")
+		w.p("errors = append(errors, vd.error(\"oneOf\", \"valid against schemas at indexes %%d and %%d\", matched, i))")
+		w.p("break")
+		w.p("}")
+		w.p("}")
+		w.p("}")
+		w.p("if matched == -1 {")
+		w.p("errors = append(errors, add(vd.error(\"oneOf\", \"oneOf failed\"), vd.causes(f, \"oneOf\", %s)...))", w.ids(s.OneOf))
+		w.p("}")
+		w.p("}")
+	}
+
+	// if + then + else
+	if s.If != nil {
+		w.p("{")
+		w.p("err := vd.validateQuiet(f, %d, \"if\", token{})", w.id(s.If))
+		w.p("f.discard = true")
+		w.p("if err == nil {")
+		if s.Then != nil {
+			w.p("if err := vd.validateInplace(f, %d, \"then\", token{}); err != nil {", w.id(s.Then))
+			w.p("errors = append(errors, add(vd.error(\"then\", \"if-then failed\"), err))")
+			w.p("}")
+		}
+		w.p("} else {")
+		if s.Else != nil {
+			w.p("if err := vd.validateInplace(f, %d, \"else\", token{}); err != nil {", w.id(s.Else))
+			w.p("errors = append(errors, add(vd.error(\"else\", \"if-else failed\"), err))")
+			w.p("}")
+		}
+		w.p("}")
+		w.p("f.discard = false")
+		w.p("}")
+	}
+
+	// unevaluatedProperties + unevaluatedItems
+	if s.UnevaluatedProperties != nil {
+		w.p("if v, ok := v.(map[string]interface{}); ok {")
+		w.p("for pname, pvalue := range v {")
+		w.p("if !f.eval.hasProp(pname) {")
+		w.appendErr(fmt.Sprintf("vd.validate(%d, \"unevaluatedProperties\", token{}, pvalue, propToken(pname))", w.id(s.UnevaluatedProperties)))
+		w.p("}")
+		w.p("}")
+		w.p("f.eval.allProps = true")
+		w.p("}")
+	}
+	if s.UnevaluatedItems != nil {
+		w.p("if v, ok := v.([]interface{}); ok {")
+		w.p("for i, item := range v {")
+		w.p("if !f.eval.hasItem(i) {")
+		w.appendErr(fmt.Sprintf("vd.validate(%d, \"unevaluatedItems\", token{}, item, indexToken(i))", w.id(s.UnevaluatedItems)))
+		w.p("}")
+		w.p("}")
+		w.p("f.eval.allItems = true")
+		w.p("}")
+	}
+
+	w.p("switch len(errors) {")
+	w.p("case 0:")
+	w.p("return nil")
+	w.p("case 1:")
+	w.p("return errors[0]")
+	w.p("default:")
+	w.p("return add(vd.error(\"\", \"\"), errors...)")
+	w.p("}")
+	w.p("}")
+}
+
+func (w *goWriter) writeObject(s *Schema) {
+	if s.MinProperties != -1 {
+		w.p("if len(v) < %d {", s.MinProperties)
+		w.p("errors = append(errors, vd.error(\"minProperties\", \"minimum %%d properties allowed, but found %%d properties\", %d, len(v)))", s.MinProperties)
+		w.p("}")
+	}
+	if s.MaxProperties != -1 {
+		w.p("if len(v) > %d {", s.MaxProperties)
+		w.p("errors = append(errors, vd.error(\"maxProperties\", \"maximum %%d properties allowed, but found %%d properties\", %d, len(v)))", s.MaxProperties)
+		w.p("}")
+	}
+	if len(s.Required) > 0 && s.Draft.version == 3 {
+		// "required" is in property schema
+		for _, pname := range s.Required {
+			w.p("if _, ok := v[%q]; !ok {", pname)
+			w.p("errors = append(errors, vd.error(%q, \"missing properties: %%s\", %q))", "properties/"+escape(pname)+"/required", quote(pname))
+			w.p("}")
+		}
+	} else if len(s.Required) > 0 {
+		w.p("{")
+		w.p("var missing []string")
+		for _, pname := range s.Required {
+			w.p("if _, ok := v[%q]; !ok {", pname)
+			w.p("missing = append(missing, %q)", quote(pname))
+			w.p("}")
+		}
+		w.p("if len(missing) > 0 {")
+		w.p("errors = append(errors, vd.error(\"required\", \"missing properties: %%s\", strings.Join(missing, \", \")))")
+		w.p("}")
+		w.p("}")
+	}
+
+	for _, pname := range sortedKeys(s.Properties) {
+		w.p("if pvalue, ok := v[%q]; ok {", pname)
+		w.p("if f.track {")
+		w.p("f.eval.addProp(%q)", pname)
+		w.p("}")
+		w.appendErr(fmt.Sprintf("vd.validate(%d, \"properties\", propToken(%q), pvalue, propToken(%q))", w.id(s.Properties[pname]), pname, pname))
+		w.p("}")
+	}
+
+	if s.PropertyNames != nil {
+		w.p("for pname := range v {")
+		w.appendErr(fmt.Sprintf("vd.validate(%d, \"propertyNames\", token{}, pname, propToken(pname))", w.id(s.PropertyNames)))
+		w.p("}")
+	}
+
+	if s.RegexProperties {
+		w.p("for pname := range v {")
+		w.p("if !jsonschema.Formats[\"regex\"](pname) {")
+		w.p("errors = append(errors, vd.error(\"\", \"patternProperty %%s is not valid regex\", quote(pname)))")
+		w.p("}")
+		w.p("}")
+	}
+
+	if len(s.PatternProperties) > 0 || s.AdditionalProperties != nil {
+		w.writeAdditional(s)
+	}
+
+	for _, dname := range sortedKeys(s.Dependencies) {
+		w.p("if _, ok := v[%q]; ok {", dname)
+		switch dvalue := s.Dependencies[dname].(type) {
+		case *Schema:
+			w.appendErr(fmt.Sprintf("vd.validateInplace(f, %d, \"dependencies\", propToken(%q))", w.id(dvalue), dname))
+		case []string:
+			for i, pname := range dvalue {
+				w.p("if _, ok := v[%q]; !ok {", pname)
+				w.p("errors = append(errors, vd.error(%q, \"property %%s is required, if %%s property exists\", %q, %q))", "dependencies/"+escape(dname)+"/"+strconv.Itoa(i), quote(pname), quote(dname))
+				w.p("}")
+			}
+		}
+		w.p("}")
+	}
+	for _, dname := range sortedKeys(s.DependentRequired) {
+		w.p("if _, ok := v[%q]; ok {", dname)
+		for i, pname := range s.DependentRequired[dname] {
+			w.p("if _, ok := v[%q]; !ok {", pname)
+			w.p("errors = append(errors, vd.error(%q, \"property %%s is required, if %%s property exists\", %q, %q))", "dependentRequired/"+escape(dname)+"/"+strconv.Itoa(i), quote(pname), quote(dname))
+			w.p("}")
+		}
+		w.p("}")
+	}
+	for _, dname := range sortedKeys(s.DependentSchemas) {
+		w.p("if _, ok := v[%q]; ok {", dname)
+		w.appendErr(fmt.Sprintf("vd.validateInplace(f, %d, \"dependentSchemas\", propToken(%q))", w.id(s.DependentSchemas[dname]), dname))
+		w.p("}")
+	}
+}
+
+// writeAdditional writes code for patternProperties + additionalProperties.
+func (w *goWriter) writeAdditional(s *Schema) {
+	_, additionalSchema := s.AdditionalProperties.(*Schema)
+	if len(s.PatternProperties) == 0 && s.AdditionalProperties == true {
+		// no property to be validated
+		w.p("f.eval.allProps = true")
+		return
+	}
+	w.p("{")
+	w.p("var additional []string")
+	if len(s.PatternProperties) > 0 || additionalSchema {
+		w.p("for pname, pvalue := range v {")
+	} else {
+		w.p("for pname := range v {")
+	}
+	w.p("matched := false")
+	if len(s.Properties) > 0 {
+		var names []string
+		for _, pname := range sortedKeys(s.Properties) {
+			names = append(names, strconv.Quote(pname))
+		}
+		w.p("switch pname {")
+		w.p("case %s:", strings.Join(names, ", "))
+		w.p("matched = true")
+		w.p("}")
+	}
+	for _, pattern := range sortedPatterns(s) {
+		re := w.newVar(fmt.Sprintf("regexp.MustCompile(%q)", pattern))
+		w.p("if %s.MatchString(pname) {", re)
+		w.p("matched = true")
+		w.p("if f.track {")
+		w.p("f.eval.addProp(pname)")
+		w.p("}")
+		w.appendErr(fmt.Sprintf("vd.validate(%d, \"patternProperties\", propToken(%q), pvalue, propToken(pname))", w.id(s.PatternProperties[s.pattern(pattern)]), pattern))
+		w.p("}")
+	}
+	w.p("if matched {")
+	w.p("continue")
+	w.p("}")
+	switch additionalProps := s.AdditionalProperties.(type) {
+	case bool:
+		if !additionalProps {
+			w.p("additional = append(additional, quote(pname))")
+		}
+	case *Schema:
+		w.appendErr(fmt.Sprintf("vd.validate(%d, \"additionalProperties\", token{}, pvalue, propToken(pname))", w.id(additionalProps)))
+	}
+	w.p("}")
+	w.p("if len(additional) > 0 {")
+	w.p("errors = append(errors, vd.error(\"additionalProperties\", \"additionalProperties %%s not allowed\", strings.Join(additional, \", \")))")
+	w.p("}")
+	if s.AdditionalProperties != nil {
+		w.p("f.eval.allProps = true")
+	}
+	w.p("}")
+}
+
+func (w *goWriter) writeArray(s *Schema) {
+	if s.MinItems != -1 {
+		w.p("if len(v) < %d {", s.MinItems)
+		w.p("errors = append(errors, vd.error(\"minItems\", \"minimum %%d items required, but found %%d items\", %d, len(v)))", s.MinItems)
+		w.p("}")
+	}
+	if s.MaxItems != -1 {
+		w.p("if len(v) > %d {", s.MaxItems)
+		w.p("errors = append(errors, vd.error(\"maxItems\", \"maximum %%d items required, but found %%d items\", %d, len(v)))", s.MaxItems)
+		w.p("}")
+	}
+	if s.UniqueItems {
+		w.p("if i, j := duplicates(v); j != -1 {")
+		w.p("errors = append(errors, vd.error(\"uniqueItems\", \"items at index %%d and %%d are equal\", i, j))")
+		w.p("}")
+	}
+
+	// items + additionalItems
+	switch items := s.Items.(type) {
+	case *Schema:
+		w.p("for i, item := range v {")
+		w.appendErr(fmt.Sprintf("vd.validate(%d, \"items\", token{}, item, indexToken(i))", w.id(items)))
+		w.p("}")
+		w.p("f.eval.allItems = true")
+	case []*Schema:
+		w.p("for i, item := range v {")
+		w.p("if i < %d {", len(items))
+		w.p("if f.track {")
+		w.p("f.eval.addItem(i)")
+		w.p("}")
+		w.appendErr(fmt.Sprintf("vd.validate(%s[i], \"items\", indexToken(i), item, indexToken(i))", w.ids(items)))
+		if sch, ok := s.AdditionalItems.(*Schema); ok {
+			w.p("} else {")
+			w.p("if f.track {")
+			w.p("f.eval.addItem(i)")
+			w.p("}")
+			w.appendErr(fmt.Sprintf("vd.validate(%d, \"additionalItems\", token{}, item, indexToken(i))", w.id(sch)))
+			w.p("}")
+		} else {
+			w.p("} else {")
+			w.p("break")
+			w.p("}")
+		}
+		w.p("}")
+		if additionalItems, ok := s.AdditionalItems.(bool); ok {
+			if additionalItems {
+				w.p("f.eval.allItems = true")
+			} else {
+				w.p("if len(v) > %d {", len(items))
+				w.p("errors = append(errors, vd.error(\"additionalItems\", \"only %%d items are allowed, but found %%d items\", %d, len(v)))", len(items))
+				w.p("}")
+			}
+		}
+	}
+
+	// prefixItems + items
+	if len(s.PrefixItems) > 0 || s.Items2020 != nil {
+		w.p("for i, item := range v {")
+		w.p("if i < %d {", len(s.PrefixItems))
+		w.p("if f.track {")
+		w.p("f.eval.addItem(i)")
+		w.p("}")
+		if len(s.PrefixItems) > 0 {
+			w.appendErr(fmt.Sprintf("vd.validate(%s[i], \"prefixItems\", indexToken(i), item, indexToken(i))", w.ids(s.PrefixItems)))
+		}
+		if s.Items2020 != nil {
+			w.p("} else {")
+			w.appendErr(fmt.Sprintf("vd.validate(%d, \"items\", token{}, item, indexToken(i))", w.id(s.Items2020)))
+			w.p("}")
+		} else {
+			w.p("} else {")
+			w.p("break")
+			w.p("}")
+		}
+		w.p("}")
+		if s.Items2020 != nil {
+			w.p("f.eval.allItems = true")
+		}
+	}
+
+	// contains + minContains + maxContains
+	if s.Contains != nil && (s.MinContains != -1 || s.MaxContains != -1) {
+		w.p("{")
+		w.p("matched := 0")
+		w.p("quiet := vd.quiet")
+		w.p("vd.quiet = true")
+		w.p("for i, item := range v {")
+		w.p("if vd.validate(%d, \"contains\", token{}, item, indexToken(i)) == nil {", w.id(s.Contains))
+		w.p("matched++")
+		if s.ContainsEval {
+			w.p("if f.track {")
+			w.p("f.eval.addItem(i)")
+			w.p("}")
+		}
+		w.p("}")
+		w.p("}")
+		w.p("vd.quiet = quiet")
+		if s.MinContains != -1 {
+			w.p("if matched < %d {", s.MinContains)
+			w.p("var causes []error")
+			w.p("if !vd.quiet {")
+			w.p("for i, item := range v {")
+			w.p("if err := vd.validate(%d, \"contains\", token{}, item, indexToken(i)); err != nil {", w.id(s.Contains))
+			w.p("causes = append(causes, err)")
+			w.p("}")
+			w.p("}")
+			w.p("}")
+			w.p("errors = append(errors, add(vd.error(\"minContains\", \"valid must be >= %%d, but got %%d\", %d, matched), causes...))", s.MinContains)
+			w.p("}")
+		}
+		if s.MaxContains != -1 {
+			w.p("if matched > %d {", s.MaxContains)
+			w.p("errors = append(errors, vd.error(\"maxContains\", \"valid must be <= %%d, but got %%d\", %d, matched))", s.MaxContains)
+			w.p("}")
+		}
+		w.p("}")
+	}
+}
+
+func (w *goWriter) writeString(s *Schema) {
+	// minLength + maxLength
+	if s.MinLength != -1 || s.MaxLength != -1 {
+		w.p("{")
+		w.p("length := utf8.RuneCountInString(v)")
+		if s.MinLength != -1 {
+			w.p("if length < %d {", s.MinLength)
+			w.p("errors = append(errors, vd.error(\"minLength\", \"length must be >= %%d, but got %%d\", %d, length))", s.MinLength)
+			w.p("}")
+		}
+		if s.MaxLength != -1 {
+			w.p("if length > %d {", s.MaxLength)
+			w.p("errors = append(errors, vd.error(\"maxLength\", \"length must be <= %%d, but got %%d\", %d, length))", s.MaxLength)
+			w.p("}")
+		}
+		w.p("}")
+	}
+
+	if s.Pattern != nil {
+		re := w.newVar(fmt.Sprintf("regexp.MustCompile(%q)", s.Pattern.String()))
+		w.p("if !%s.MatchString(v) {", re)
+		w.p("errors = append(errors, vd.error(\"pattern\", \"does not match pattern %%s\", %q))", quote(s.Pattern.String()))
+		w.p("}")
+	}
+
+	// contentEncoding + contentMediaType
+	if s.decoder != nil || s.mediaType != nil {
+		w.p("{")
+		w.p("decoded := %v", s.ContentEncoding == "")
+		w.p("var content []byte")
+		if s.decoder != nil {
+			w.p("if b, err := jsonschema.Decoders[%q](v); err != nil {", s.ContentEncoding)
+			w.p("errors = append(errors, vd.error(\"contentEncoding\", \"value is not %%s encoded\", %q))", s.ContentEncoding)
+			w.p("} else {")
+			w.p("content, decoded = b, true")
+			w.p("}")
+		}
+		if s.mediaType != nil {
+			w.p("if decoded {")
+			if s.decoder == nil {
+				w.p("content = []byte(v)")
+			}
+			w.p("if err := jsonschema.MediaTypes[%q](content); err != nil {", s.ContentMediaType)
+			w.p("errors = append(errors, vd.error(\"contentMediaType\", \"value is not of mediatype %%s\", %q))", quote(s.ContentMediaType))
+			w.p("}")
+			w.p("}")
+		}
+		if s.ContentSchema != nil {
+			w.p("if decoded {")
+			w.p("if contentJSON, err := unmarshal(content); err != nil {")
+			w.p("errors = append(errors, vd.error(\"contentSchema\", \"value is not valid json\"))")
+			w.p("} else {")
+			w.appendErr(fmt.Sprintf("vd.validate(%d, \"contentSchema\", token{}, contentJSON, token{})", w.id(s.ContentSchema)))
+			w.p("}")
+			w.p("}")
+		}
+		w.p("_, _ = content, decoded")
+		w.p("}")
+	}
+}
+
+func (w *goWriter) writeNumber(s *Schema) {
+	limits := []struct {
+		kw   string
+		r    *big.Rat
+		cond string
+		msg  string
+	}{
+		{"minimum", s.Minimum, "< 0", "must be >= %v but found %v"},
+		{"exclusiveMinimum", s.ExclusiveMinimum, "<= 0", "must be > %v but found %v"},
+		{"maximum", s.Maximum, "> 0", "must be <= %v but found %v"},
+		{"exclusiveMaximum", s.ExclusiveMaximum, ">= 0", "must be < %v but found %v"},
+	}
+	var body bool
+	for _, l := range limits {
+		if l.r == nil {
+			continue
+		}
+		if !body {
+			w.p("num := toRat(v)")
+			body = true
+		}
+		r := w.newVar(fmt.Sprintf("mustRat(%q)", l.r.RatString()))
+		w.p("if num.Cmp(%s) %s {", r, l.cond)
+		w.p("errors = append(errors, vd.error(%q, %q, %q, v))", l.kw, l.msg, ratString(l.r))
+		w.p("}")
+	}
+	if s.MultipleOf != nil {
+		if !body {
+			w.p("num := toRat(v)")
+		}
+		r := w.newVar(fmt.Sprintf("mustRat(%q)", s.MultipleOf.RatString()))
+		w.p("if !isMultipleOf(num, %s) {", r)
+		kw := s.keyword("multipleOf")
+		w.p("errors = append(errors, vd.error(%q, \"%%v not %s %%v\", v, %q))", kw, kw, ratString(s.MultipleOf))
+		w.p("}")
+	}
+}
+
+// pattern returns the compiled regex of patternProperties with given string.
+func (s *Schema) pattern(str string) *regexp.Regexp {
+	for re := range s.PatternProperties {
+		if re.String() == str {
+			return re
+		}
+	}
+	return nil
+}
+
+func sortedPatterns(s *Schema) []string {
+	var patterns []string
+	for re := range s.PatternProperties {
+		patterns = append(patterns, re.String())
+	}
+	sort.Strings(patterns)
+	return patterns
+}
+
+// goLiteral returns go expression for json value v.
+func goLiteral(v interface{}) string {
+	switch v := v.(type) {
+	case nil:
+		return "nil"
+	case bool:
+		return strconv.FormatBool(v)
+	case string:
+		return strconv.Quote(v)
+	case json.Number:
+		return fmt.Sprintf("json.Number(%q)", string(v))
+	case []interface{}:
+		var items []string
+		for _, item := range v {
+			items = append(items, goLiteral(item))
+		}
+		return "[]interface{}{" + strings.Join(items, ", ") + "}"
+	case map[string]interface{}:
+		var entries []string
+		for _, k := range sortedKeys(v) {
+			entries = append(entries, fmt.Sprintf("%q: %s", k, goLiteral(v[k])))
+		}
+		return "map[string]interface{}{" + strings.Join(entries, ", ") + "}"
+	}
+	// other number types
+	return fmt.Sprintf("json.Number(%q)", fmt.Sprint(v))
+}
+
+// goName converts property name to exported go identifier.
+func goName(pname string) string {
+	var sb strings.Builder
+	upper := true
+	for _, r := range pname {
+		switch {
+		case r == '_' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9':
+			if upper {
+				sb.WriteString(strings.ToUpper(string(r)))
+				upper = false
+			} else {
+				sb.WriteRune(r)
+			}
+		default:
+			upper = true
+		}
+	}
+	name := sb.String()
+	if name == "" || name[0] >= '0' && name[0] <= '9' || name[0] == '_' {
+		name = "X" + name
+	}
+	return name
+}
+
+// identV matches usage of variable v in generated code.
+var identV = regexp.MustCompile(`\bv\b`)
+
+// sortedKeys returns keys of map m in sorted order.
+// m must be a map with string keys.
+func sortedKeys(m interface{}) []string {
+	var keys []string
+	for _, k := range reflect.ValueOf(m).MapKeys() {
+		keys = append(keys, k.String())
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,525 @@
+package jsonschema
+
+// goRuntime is the runtime used by the code generated by GoGenerator.
+// It mirrors the validator of this package, so that the generated code
+// reports same errors as Schema.Validate.
+const goRuntime = `
+// validator holds the state of a single validation.
+type validator struct {
+	vloc  string
+	scope []*frame
+	quiet bool
+}
+
+// frame is an entry in dynamic scope.
+type frame struct {
+	sch     int
+	v       interface{}
+	kw      string
+	ktok    token
+	vtok    token
+	inplace bool
+	discard bool
+	track   bool
+	eval    evaluated
+}
+
+type schemaInfo struct {
+	loc             string // absolute location
+	url             string // location without fragment
+	ptr             string // fragment of location without '#'
+	recursiveAnchor bool
+	dynamicAnchor   string
+	dynamicAnchors  []int
+	validate        func(*validator, *frame) error
+}
+
+func validateRoot(sch int, v interface{}) (err error) {
+	vd := &validator{}
+	defer func() {
+		if r := recover(); r != nil {
+			switch r := r.(type) {
+			case jsonschema.InfiniteLoopError, jsonschema.InvalidJSONTypeError:
+				err = r.(error)
+			default:
+				panic(r)
+			}
+		}
+	}()
+	f := vd.push(sch, "", token{}, v, token{}, false)
+	if err := schemas[sch].validate(vd, f); err != nil {
+		ve := &jsonschema.ValidationError{
+			AbsoluteKeywordLocation: schemas[sch].loc,
+			Message:                 fmt.Sprintf("doesn't validate with %s", schemas[sch].loc),
+		}
+		return causes(ve, err)
+	}
+	return nil
+}
+
+type token struct {
+	kind uint8 // 0=raw 1=prop 2=index
+	s    string
+	i    int
+}
+
+func propToken(name string) token { return token{kind: 1, s: name} }
+func indexToken(i int) token      { return token{kind: 2, i: i} }
+
+func joinToken(path string, t token) string {
+	if tok := t.String(); tok != "" {
+		return path + "/" + tok
+	}
+	return path
+}
+
+func (t token) String() string {
+	switch t.kind {
+	case 2:
+		return strconv.Itoa(t.i)
+	case 1:
+		return escape(t.s)
+	default:
+		return t.s
+	}
+}
+
+func (vd *validator) push(sch int, kw string, ktok token, v interface{}, vtok token, inplace bool) *frame {
+	if inplace {
+		vd.checkLoop(sch, kw, ktok)
+	}
+	n := len(vd.scope)
+	if n < cap(vd.scope) {
+		vd.scope = vd.scope[:n+1]
+	} else {
+		vd.scope = append(vd.scope, nil)
+	}
+	f := vd.scope[n]
+	if f == nil {
+		f = new(frame)
+		vd.scope[n] = f
+	}
+	f.sch, f.v = sch, v
+	f.kw, f.ktok, f.vtok = kw, ktok, vtok
+	f.inplace, f.discard = inplace, false
+	f.track = false
+	return f
+}
+
+func (vd *validator) pop() {
+	vd.scope = vd.scope[:len(vd.scope)-1]
+}
+
+func (vd *validator) checkLoop(sch int, kw string, ktok token) {
+	i := len(vd.scope) - 1
+	for ; i >= 0; i-- {
+		if vd.scope[i].sch == sch {
+			break
+		}
+		if !vd.scope[i].inplace {
+			return
+		}
+	}
+	if i < 0 {
+		return
+	}
+	for i > 0 && vd.scope[i].inplace {
+		i--
+	}
+	path := schemas[vd.scope[i].sch].loc
+	for _, f := range vd.scope[i+1:] {
+		if p := joinToken(f.kw, f.ktok); p != "" {
+			path += "/" + p
+		}
+	}
+	if p := joinToken(kw, ktok); p != "" {
+		path += "/" + p
+	}
+	panic(jsonschema.InfiniteLoopError(path))
+}
+
+func (vd *validator) validate(sch int, kw string, ktok token, v interface{}, vtok token) error {
+	f := vd.push(sch, kw, ktok, v, vtok, false)
+	err := schemas[sch].validate(vd, f)
+	vd.pop()
+	return err
+}
+
+func (vd *validator) validateInplace(f *frame, sch int, kw string, ktok token) error {
+	sf := vd.push(sch, kw, ktok, f.v, token{}, true)
+	sf.track = f.track
+	err := schemas[sch].validate(vd, sf)
+	if err == nil && f.track {
+		f.eval.merge(&sf.eval)
+	}
+	vd.pop()
+	return err
+}
+
+func (vd *validator) validateQuiet(f *frame, sch int, kw string, ktok token) error {
+	quiet := vd.quiet
+	vd.quiet = true
+	err := vd.validateInplace(f, sch, kw, ktok)
+	vd.quiet = quiet
+	return err
+}
+
+func (vd *validator) validateRef(f *frame, sch int, refKeyword string) error {
+	if err := vd.validateInplace(f, sch, refKeyword, token{}); err != nil {
+		var url = schemas[sch].loc
+		if schemas[f.sch].url == schemas[sch].url {
+			url = schemas[sch].ptr
+		}
+		return causes(vd.error(refKeyword, "doesn't validate with %s", quote(url)), err)
+	}
+	return nil
+}
+
+func (vd *validator) recursiveRef(sch int) int {
+	for _, f := range vd.scope {
+		if schemas[f.sch].recursiveAnchor {
+			return f.sch
+		}
+	}
+	return sch
+}
+
+func (vd *validator) dynamicRef(sch int) int {
+	anchor := schemas[sch].dynamicAnchor
+	res := sch
+	for i := len(vd.scope) - 1; i >= 0; i-- {
+		sf := vd.scope[i]
+		if sf.discard {
+			break
+		}
+		for _, da := range schemas[sf.sch].dynamicAnchors {
+			if schemas[da].dynamicAnchor == anchor && da != sch {
+				res = da
+				break
+			}
+		}
+	}
+	return res
+}
+
+func (vd *validator) causes(f *frame, kw string, schemas []int) []error {
+	if vd.quiet {
+		return nil
+	}
+	var causes []error
+	for i, sch := range schemas {
+		if err := vd.validateInplace(f, sch, kw, indexToken(i)); err != nil {
+			causes = append(causes, err)
+		}
+	}
+	return causes
+}
+
+// errQuiet is the error reported in quiet mode. It is never exposed,
+// as generated validators do not support extensions.
+var errQuiet = &jsonschema.ValidationError{Message: "validation failed"}
+
+func (vd *validator) error(keywordPath string, format string, a ...interface{}) *jsonschema.ValidationError {
+	if vd.quiet {
+		return errQuiet
+	}
+	f := vd.scope[len(vd.scope)-1]
+	return &jsonschema.ValidationError{
+		KeywordLocation:         vd.keywordLocation(keywordPath),
+		AbsoluteKeywordLocation: joinPtr(schemas[f.sch].loc, keywordPath),
+		InstanceLocation:        vd.instanceLocation(),
+		Message:                 fmt.Sprintf(format, a...),
+	}
+}
+
+func (vd *validator) keywordLocation(path string) string {
+	var sb strings.Builder
+	for _, f := range vd.scope[1:] {
+		if p := joinToken(f.kw, f.ktok); p != "" {
+			sb.WriteByte('/')
+			sb.WriteString(p)
+		}
+	}
+	if path != "" {
+		sb.WriteByte('/')
+		sb.WriteString(path)
+	}
+	return sb.String()
+}
+
+func (vd *validator) instanceLocation() string {
+	var sb strings.Builder
+	sb.WriteString(vd.vloc)
+	for _, f := range vd.scope[1:] {
+		if !f.inplace {
+			if tok := f.vtok.String(); tok != "" {
+				sb.WriteByte('/')
+				sb.WriteString(tok)
+			}
+		}
+	}
+	return sb.String()
+}
+
+func add(ve *jsonschema.ValidationError, causes ...error) error {
+	if ve == errQuiet {
+		return ve
+	}
+	for _, cause := range causes {
+		ve.Causes = append(ve.Causes, cause.(*jsonschema.ValidationError))
+	}
+	return ve
+}
+
+func causes(ve *jsonschema.ValidationError, err error) error {
+	if ve == errQuiet {
+		return ve
+	}
+	if err := err.(*jsonschema.ValidationError); err.Message == "" {
+		ve.Causes = err.Causes
+	} else {
+		add(ve, err)
+	}
+	return ve
+}
+
+func joinPtr(ptr1, ptr2 string) string {
+	if len(ptr1) == 0 {
+		return ptr2
+	}
+	if len(ptr2) == 0 {
+		return ptr1
+	}
+	return ptr1 + "/" + ptr2
+}
+
+func quote(s string) string {
+	s = fmt.Sprintf("%q", s)
+	s = strings.ReplaceAll(s, "\\\"", "\"")
+	s = strings.ReplaceAll(s, "'", "\\'")
+	return "'" + s[1:len(s)-1] + "'"
+}
+
+func escape(token string) string {
+	token = strings.ReplaceAll(token, "~", "~0")
+	token = strings.ReplaceAll(token, "/", "~1")
+	return url.PathEscape(token)
+}
+
+type evaluated struct {
+	allProps bool
+	props    map[string]struct{}
+	allItems bool
+	items    map[int]struct{}
+}
+
+func (e *evaluated) reset() {
+	e.allProps, e.allItems = false, false
+	for pname := range e.props {
+		delete(e.props, pname)
+	}
+	for i := range e.items {
+		delete(e.items, i)
+	}
+}
+
+func (e *evaluated) addProp(pname string) {
+	if e.props == nil {
+		e.props = make(map[string]struct{})
+	}
+	e.props[pname] = struct{}{}
+}
+
+func (e *evaluated) addItem(i int) {
+	if e.items == nil {
+		e.items = make(map[int]struct{})
+	}
+	e.items[i] = struct{}{}
+}
+
+func (e *evaluated) hasProp(pname string) bool {
+	if e.allProps {
+		return true
+	}
+	_, ok := e.props[pname]
+	return ok
+}
+
+func (e *evaluated) hasItem(i int) bool {
+	if e.allItems {
+		return true
+	}
+	_, ok := e.items[i]
+	return ok
+}
+
+func (e *evaluated) merge(o *evaluated) {
+	if o.allProps {
+		e.allProps = true
+	} else if !e.allProps {
+		for pname := range o.props {
+			e.addProp(pname)
+		}
+	}
+	if o.allItems {
+		e.allItems = true
+	} else if !e.allItems {
+		for i := range o.items {
+			e.addItem(i)
+		}
+	}
+}
+
+func jsonType(v interface{}) string {
+	switch v.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case json.Number, float32, float64, int, int8, int32, int64, uint, uint8, uint32, uint64:
+		return "number"
+	case string:
+		return "string"
+	case []interface{}:
+		return "array"
+	case map[string]interface{}:
+		return "object"
+	}
+	panic(jsonschema.InvalidJSONTypeError(fmt.Sprintf("%T", v)))
+}
+
+func equals(v1, v2 interface{}) bool {
+	v1Type := jsonType(v1)
+	if v1Type != jsonType(v2) {
+		return false
+	}
+	switch v1Type {
+	case "array":
+		arr1, arr2 := v1.([]interface{}), v2.([]interface{})
+		if len(arr1) != len(arr2) {
+			return false
+		}
+		for i := range arr1 {
+			if !equals(arr1[i], arr2[i]) {
+				return false
+			}
+		}
+		return true
+	case "object":
+		obj1, obj2 := v1.(map[string]interface{}), v2.(map[string]interface{})
+		if len(obj1) != len(obj2) {
+			return false
+		}
+		for k, v1 := range obj1 {
+			if v2, ok := obj2[k]; ok {
+				if !equals(v1, v2) {
+					return false
+				}
+			} else {
+				return false
+			}
+		}
+		return true
+	case "number":
+		return toRat(v1).Cmp(toRat(v2)) == 0
+	default:
+		return v1 == v2
+	}
+}
+
+func contains(values []interface{}, v interface{}) bool {
+	for _, item := range values {
+		if equals(v, item) {
+			return true
+		}
+	}
+	return false
+}
+
+// duplicates returns indexes i < j of first pair of equal items
+// in arr, such that j is smallest. It returns -1, -1 if none.
+func duplicates(arr []interface{}) (int, int) {
+	if len(arr) <= 8 {
+		for j := 1; j < len(arr); j++ {
+			for i := 0; i < j; i++ {
+				if equals(arr[i], arr[j]) {
+					return i, j
+				}
+			}
+		}
+		return -1, -1
+	}
+	seen := make(map[string][]int, len(arr))
+	for j, item := range arr {
+		key := canonical(item)
+		for _, i := range seen[key] {
+			if equals(arr[i], item) {
+				return i, j
+			}
+		}
+		seen[key] = append(seen[key], j)
+	}
+	return -1, -1
+}
+
+// canonical returns string, which is same for equal json values.
+func canonical(v interface{}) string {
+	switch v := v.(type) {
+	case string:
+		return "s" + v
+	case []interface{}:
+		return "a" + strconv.Itoa(len(v))
+	case map[string]interface{}:
+		return "o" + strconv.Itoa(len(v))
+	}
+	if jsonType(v) == "number" {
+		return "n" + toRat(v).RatString()
+	}
+	return fmt.Sprint(v)
+}
+
+func toRat(v interface{}) *big.Rat {
+	var s string
+	switch v := v.(type) {
+	case json.Number:
+		s = string(v)
+	case float64:
+		s = strconv.FormatFloat(v, 'g', -1, 64)
+	case float32:
+		s = strconv.FormatFloat(float64(v), 'g', -1, 32)
+	default:
+		s = fmt.Sprint(v)
+	}
+	r, ok := new(big.Rat).SetString(s)
+	if !ok {
+		panic(jsonschema.InvalidJSONTypeError(fmt.Sprintf("%T", v)))
+	}
+	return r
+}
+
+func isInt(v interface{}) bool {
+	return toRat(v).IsInt()
+}
+
+func isMultipleOf(r, m *big.Rat) bool {
+	return new(big.Rat).Quo(r, m).IsInt()
+}
+
+func mustRat(s string) *big.Rat {
+	r, _ := new(big.Rat).SetString(s)
+	return r
+}
+
+func unmarshal(b []byte) (interface{}, error) {
+	decoder := json.NewDecoder(bytes.NewReader(b))
+	decoder.UseNumber()
+	var doc interface{}
+	if err := decoder.Decode(&doc); err != nil {
+		return nil, err
+	}
+	if t, _ := decoder.Token(); t != nil {
+		return nil, fmt.Errorf("invalid character %v after top-level value", t)
+	}
+	return doc, nil
+}
+`
@@ -0,0 +1,335 @@
+package jsonschema_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/santhosh-tekuri/jsonschema/v5"
+)
+
+// genCase is a test case run by the conformance driver.
+type genCase struct {
+	Name string          // test name, used for reporting
+	Func int             // index of generated validate function
+	Data json.RawMessage // instance
+}
+
+// TestGoGeneratorConformance generates code for the schemas in
+// JSON-Schema-Test-Suite, and checks that the generated code reports
+// same errors as Schema.Validate for all test instances.
+//
+// It builds the generated code using go command, so it is skipped in
+// short mode.
+func TestGoGeneratorConformance(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping in short mode")
+	}
+	goCmd, err := exec.LookPath("go")
+	if err != nil {
+		t.Skip("go command not found")
+	}
+
+	g := jsonschema.NewGoGenerator("main")
+	person := compilePerson(t)
+	if err := g.AddType("Person", person); err != nil {
+		t.Fatal(err)
+	}
+	var schemas []*jsonschema.Schema
+	var cases []genCase
+	walkSuite(t, func(path string, group testGroup, sch *jsonschema.Schema) {
+		if err := g.Add(fmt.Sprintf("V%d", len(schemas)), sch); err != nil {
+			t.Errorf("%s %s: %v", path, group.Description, err)
+			return
+		}
+		for _, test := range group.Tests {
+			cases = append(cases, genCase{
+				Name: path + ": " + group.Description + ": " + test.Description,
+				Func: len(schemas),
+				Data: test.Data,
+			})
+		}
+		schemas = append(schemas, sch)
+	})
+
+	// write the module with generated code and driver
+	dir, err := ioutil.TempDir("", "jsonschema-gen")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	var buf bytes.Buffer
+	if _, err := g.WriteTo(&buf); err != nil {
+		t.Fatal(err)
+	}
+	pwd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var funcs []string
+	for i := range schemas {
+		funcs = append(funcs, fmt.Sprintf("V%d", i))
+	}
+	files := map[string]string{
+		"go.mod":  "module conformance\n\ngo 1.15\n\nrequire github.com/santhosh-tekuri/jsonschema/v5 v5.0.0\n\nreplace github.com/santhosh-tekuri/jsonschema/v5 => " + pwd + "\n",
+		"gen.go":  buf.String(),
+		"main.go": strings.Replace(genDriver, "FUNCS", strings.Join(funcs, ", "), 1),
+	}
+	for name, content := range files {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	input, err := json.Marshal(cases)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cmd := exec.Command(goCmd, "run", ".")
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), "GOFLAGS=-mod=mod", "GOPROXY=off")
+	cmd.Stdin = bytes.NewReader(input)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	output, err := cmd.Output()
+	if err != nil {
+		t.Fatalf("running generated code: %v\n%s", err, stderr.String())
+	}
+	var results struct {
+		Cases []string
+		Types []string
+	}
+	if err := json.Unmarshal(output, &results); err != nil {
+		t.Fatal(err)
+	}
+	if len(results.Cases) != len(cases) {
+		t.Fatalf("got %d results, want %d", len(results.Cases), len(cases))
+	}
+
+	for i, c := range cases {
+		decoder := json.NewDecoder(bytes.NewReader(c.Data))
+		decoder.UseNumber()
+		var v interface{}
+		if err := decoder.Decode(&v); err != nil {
+			t.Fatal(err)
+		}
+		want := normalizeErr(schemas[c.Func].Validate(v))
+		if got := results.Cases[i]; got != want {
+			t.Errorf("%s:\n got: %s\nwant: %s", c.Name, got, want)
+		}
+	}
+	for i, instance := range personInstances {
+		want := normalizeErr(person.Validate(decodeString(t, instance)))
+		if got := results.Types[i]; got != want {
+			t.Errorf("Person %d:\n got: %s\nwant: %s", i, got, want)
+		}
+	}
+}
+
+// normalizeErr returns string representation of err, which does not
+// depend on map iteration order during validation.
+func normalizeErr(err error) string {
+	if err == nil {
+		return "valid"
+	}
+	ve, ok := err.(*jsonschema.ValidationError)
+	if !ok {
+		return err.Error()
+	}
+	msg := ve.Message
+	if strings.HasPrefix(msg, "additionalProperties ") {
+		props := strings.Split(strings.TrimSuffix(strings.TrimPrefix(msg, "additionalProperties "), " not allowed"), ", ")
+		sort.Strings(props)
+		msg = "additionalProperties " + strings.Join(props, ", ") + " not allowed"
+	}
+	var causes []string
+	for _, c := range ve.Causes {
+		causes = append(causes, normalizeErr(c))
+	}
+	sort.Strings(causes)
+	return fmt.Sprintf("[I#%s] [S#%s] [A#%s] %s {%s}", ve.InstanceLocation, ve.KeywordLocation, ve.AbsoluteKeywordLocation, msg, strings.Join(causes, ", "))
+}
+
+// genDriver is main.go of conformance module. It reads genCase list
+// from stdin and writes normalized result of each case to stdout.
+const genDriver = `package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/santhosh-tekuri/jsonschema/v5"
+)
+
+var funcs = []func(interface{}) error{FUNCS}
+
+func main() {
+	var cases []struct {
+		Func int
+		Data json.RawMessage
+	}
+	if err := json.NewDecoder(os.Stdin).Decode(&cases); err != nil {
+		panic(err)
+	}
+	var results struct {
+		Cases []string
+		Types []string
+	}
+	for _, c := range cases {
+		decoder := json.NewDecoder(bytes.NewReader(c.Data))
+		decoder.UseNumber()
+		var v interface{}
+		if err := decoder.Decode(&v); err != nil {
+			panic(err)
+		}
+		results.Cases = append(results.Cases, normalizeErr(funcs[c.Func](v)))
+	}
+
+	score, zip, badZip := json.Number("1.5"), "12345", "12"
+	persons := []*Person{
+		{Name: "gopher", Age: 5, Score: &score, Tags: []string{"a"}, Home: &PersonHome{City: "x"}, Offices: []*PersonHome{{City: "y", Zip: &zip}}},
+		{Name: "gopher", Age: -1, Offices: []*PersonHome{{City: "y", Zip: &badZip}, {Zip: &zip}}},
+	}
+	for _, p := range persons {
+		results.Types = append(results.Types, normalizeErr(p.Validate()))
+	}
+
+	if err := json.NewEncoder(os.Stdout).Encode(results); err != nil {
+		panic(err)
+	}
+}
+
+func normalizeErr(err error) string {
+	if err == nil {
+		return "valid"
+	}
+	ve, ok := err.(*jsonschema.ValidationError)
+	if !ok {
+		return err.Error()
+	}
+	msg := ve.Message
+	if strings.HasPrefix(msg, "additionalProperties ") {
+		props := strings.Split(strings.TrimSuffix(strings.TrimPrefix(msg, "additionalProperties "), " not allowed"), ", ")
+		sort.Strings(props)
+		msg = "additionalProperties " + strings.Join(props, ", ") + " not allowed"
+	}
+	var causes []string
+	for _, c := range ve.Causes {
+		causes = append(causes, normalizeErr(c))
+	}
+	sort.Strings(causes)
+	return fmt.Sprintf("[I#%s] [S#%s] [A#%s] %s {%s}", ve.InstanceLocation, ve.KeywordLocation, ve.AbsoluteKeywordLocation, msg, strings.Join(causes, ", "))
+}
+`
+
+// personSchema is used to test struct types generated by GoGenerator.
+const personSchema = `{
+	"$defs": {
+		"address": {
+			"type": "object",
+			"properties": {"city": {"type": "string"}, "zip": {"type": "string", "pattern": "^[0-9]{5}$"}},
+			"required": ["city"]
+		}
+	},
+	"type": "object",
+	"properties": {
+		"name": {"type": "string"},
+		"age": {"type": "integer", "minimum": 0},
+		"score": {"type": "number"},
+		"tags": {"type": "array", "items": {"type": "string"}},
+		"home": {"$ref": "#/$defs/address"},
+		"offices": {"type": "array", "items": {"$ref": "#/$defs/address"}},
+		"x-extra": true
+	},
+	"required": ["name", "age"]
+}`
+
+// personInstances are json equivalents of Person values
+// validated by genDriver.
+var personInstances = []string{
+	`{"name": "gopher", "age": 5, "score": 1.5, "tags": ["a"], "home": {"city": "x"}, "offices": [{"city": "y", "zip": "12345"}]}`,
+	`{"name": "gopher", "age": -1, "offices": [{"city": "y", "zip": "12"}, {"city": "", "zip": "12345"}]}`,
+}
+
+func compilePerson(t *testing.T) *jsonschema.Schema {
+	t.Helper()
+	c := jsonschema.NewCompiler()
+	if err := c.AddResource("person.json", strings.NewReader(personSchema)); err != nil {
+		t.Fatal(err)
+	}
+	sch, err := c.Compile("person.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return sch
+}
+
+func TestGoGeneratorTypes(t *testing.T) {
+	sch := compilePerson(t)
+	g := jsonschema.NewGoGenerator("person")
+	if err := g.AddType("Person", sch); err != nil {
+		t.Fatal(err)
+	}
+	if err := g.Add("ValidatePerson", sch); err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if _, err := g.WriteTo(&buf); err != nil {
+		t.Fatal(err)
+	}
+	src := buf.String()
+	for _, want := range []string{
+		"type Person struct {",
+		"Name string `json:\"name\"`",
+		"Age int64 `json:\"age\"`",
+		"Score *json.Number `json:\"score,omitempty\"`",
+		"Tags []string `json:\"tags,omitempty\"`",
+		"Home *PersonHome `json:\"home,omitempty\"`",
+		"Offices []*PersonHome `json:\"offices,omitempty\"`",
+		"XExtra interface{} `json:\"x-extra,omitempty\"`",
+		"type PersonHome struct {",
+		"func (t *Person) Validate() error {",
+		"func ValidatePerson(v interface{}) error {",
+	} {
+		if !containsFields(src, want) {
+			t.Errorf("generated code does not contain %q", want)
+		}
+	}
+
+	// schemas with extensions are not supported
+	c := jsonschema.NewCompiler()
+	c.RegisterExtension("powerOf", powerOfMeta, powerOfCompiler{})
+	if err := c.AddResource("test.json", strings.NewReader(`{"powerOf": 10}`)); err != nil {
+		t.Fatal(err)
+	}
+	sch, err := c.Compile("test.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := jsonschema.NewGoGenerator("test").Add("Validate", sch); err == nil {
+		t.Error("error expected for schema with extension")
+	}
+}
+
+// containsFields tells whether src has a line with same fields as line.
+// gofmt aligns struct fields, so whitespace may differ.
+func containsFields(src, line string) bool {
+	want := strings.Join(strings.Fields(line), " ")
+	for _, l := range strings.Split(src, "\n") {
+		if strings.Join(strings.Fields(l), " ") == want {
+			return true
+		}
+	}
+	return false
+}
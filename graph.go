@@ -0,0 +1,162 @@
+package jsonschema
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+)
+
+// Graph is the dependency graph of the resources, used by a Schema.
+type Graph struct {
+	// Resources lists the urls of resources, in sorted order.
+	Resources []string `json:"resources"`
+
+	// Edges lists the references between resources.
+	Edges []GraphEdge `json:"edges"`
+
+	// Cycles lists the groups of resources, which depend on each other.
+	Cycles [][]string `json:"cycles,omitempty"`
+}
+
+// GraphEdge is a reference from one resource to another.
+type GraphEdge struct {
+	From     string `json:"from"`     // url of referring resource
+	To       string `json:"to"`       // location of referred schema
+	Keyword  string `json:"keyword"`  // one of $ref, $recursiveRef, $dynamicRef, $schema
+	Location string `json:"location"` // absolute keyword location
+}
+
+// Graph returns the dependency graph of resources used by s.
+// Metaschemas of standard drafts are not included.
+func (s *Schema) Graph() *Graph {
+	g := &Graph{Edges: []GraphEdge{}}
+	seen := make(map[*Schema]bool)
+	resources := make(map[string]bool)
+	var visit func(s *Schema)
+	visit = func(s *Schema) {
+		if s == nil || seen[s] || isDraftMeta(s) {
+			return
+		}
+		seen[s] = true
+		from := s.url()
+		resources[from] = true
+		edge := func(keyword string, to *Schema) {
+			if to == nil || isDraftMeta(to) || to.url() == from {
+				return
+			}
+			g.Edges = append(g.Edges, GraphEdge{from, to.Location, keyword, s.Location + "/" + keyword})
+		}
+		edge("$schema", s.meta)
+		edge("$ref", s.Ref)
+		edge("$recursiveRef", s.RecursiveRef)
+		edge("$dynamicRef", s.DynamicRef)
+
+		visit(s.meta)
+		for _, sch := range subschemas(s) {
+			visit(sch)
+		}
+		for _, key := range sortedKeys(s.extSchemas) {
+			visit(s.extSchemas[key])
+		}
+		for _, key := range sortedKeys(s.extRefs) {
+			visit(s.extRefs[key])
+		}
+	}
+	visit(s)
+
+	for url := range resources {
+		g.Resources = append(g.Resources, url)
+	}
+	sort.Strings(g.Resources)
+	sort.Slice(g.Edges, func(i, j int) bool {
+		return g.Edges[i].Location < g.Edges[j].Location
+	})
+	g.Cycles = g.cycles()
+	return g
+}
+
+func isDraftMeta(s *Schema) bool {
+	d := findDraft(s.url())
+	return d != nil && d.meta == s
+}
+
+// cycles returns the strongly connected components with
+// more than one resource, using Tarjan's algorithm.
+func (g *Graph) cycles() [][]string {
+	adj := make(map[string][]string)
+	for _, e := range g.Edges {
+		to, _ := split(e.To)
+		adj[e.From] = append(adj[e.From], to)
+	}
+
+	var cycles [][]string
+	index := make(map[string]int)
+	low := make(map[string]int)
+	onStack := make(map[string]bool)
+	var stack []string
+	var strongConnect func(v string)
+	strongConnect = func(v string) {
+		index[v] = len(index)
+		low[v] = index[v]
+		stack = append(stack, v)
+		onStack[v] = true
+		for _, w := range adj[v] {
+			if _, ok := index[w]; !ok {
+				strongConnect(w)
+				if low[w] < low[v] {
+					low[v] = low[w]
+				}
+			} else if onStack[w] && index[w] < low[v] {
+				low[v] = index[w]
+			}
+		}
+		if low[v] == index[v] {
+			var scc []string
+			for {
+				w := stack[len(stack)-1]
+				stack = stack[:len(stack)-1]
+				onStack[w] = false
+				scc = append(scc, w)
+				if w == v {
+					break
+				}
+			}
+			if len(scc) > 1 {
+				sort.Strings(scc)
+				cycles = append(cycles, scc)
+			}
+		}
+	}
+	for _, v := range g.Resources {
+		if _, ok := index[v]; !ok {
+			strongConnect(v)
+		}
+	}
+	sort.Slice(cycles, func(i, j int) bool {
+		return cycles[i][0] < cycles[j][0]
+	})
+	return cycles
+}
+
+// WriteDOT writes the graph in Graphviz DOT format to w.
+// Edges are labelled with their keyword location.
+func (g *Graph) WriteDOT(w io.Writer) error {
+	var err error
+	p := func(format string, a ...interface{}) {
+		if err == nil {
+			_, err = fmt.Fprintf(w, format, a...)
+		}
+	}
+	p("digraph {\n")
+	for _, r := range g.Resources {
+		p("\t%s;\n", strconv.Quote(r))
+	}
+	for _, e := range g.Edges {
+		to, _ := split(e.To)
+		_, loc := split(e.Location)
+		p("\t%s -> %s [label=%s];\n", strconv.Quote(e.From), strconv.Quote(to), strconv.Quote(loc))
+	}
+	p("}\n")
+	return err
+}
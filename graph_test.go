@@ -0,0 +1,65 @@
+package jsonschema_test
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/santhosh-tekuri/jsonschema/v5"
+)
+
+func TestGraph(t *testing.T) {
+	c := jsonschema.NewCompiler()
+	c.Loader = &mapLoader{docs: map[string]string{
+		"map:///a.json": `{
+			"$schema": "map:///meta.json",
+			"properties": {
+				"b": {"$ref": "b.json#/$defs/b"},
+				"c": {"$ref": "c.json"},
+				"self": {"$ref": "#"}
+			}
+		}`,
+		"map:///b.json": `{
+			"$defs": {
+				"b": {"items": {"$ref": "a.json"}}
+			}
+		}`,
+		"map:///c.json": `{
+			"$id": "map:///c.json",
+			"$dynamicAnchor": "node",
+			"items": {"$dynamicRef": "#node"}
+		}`,
+		"map:///meta.json": `{
+			"$schema": "https://json-schema.org/draft/2020-12/schema",
+			"$ref": "https://json-schema.org/draft/2020-12/schema"
+		}`,
+	}}
+	sch, err := c.Compile("map:///a.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	g := sch.Graph()
+	want := &jsonschema.Graph{
+		Resources: []string{"map:///a.json", "map:///b.json", "map:///c.json", "map:///meta.json"},
+		Edges: []jsonschema.GraphEdge{
+			{"map:///a.json", "map:///meta.json#", "$schema", "map:///a.json#/$schema"},
+			{"map:///a.json", "map:///b.json#/$defs/b", "$ref", "map:///a.json#/properties/b/$ref"},
+			{"map:///a.json", "map:///c.json#", "$ref", "map:///a.json#/properties/c/$ref"},
+			{"map:///b.json", "map:///a.json#", "$ref", "map:///b.json#/$defs/b/items/$ref"},
+		},
+		Cycles: [][]string{{"map:///a.json", "map:///b.json"}},
+	}
+	if !reflect.DeepEqual(g, want) {
+		t.Fatalf("got %#v\nwant %#v", g, want)
+	}
+
+	var buf bytes.Buffer
+	if err := g.WriteDOT(&buf); err != nil {
+		t.Fatal(err)
+	}
+	edge := `"map:///b.json" -> "map:///a.json" [label="#/$defs/b/items/$ref"];`
+	if !strings.Contains(buf.String(), edge) {
+		t.Fatalf("%s not found in\n%s", edge, buf.String())
+	}
+}
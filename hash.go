@@ -0,0 +1,140 @@
+package jsonschema
+
+import (
+	"fmt"
+	"hash/maphash"
+	"math/big"
+)
+
+// hashSeed is used to hash json values. It is random per process,
+// so that hash collisions cannot be crafted in instances.
+var hashSeed = maphash.MakeSeed()
+
+// hash tags for json types. these distinguish values of different
+// types with same content, for example "1" and 1.
+const (
+	hashNull uint64 = iota + 1
+	hashFalse
+	hashTrue
+	hashNumber
+	hashString
+	hashArray
+	hashObject
+)
+
+// hashValue returns canonical hash of json value v. It is consistent
+// with equals: if equals(v1, v2) then hashValue(v1) == hashValue(v2).
+// In particular numbers are hashed by their numeric value, so that 1
+// and 1.0 have same hash. Object hash does not depend on key order.
+//
+// It panics with InvalidJSONTypeError if v is not valid json value.
+func hashValue(v interface{}) uint64 {
+	switch v := v.(type) {
+	case nil:
+		return mix(hashNull)
+	case bool:
+		if v {
+			return mix(hashTrue)
+		}
+		return mix(hashFalse)
+	case string:
+		return mix(hashString ^ hashStr(v))
+	case []interface{}:
+		h := mix(hashArray + uint64(len(v)))
+		for _, item := range v {
+			h = mix(h ^ hashValue(item))
+		}
+		return h
+	case map[string]interface{}:
+		// sum of entry hashes is independent of iteration order
+		var sum uint64
+		for k, item := range v {
+			sum += mix(hashStr(k) ^ mix(hashValue(item)))
+		}
+		return mix(hashObject + uint64(len(v)) ^ sum)
+	}
+	switch jsonType(v) {
+	case "number":
+		if d, ok := toDecimal(v); ok {
+			h := mix(hashNumber ^ d.mant)
+			h = mix(h ^ uint64(uint32(d.exp)))
+			if d.neg {
+				h = mix(h)
+			}
+			return h
+		}
+		// number cannot be represented as decimal. whether a number is
+		// representable as decimal depends only on its value, so it is
+		// safe to hash such numbers differently.
+		r, _ := new(big.Rat).SetString(fmt.Sprint(v))
+		return mix(hashNumber ^ hashStr(r.RatString()))
+	}
+	panic("BUG: unreachable") // jsonType panics for invalid types
+}
+
+func hashStr(s string) uint64 {
+	var h maphash.Hash
+	h.SetSeed(hashSeed)
+	_, _ = h.WriteString(s)
+	return h.Sum64()
+}
+
+// mix is the finalizer of splitmix64. It spreads the bits of x,
+// so that simple combinations of hashes do not collide.
+func mix(x uint64) uint64 {
+	x ^= x >> 30
+	x *= 0xbf58476d1ce4e5b9
+	x ^= x >> 27
+	x *= 0x94d049bb133111eb
+	x ^= x >> 31
+	return x
+}
+
+// valueIndex is a hash index of json values, used to check membership
+// of value in large enum in constant time.
+type valueIndex map[uint64][]int // hash to indexes of values with that hash
+
+func newValueIndex(values []interface{}) valueIndex {
+	index := make(valueIndex, len(values))
+	for i, v := range values {
+		h := hashValue(v)
+		index[h] = append(index[h], i)
+	}
+	return index
+}
+
+// contains tells whether v is equal to any of the values indexed.
+func (index valueIndex) contains(values []interface{}, v interface{}) bool {
+	for _, i := range index[hashValue(v)] {
+		if equals(v, values[i]) {
+			return true
+		}
+	}
+	return false
+}
+
+// duplicates returns indexes i < j of first pair of equal items in arr,
+// such that j is smallest. It returns -1, -1 if items of arr are unique.
+//
+// hashes and seen are scratch buffers, which are returned for reuse.
+func duplicates(arr []interface{}, hashes []uint64, seen map[uint64]int) (int, int, []uint64) {
+	hashes = hashes[:0]
+	for j, item := range arr {
+		h := hashValue(item)
+		hashes = append(hashes, h)
+		if i, ok := seen[h]; ok {
+			if equals(arr[i], item) {
+				return i, j, hashes
+			}
+			// hash collision. check all previous items with same hash
+			for i := range hashes[:j] {
+				if hashes[i] == h && equals(arr[i], item) {
+					return i, j, hashes
+				}
+			}
+		} else {
+			seen[h] = j
+		}
+	}
+	return -1, -1, hashes
+}
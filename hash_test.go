@@ -0,0 +1,88 @@
+package jsonschema
+
+import (
+	"encoding/json"
+	"math/big"
+	"strings"
+	"testing"
+)
+
+func TestHashValue(t *testing.T) {
+	decode := func(s string) interface{} {
+		decoder := json.NewDecoder(strings.NewReader(s))
+		decoder.UseNumber()
+		var v interface{}
+		if err := decoder.Decode(&v); err != nil {
+			t.Fatal(err)
+		}
+		return v
+	}
+	tests := []struct {
+		v1, v2 string
+		equal  bool
+	}{
+		{`1`, `1.0`, true},
+		{`1`, `1e0`, true},
+		{`100`, `1e2`, true},
+		{`-0`, `0`, true},
+		{`1.5`, `15e-1`, true},
+		{`123456789012345678901234567890`, `1.2345678901234567890123456789e29`, true},
+		{`1`, `-1`, false},
+		{`1`, `"1"`, false},
+		{`null`, `false`, false},
+		{`true`, `false`, false},
+		{`""`, `null`, false},
+		{`[1, 2]`, `[2, 1]`, false},
+		{`[1, 2]`, `[1.0, 2.0]`, true},
+		{`[]`, `{}`, false},
+		{`{"a": 1, "b": [true]}`, `{"b": [true], "a": 1.0}`, true},
+		{`{"a": 1, "b": 2}`, `{"a": 2, "b": 1}`, false},
+		{`{"a": "b"}`, `{"b": "a"}`, false},
+		{`{"a": {}}`, `{"a": []}`, false},
+	}
+	for _, test := range tests {
+		v1, v2 := decode(test.v1), decode(test.v2)
+		if got := equals(v1, v2); got != test.equal {
+			t.Fatalf("equals(%s, %s): got %v, want %v", test.v1, test.v2, got, test.equal)
+		}
+		if got := hashValue(v1) == hashValue(v2); got != test.equal {
+			t.Errorf("hashValue(%s) == hashValue(%s): got %v, want %v", test.v1, test.v2, got, test.equal)
+		}
+	}
+
+	// go types produced by custom decoders
+	r, _ := new(big.Rat).SetString("1e400")
+	for _, v := range []interface{}{1, int64(1), uint8(1), 1.0, float32(1), json.Number("1.00")} {
+		if hashValue(v) != hashValue(json.Number("1")) {
+			t.Errorf("hashValue(%T(%v)) differs from hashValue(1)", v, v)
+		}
+	}
+	if hashValue(json.Number("1e400")) != hashValue(json.Number(r.FloatString(0))) {
+		t.Error("hashValue(1e400) differs for same value")
+	}
+}
+
+func TestDuplicates(t *testing.T) {
+	tests := []struct {
+		arr  []interface{}
+		i, j int
+	}{
+		{[]interface{}{}, -1, -1},
+		{[]interface{}{"a", "b", "c"}, -1, -1},
+		{[]interface{}{"a", "b", "a", "b"}, 0, 2},
+		{[]interface{}{"a", "b", "b", "a"}, 1, 2},
+		{[]interface{}{1.0, json.Number("2"), json.Number("1.0")}, 0, 2},
+	}
+	seen := make(map[uint64]int)
+	var hashes []uint64
+	for _, test := range tests {
+		var i, j int
+		i, j, hashes = duplicates(test.arr, hashes, seen)
+		for h := range seen {
+			delete(seen, h)
+		}
+		if i != test.i || j != test.j {
+			t.Errorf("duplicates(%v): got %d %d, want %d %d", test.arr, i, j, test.i, test.j)
+		}
+	}
+}
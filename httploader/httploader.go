@@ -6,12 +6,28 @@
 // To use httploader, link this package into your program:
 //
 //	import _ "github.com/santhosh-tekuri/jsonschema/v5/httploader"
+//
+// For more control over loading, use Loader with Compiler.Loader.
 package httploader
 
 import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"io/ioutil"
 	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/santhosh-tekuri/jsonschema/v5"
 )
@@ -21,18 +37,346 @@ var Client = http.DefaultClient
 
 // Load loads resource from given http(s) url.
 func Load(url string) (io.ReadCloser, error) {
-	resp, err := Client.Get(url)
+	l := &Loader{Client: Client}
+	return l.Load(context.Background(), url)
+}
+
+func init() {
+	jsonschema.Loaders["http"] = Load
+	jsonschema.Loaders["https"] = Load
+}
+
+// Loader is a jsonschema.Loader for http/https url.
+//
+// The zero value loads any url with http.DefaultClient,
+// without timeout, size limit, retries and caching.
+type Loader struct {
+	// Client is used to send requests. If nil, http.DefaultClient is used.
+	Client *http.Client
+
+	// Timeout limits the time taken by each request, including
+	// reading the response body. Zero means no timeout.
+	Timeout time.Duration
+
+	// MaxSize is the maximum size of response body in bytes.
+	// Zero means no limit.
+	MaxSize int64
+
+	// Allow lists the urls that can be loaded. If empty, all urls
+	// are allowed. Entry with "://" is url prefix like
+	// "https://example.com/schemas/", whose scheme and host must
+	// match exactly and path as prefix. Other entries are host patterns
+	// like "*.example.com", matched against host name without port,
+	// using path.Match. It is also applied to each redirect.
+	Allow []string
+
+	// Header is added to each request. Use it to send
+	// Authorization or other custom headers.
+	Header http.Header
+
+	// Retries is the number of times a request is retried, on network
+	// errors or responses with status 429 or 5xx.
+	Retries int
+
+	// RetryDelay is the delay before first retry. It is doubled
+	// after each retry. Defaults to 100ms.
+	RetryDelay time.Duration
+
+	// CacheDir is the directory, where responses are cached. Cached
+	// responses are used while fresh as per Cache-Control or Expires
+	// header, and revalidated using ETag or Last-Modified after that.
+	// If empty, responses are not cached.
+	CacheDir string
+}
+
+// Load implements jsonschema.Loader.
+func (l *Loader) Load(ctx context.Context, url string) (io.ReadCloser, error) {
+	if !l.allowed(url) {
+		return nil, notAllowedError(url)
+	}
+
+	var entry *cacheEntry
+	if l.CacheDir != "" {
+		entry = l.readCache(url)
+		if entry != nil && time.Now().Before(entry.Expires) {
+			return ioutil.NopCloser(bytes.NewReader(entry.body)), nil
+		}
+	}
+
+	delay := l.RetryDelay
+	if delay == 0 {
+		delay = 100 * time.Millisecond
+	}
+	for i := 0; ; i++ {
+		body, err := l.fetch(ctx, url, entry)
+		if err == nil {
+			return ioutil.NopCloser(bytes.NewReader(body)), nil
+		}
+		var re retryableError
+		if i == l.Retries || !errors.As(err, &re) {
+			return nil, err
+		}
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+		delay *= 2
+	}
+}
+
+// retryableError is the error for which request is retried.
+type retryableError struct {
+	err error
+}
+
+func (e retryableError) Error() string {
+	return e.err.Error()
+}
+
+func (e retryableError) Unwrap() error {
+	return e.err
+}
+
+// notAllowedError is the error for url, which is not in Loader.Allow.
+type notAllowedError string
+
+func (e notAllowedError) Error() string {
+	return fmt.Sprintf("%s is not allowed", string(e))
+}
+
+func (l *Loader) allowed(s string) bool {
+	if len(l.Allow) == 0 {
+		return true
+	}
+	u, err := url.Parse(s)
+	if err != nil {
+		return false
+	}
+	for _, pattern := range l.Allow {
+		if strings.Contains(pattern, "://") {
+			if hasPrefix(u, pattern) {
+				return true
+			}
+		} else if ok, _ := path.Match(pattern, u.Hostname()); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// hasPrefix tells whether u has url prefix. scheme and host must be
+// same, and only path is matched as prefix, so that prefix
+// "https://example.com" does not match "https://example.com.evil.org".
+func hasPrefix(u *url.URL, prefix string) bool {
+	p, err := url.Parse(prefix)
+	if err != nil || u.User != nil {
+		return false
+	}
+	if u.Scheme != p.Scheme || !strings.EqualFold(u.Host, p.Host) {
+		return false
+	}
+	// resolve dot segments, which server may resolve
+	upath := path.Clean("/" + u.Path)
+	if strings.HasSuffix(u.Path, "/") && upath != "/" {
+		upath += "/"
+	}
+	return strings.HasPrefix(upath, p.Path)
+}
+
+// fetch sends the request for url, and returns the response body.
+// entry is the stale cache entry, used for revalidation.
+func (l *Loader) fetch(ctx context.Context, url string, entry *cacheEntry) ([]byte, error) {
+	if l.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, l.Timeout)
+		defer cancel()
+	}
+	req, err := http.NewRequest(http.MethodGet, url, nil)
 	if err != nil {
 		return nil, err
 	}
-	if resp.StatusCode != http.StatusOK {
-		_ = resp.Body.Close()
+	req = req.WithContext(ctx)
+	for k, v := range l.Header {
+		req.Header[k] = v
+	}
+	if entry != nil {
+		if entry.ETag != "" {
+			req.Header.Set("If-None-Match", entry.ETag)
+		}
+		if entry.LastModified != "" {
+			req.Header.Set("If-Modified-Since", entry.LastModified)
+		}
+	}
+
+	client := l.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	if len(l.Allow) > 0 {
+		client = l.checkRedirect(client)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		var na notAllowedError
+		if ctx.Err() != nil || errors.As(err, &na) {
+			return nil, err
+		}
+		return nil, retryableError{err}
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusNotModified && entry != nil:
+		entry.Expires = expires(resp.Header)
+		l.writeCache(url, entry)
+		return entry.body, nil
+	case resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500:
+		return nil, retryableError{fmt.Errorf("%s returned status code %d", url, resp.StatusCode)}
+	case resp.StatusCode != http.StatusOK:
 		return nil, fmt.Errorf("%s returned status code %d", url, resp.StatusCode)
 	}
-	return resp.Body, nil
+
+	var r io.Reader = resp.Body
+	if l.MaxSize > 0 {
+		r = io.LimitReader(r, l.MaxSize+1)
+	}
+	body, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	if l.MaxSize > 0 && int64(len(body)) > l.MaxSize {
+		return nil, fmt.Errorf("%s response exceeds %d bytes", url, l.MaxSize)
+	}
+
+	if l.CacheDir != "" && !hasDirective(resp.Header, "no-store") {
+		l.writeCache(url, &cacheEntry{
+			URL:          url,
+			ETag:         resp.Header.Get("ETag"),
+			LastModified: resp.Header.Get("Last-Modified"),
+			Expires:      expires(resp.Header),
+			body:         body,
+		})
+	}
+	return body, nil
 }
 
-func init() {
-	jsonschema.Loaders["http"] = Load
-	jsonschema.Loaders["https"] = Load
+// checkRedirect returns copy of client, which does not follow
+// redirects to urls that are not allowed.
+func (l *Loader) checkRedirect(client *http.Client) *http.Client {
+	c := *client
+	c.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+		if !l.allowed(req.URL.String()) {
+			return notAllowedError(req.URL.String())
+		}
+		if client.CheckRedirect != nil {
+			return client.CheckRedirect(req, via)
+		}
+		if len(via) >= 10 { // same as http.Client default
+			return errors.New("stopped after 10 redirects")
+		}
+		return nil
+	}
+	return &c
+}
+
+// cache ---
+
+// cacheEntry is the metadata of cached response.
+type cacheEntry struct {
+	URL          string
+	ETag         string
+	LastModified string
+	Expires      time.Time // response is fresh until Expires
+	body         []byte
+}
+
+// expires returns the time until which the response is fresh.
+func expires(h http.Header) time.Time {
+	now := time.Now()
+	if hasDirective(h, "no-cache") {
+		return now
+	}
+	for _, d := range strings.Split(h.Get("Cache-Control"), ",") {
+		d = strings.TrimSpace(d)
+		if strings.HasPrefix(d, "max-age=") {
+			if secs, err := strconv.Atoi(strings.TrimPrefix(d, "max-age=")); err == nil {
+				return now.Add(time.Duration(secs) * time.Second)
+			}
+		}
+	}
+	if t, err := http.ParseTime(h.Get("Expires")); err == nil {
+		return t
+	}
+	return now
+}
+
+func hasDirective(h http.Header, directive string) bool {
+	for _, d := range strings.Split(h.Get("Cache-Control"), ",") {
+		if strings.TrimSpace(d) == directive {
+			return true
+		}
+	}
+	return false
+}
+
+// cacheFile returns the path of cache file for url.
+//
+// The file has the metadata as json in first line, followed by
+// the body. Keeping both in one file ensures that body always
+// matches its metadata.
+func (l *Loader) cacheFile(url string) string {
+	sum := sha256.Sum256([]byte(url))
+	return filepath.Join(l.CacheDir, hex.EncodeToString(sum[:])+".cache")
+}
+
+// readCache returns the cache entry for url, or nil if not found.
+func (l *Loader) readCache(url string) *cacheEntry {
+	b, err := ioutil.ReadFile(l.cacheFile(url))
+	if err != nil {
+		return nil
+	}
+	i := bytes.IndexByte(b, '\n')
+	if i == -1 {
+		return nil
+	}
+	entry := &cacheEntry{}
+	if err := json.Unmarshal(b[:i], entry); err != nil || entry.URL != url {
+		return nil
+	}
+	entry.body = b[i+1:]
+	return entry
+}
+
+// writeCache writes entry to cache. Errors are ignored, as
+// cache is only an optimization.
+func (l *Loader) writeCache(url string, entry *cacheEntry) {
+	b, err := json.Marshal(entry)
+	if err != nil {
+		return
+	}
+	if err := os.MkdirAll(l.CacheDir, 0755); err != nil {
+		return
+	}
+	b = append(b, '\n')
+	_ = writeFile(l.cacheFile(url), append(b, entry.body...))
+}
+
+// writeFile writes file atomically, so that concurrent
+// readers never see partial content.
+func writeFile(name string, b []byte) error {
+	f, err := ioutil.TempFile(filepath.Dir(name), filepath.Base(name)+".tmp")
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(b); err != nil {
+		f.Close()
+		os.Remove(f.Name())
+		return err
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(f.Name())
+		return err
+	}
+	return os.Rename(f.Name(), name)
 }
@@ -0,0 +1,234 @@
+package httploader_test
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/santhosh-tekuri/jsonschema/v5"
+	"github.com/santhosh-tekuri/jsonschema/v5/httploader"
+)
+
+func load(t *testing.T, l *httploader.Loader, url string) (string, error) {
+	t.Helper()
+	r, err := l.Load(context.Background(), url)
+	if err != nil {
+		return "", err
+	}
+	defer r.Close()
+	b, err := ioutil.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(b), nil
+}
+
+func TestLoader(t *testing.T) {
+	fails := int32(2)
+	mux := http.NewServeMux()
+	mux.HandleFunc("/schema.json", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"$ref": "base.json"}`))
+	})
+	mux.HandleFunc("/base.json", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"type": "string"}`))
+	})
+	mux.HandleFunc("/auth.json", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Write([]byte(`{}`))
+	})
+	mux.HandleFunc("/slow.json", func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-time.After(5 * time.Second):
+		case <-r.Context().Done():
+		}
+	})
+	mux.HandleFunc("/flaky.json", func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&fails, -1) >= 0 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte(`{}`))
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	t.Run("compile", func(t *testing.T) {
+		c := jsonschema.NewCompiler()
+		c.Loader = jsonschema.SchemeLoader{"http": &httploader.Loader{}}
+		sch, err := c.Compile(server.URL + "/schema.json")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := sch.Validate(1); err == nil {
+			t.Fatal("error expected")
+		}
+	})
+
+	t.Run("header", func(t *testing.T) {
+		l := &httploader.Loader{}
+		if _, err := load(t, l, server.URL+"/auth.json"); err == nil {
+			t.Fatal("error expected")
+		}
+		l.Header = http.Header{"Authorization": {"Bearer token"}}
+		if _, err := load(t, l, server.URL+"/auth.json"); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	t.Run("allow", func(t *testing.T) {
+		l := &httploader.Loader{Allow: []string{"*.example.com", server.URL + "/base"}}
+		if _, err := load(t, l, server.URL+"/schema.json"); err == nil {
+			t.Fatal("error expected")
+		}
+		if _, err := load(t, l, server.URL+"/base.json"); err != nil {
+			t.Fatal(err)
+		}
+		u, err := url.Parse(server.URL)
+		if err != nil {
+			t.Fatal(err)
+		}
+		l.Allow = []string{u.Hostname()} // server url has port
+		if _, err := load(t, l, server.URL+"/schema.json"); err != nil {
+			t.Fatal(err)
+		}
+		l.Allow = []string{u.Host}
+		if _, err := load(t, l, server.URL+"/schema.json"); err == nil {
+			t.Fatal("error expected")
+		}
+
+		// url prefix must not match other hosts or paths
+		l.Allow = []string{"https://example.com", "http://" + u.Host + "/base"}
+		for _, s := range []string{
+			"https://example.com.evil.org/schema.json",
+			"https://example.com@evil.org/schema.json",
+			"https://example.com:8443/schema.json",
+			"http://example.com/schema.json",
+			"http://user@" + u.Host + "/base.json",
+			server.URL + "/base/../schema.json",
+		} {
+			if _, err := load(t, l, s); err == nil || !strings.Contains(err.Error(), "is not allowed") {
+				t.Errorf("%s: got %v, want not allowed error", s, err)
+			}
+		}
+	})
+
+	t.Run("allowRedirect", func(t *testing.T) {
+		other := httptest.NewServer(mux)
+		defer other.Close()
+		redirect := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			http.Redirect(w, r, other.URL+r.URL.Path, http.StatusFound)
+		}))
+		defer redirect.Close()
+
+		l := &httploader.Loader{Allow: []string{redirect.URL + "/"}, Retries: 2, RetryDelay: time.Millisecond}
+		_, err := load(t, l, redirect.URL+"/base.json")
+		if err == nil || !strings.Contains(err.Error(), other.URL+"/base.json is not allowed") {
+			t.Fatalf("got %v, want not allowed error", err)
+		}
+		l.Allow = append(l.Allow, other.URL+"/")
+		if _, err := load(t, l, redirect.URL+"/base.json"); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	t.Run("maxSize", func(t *testing.T) {
+		l := &httploader.Loader{MaxSize: 10}
+		if _, err := load(t, l, server.URL+"/schema.json"); err == nil {
+			t.Fatal("error expected")
+		}
+		l.MaxSize = 100
+		if _, err := load(t, l, server.URL+"/schema.json"); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	t.Run("timeout", func(t *testing.T) {
+		l := &httploader.Loader{Timeout: 50 * time.Millisecond}
+		start := time.Now()
+		if _, err := load(t, l, server.URL+"/slow.json"); err == nil {
+			t.Fatal("error expected")
+		}
+		if d := time.Since(start); d > 2*time.Second {
+			t.Fatalf("timeout not honored: took %v", d)
+		}
+	})
+
+	t.Run("retries", func(t *testing.T) {
+		l := &httploader.Loader{Retries: 1, RetryDelay: time.Millisecond}
+		if _, err := load(t, l, server.URL+"/flaky.json"); err == nil {
+			t.Fatal("error expected")
+		}
+		atomic.StoreInt32(&fails, 2)
+		l.Retries = 2
+		if _, err := load(t, l, server.URL+"/flaky.json"); err != nil {
+			t.Fatal(err)
+		}
+	})
+}
+
+func TestLoaderCache(t *testing.T) {
+	const etag = `"v1"`
+	var requests, notModified int32
+	var cacheControl atomic.Value
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		w.Header().Set("Cache-Control", cacheControl.Load().(string))
+		w.Header().Set("ETag", etag)
+		if r.Header.Get("If-None-Match") == etag {
+			atomic.AddInt32(&notModified, 1)
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Write([]byte(`{"type": "string"}`))
+	}))
+	defer server.Close()
+
+	dir, err := ioutil.TempDir("", "httploader")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	tests := []struct {
+		cacheControl string
+		requests     int32 // for two loads
+		notModified  int32
+	}{
+		{"max-age=3600", 1, 0},
+		{"no-cache", 2, 1},
+		{"no-store", 2, 0},
+	}
+	for _, test := range tests {
+		t.Run(test.cacheControl, func(t *testing.T) {
+			cacheControl.Store(test.cacheControl)
+			atomic.StoreInt32(&requests, 0)
+			atomic.StoreInt32(&notModified, 0)
+			l := &httploader.Loader{CacheDir: dir + "/" + test.cacheControl}
+			for i := 0; i < 2; i++ {
+				body, err := load(t, l, server.URL+"/schema.json")
+				if err != nil {
+					t.Fatal(err)
+				}
+				if body != `{"type": "string"}` {
+					t.Fatalf("got %q", body)
+				}
+			}
+			if got := atomic.LoadInt32(&requests); got != test.requests {
+				t.Errorf("requests: got %d, want %d", got, test.requests)
+			}
+			if got := atomic.LoadInt32(&notModified); got != test.notModified {
+				t.Errorf("notModified: got %d, want %d", got, test.notModified)
+			}
+		})
+	}
+}
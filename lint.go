@@ -0,0 +1,284 @@
+package jsonschema
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Severity of a Finding.
+type Severity string
+
+const (
+	SeverityError   Severity = "error"
+	SeverityWarning Severity = "warning"
+)
+
+// Finding is a problem in schema, reported by Compiler.Lint.
+type Finding struct {
+	Location string   `json:"location"` // absolute keyword location
+	Severity Severity `json:"severity"`
+	Rule     string   `json:"rule"` // rule id, like "unknown-keyword"
+	Message  string   `json:"message"`
+}
+
+func (f Finding) String() string {
+	return fmt.Sprintf("%s: %s: %s [%s]", f.Location, f.Severity, f.Message, f.Rule)
+}
+
+// StrictError is the error returned by Compile in Strict mode,
+// when schema has findings with SeverityError.
+type StrictError struct {
+	Findings []Finding
+}
+
+func (e *StrictError) Error() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "jsonschema: strict mode found %d error(s)", len(e.Findings))
+	for _, f := range e.Findings {
+		b.WriteString("\n  ")
+		b.WriteString(f.String())
+	}
+	return b.String()
+}
+
+// Lint compiles schema at url, and returns the findings in it
+// and in the resources it refers, sorted by location.
+//
+// Following rules are checked:
+//
+//	unknown-keyword   error    keyword not defined by draft, vocabularies or extensions
+//	wrong-draft       error    keyword defined only by other drafts, like prefixItems in draft7
+//	duplicate-id      error    same $id used by more than one schema
+//	duplicate-anchor  error    same anchor used by more than one schema in a resource
+//	ref-sibling       warning  keyword ignored, because it is sibling of $ref in draft7 or earlier
+//	unknown-format    warning  format not registered in compiler
+func (c *Compiler) Lint(url string) ([]Finding, error) {
+	sch, err := c.Compile(url)
+	if err != nil {
+		return nil, err
+	}
+	return c.lint(sch), nil
+}
+
+func (c *Compiler) lint(sch *Schema) []Finding {
+	l := &linter{
+		c:       c,
+		ids:     make(map[string]string),
+		anchors: make(map[string]string),
+		known:   make(map[*Schema]map[string]bool),
+	}
+	for _, u := range sch.Graph().Resources {
+		if r := c.Registry.root(u); r != nil {
+			l.resource(r)
+		}
+	}
+	sort.SliceStable(l.findings, func(i, j int) bool {
+		return l.findings[i].Location < l.findings[j].Location
+	})
+	return l.findings
+}
+
+// strict returns StrictError, if lint finds errors in sch.
+func (c *Compiler) strict(sch *Schema) error {
+	var errs []Finding
+	for _, f := range c.lint(sch) {
+		if f.Severity == SeverityError {
+			errs = append(errs, f)
+		}
+	}
+	if len(errs) > 0 {
+		return &StrictError{errs}
+	}
+	return nil
+}
+
+type linter struct {
+	c        *Compiler
+	findings []Finding
+	ids      map[string]string           // id to location
+	anchors  map[string]string           // base#anchor to location
+	known    map[*Schema]map[string]bool // keywords of metaschema
+}
+
+func (l *linter) report(loc string, severity Severity, rule, format string, a ...interface{}) {
+	l.findings = append(l.findings, Finding{loc, severity, rule, fmt.Sprintf(format, a...)})
+}
+
+func (l *linter) resource(r *resource) {
+	meta := r.draft.meta
+	if r.schema != nil && r.schema.meta != nil {
+		meta = r.schema.meta
+	}
+	known := make(map[string]bool)
+	for kw := range l.keywords(meta) {
+		known[kw] = true
+	}
+	for _, ext := range l.c.extensions {
+		for kw := range l.keywords(ext.meta) {
+			known[kw] = true
+		}
+	}
+	l.schema(r.draft, known, r.doc, r.url, r.url+"#")
+}
+
+// keywords returns the keywords defined by metaschema meta,
+// collected from properties of meta and the schemas it refers.
+func (l *linter) keywords(meta *Schema) map[string]bool {
+	if kws, ok := l.known[meta]; ok {
+		return kws
+	}
+	kws := map[string]bool{"$ref": true}
+	l.known[meta] = kws // to handle cycles
+	var collect func(s *Schema)
+	seen := make(map[*Schema]bool)
+	collect = func(s *Schema) {
+		if s == nil || seen[s] {
+			return
+		}
+		seen[s] = true
+		for kw := range s.Properties {
+			kws[kw] = true
+		}
+		collect(s.Ref)
+		collect(s.DynamicRef)
+		collect(s.RecursiveRef)
+		for _, sch := range s.AllOf {
+			collect(sch)
+		}
+	}
+	collect(meta)
+	return kws
+}
+
+func (l *linter) schema(d *Draft, known map[string]bool, v interface{}, base, loc string) {
+	m, ok := v.(map[string]interface{})
+	if !ok {
+		return
+	}
+
+	if id, err := d.resolveID(base, m); err == nil && id != "" {
+		if prev, ok := l.ids[id]; ok && prev != loc {
+			l.report(loc+"/"+d.id, SeverityError, "duplicate-id", "$id %s is already used at %s", id, prev)
+		} else {
+			l.ids[id] = loc
+		}
+		base = id
+	}
+	for _, anchor := range d.anchors(m) {
+		key := base + "#" + anchor
+		if prev, ok := l.anchors[key]; ok && prev != loc {
+			l.report(loc, SeverityError, "duplicate-anchor", "anchor %q is already used at %s", anchor, prev)
+		} else {
+			l.anchors[key] = loc
+		}
+	}
+
+	_, hasRef := m["$ref"]
+	for _, kw := range sortedKeys(m) {
+		kwLoc := loc + "/" + escape(kw)
+		switch {
+		case known[kw]:
+		case len(l.draftsOf(kw)) > 0:
+			l.report(kwLoc, SeverityError, "wrong-draft", "%s is not a keyword in %s, but in %s", kw, d, strings.Join(l.draftsOf(kw), ", "))
+		default:
+			msg := fmt.Sprintf("unknown keyword %s", kw)
+			if s := suggest(kw, known); s != "" {
+				msg += fmt.Sprintf(", did you mean %s?", s)
+			}
+			l.report(kwLoc, SeverityError, "unknown-keyword", "%s", msg)
+		}
+		if hasRef && d.version <= 7 && kw != "$ref" && kw != "$schema" && kw != "definitions" && kw != "$comment" {
+			l.report(kwLoc, SeverityWarning, "ref-sibling", "%s is ignored, because it is sibling of $ref in %s", kw, d)
+		}
+		if kw == "format" && known[kw] {
+			if f, ok := m[kw].(string); ok && !l.formatExists(f) {
+				l.report(kwLoc, SeverityWarning, "unknown-format", "unknown format %q", f)
+			}
+		}
+
+		pos, ok := d.subschemas[kw]
+		if !ok {
+			continue
+		}
+		switch v := m[kw].(type) {
+		case map[string]interface{}:
+			if pos&self != 0 {
+				l.schema(d, known, v, base, kwLoc)
+			} else if pos&prop != 0 {
+				for _, name := range sortedKeys(v) {
+					l.schema(d, known, v[name], base, kwLoc+"/"+escape(name))
+				}
+			}
+		case []interface{}:
+			if pos&item != 0 {
+				for i, item := range v {
+					l.schema(d, known, item, base, kwLoc+"/"+strconv.Itoa(i))
+				}
+			}
+		}
+	}
+}
+
+// draftsOf returns the drafts, which define keyword kw.
+func (l *linter) draftsOf(kw string) []string {
+	var list []string
+	for _, d := range drafts {
+		if l.keywords(d.meta)[kw] {
+			list = append(list, d.String())
+		}
+	}
+	return list
+}
+
+func (l *linter) formatExists(f string) bool {
+	if _, ok := l.c.Formats[f]; ok {
+		return true
+	}
+	_, ok := Formats[f]
+	return ok
+}
+
+// suggest returns the keyword in known, which is nearest to
+// misspelled kw. Empty string is returned, if none is near.
+func suggest(kw string, known map[string]bool) string {
+	best, bestDist := "", 3
+	for _, k := range sortedKeys(known) {
+		if d := editDistance(kw, k); d < bestDist {
+			best, bestDist = k, d
+		}
+	}
+	return best
+}
+
+// editDistance returns the Levenshtein distance between a and b.
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min3(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(b)]
+}
+
+func min3(a, b, c int) int {
+	if b < a {
+		a = b
+	}
+	if c < a {
+		a = c
+	}
+	return a
+}
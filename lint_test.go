@@ -0,0 +1,92 @@
+package jsonschema_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/santhosh-tekuri/jsonschema/v5"
+)
+
+func TestLint(t *testing.T) {
+	docs := map[string]string{
+		"map:///root.json": `{
+			"$schema": "http://json-schema.org/draft-07/schema#",
+			"type": "object",
+			"properties": {
+				"a": {"typo": "string", "maxLenght": 2},
+				"b": {"prefixItems": [{"type": "string"}]},
+				"c": {"$ref": "#/definitions/c", "minimum": 1},
+				"d": {"type": "string", "format": "no-such-format"},
+				"e": {"$ref": "other.json"},
+				"f": {"$id": "dup.json"},
+				"g": {"$ref": "ref.json"}
+			},
+			"definitions": {
+				"c": {"type": "integer", "format": "email"}
+			}
+		}`,
+		"map:///other.json": `{
+			"$schema": "https://json-schema.org/draft/2020-12/schema",
+			"$defs": {
+				"x": {"$anchor": "x", "additionalItems": false},
+				"y": {"$anchor": "x"},
+				"z": {"$id": "dup.json"}
+			}
+		}`,
+		"map:///ref.json": `{
+			"$schema": "http://json-schema.org/draft-07/schema#",
+			"$ref": "root.json#/definitions/c"
+		}`,
+	}
+	want := []struct {
+		loc, rule string
+		severity  jsonschema.Severity
+	}{
+		{"map:///other.json#/$defs/x/additionalItems", "wrong-draft", jsonschema.SeverityError},
+		{"map:///other.json#/$defs/y", "duplicate-anchor", jsonschema.SeverityError},
+		{"map:///root.json#/properties/a/maxLenght", "unknown-keyword", jsonschema.SeverityError},
+		{"map:///root.json#/properties/a/typo", "unknown-keyword", jsonschema.SeverityError},
+		{"map:///root.json#/properties/b/prefixItems", "wrong-draft", jsonschema.SeverityError},
+		{"map:///root.json#/properties/c/minimum", "ref-sibling", jsonschema.SeverityWarning},
+		{"map:///root.json#/properties/d/format", "unknown-format", jsonschema.SeverityWarning},
+		{"map:///root.json#/properties/f/$id", "duplicate-id", jsonschema.SeverityError},
+	}
+
+	c := jsonschema.NewCompiler()
+	c.Loader = &mapLoader{docs: docs}
+	findings, err := c.Lint("map:///root.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(findings) != len(want) {
+		t.Fatalf("got %d findings, want %d: %v", len(findings), len(want), findings)
+	}
+	for i, f := range findings {
+		if f.Location != want[i].loc || f.Rule != want[i].rule || f.Severity != want[i].severity {
+			t.Errorf("finding %d: got %v, want %v", i, f, want[i])
+		}
+	}
+	if got := findings[2].Message; got != "unknown keyword maxLenght, did you mean maxLength?" {
+		t.Errorf("got message %q", got)
+	}
+
+	t.Run("strict", func(t *testing.T) {
+		c := jsonschema.NewCompiler()
+		c.Loader = &mapLoader{docs: docs}
+		c.Strict = true
+		_, err := c.Compile("map:///root.json")
+		var serr *jsonschema.StrictError
+		if !errors.As(err, &serr) {
+			t.Fatalf("got %v, want StrictError", err)
+		}
+		if len(serr.Findings) != 6 {
+			t.Errorf("got %d errors, want 6: %v", len(serr.Findings), serr.Findings)
+		}
+
+		c = jsonschema.NewCompiler()
+		c.Strict = true
+		if _, err := c.Compile("testdata/person schema.json"); err != nil {
+			t.Fatal(err)
+		}
+	})
+}
@@ -1,13 +1,20 @@
 package jsonschema
 
 import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"errors"
 	"fmt"
 	"io"
+	"io/ioutil"
 	"net/url"
 	"os"
+	"path"
 	"path/filepath"
 	"runtime"
 	"strings"
+	"sync"
 )
 
 func loadFileURL(s string) (io.ReadCloser, error) {
@@ -23,6 +30,28 @@ func loadFileURL(s string) (io.ReadCloser, error) {
 	return os.Open(f)
 }
 
+// loadDataURL loads the data embedded in RFC 2397 data url.
+func loadDataURL(s string) (io.ReadCloser, error) {
+	s, _ = split(s)
+	comma := strings.IndexByte(s, ',')
+	if !strings.HasPrefix(s, "data:") || comma == -1 {
+		return nil, errors.New("jsonschema: invalid data url")
+	}
+	meta, data := s[len("data:"):comma], s[comma+1:]
+	data, err := url.PathUnescape(data)
+	if err != nil {
+		return nil, fmt.Errorf("jsonschema: invalid data url: %v", err)
+	}
+	if strings.HasSuffix(meta, ";base64") {
+		b, err := base64.StdEncoding.DecodeString(data)
+		if err != nil {
+			return nil, fmt.Errorf("jsonschema: invalid data url: %v", err)
+		}
+		return ioutil.NopCloser(bytes.NewReader(b)), nil
+	}
+	return ioutil.NopCloser(strings.NewReader(data)), nil
+}
+
 // Loaders is a registry of functions, which know how to load
 // absolute url of specific schema.
 //
@@ -58,3 +87,110 @@ var LoadURL = func(s string) (io.ReadCloser, error) {
 	}
 	return loader(s)
 }
+
+// Loader loads the document at given absolute url.
+//
+// The ctx is the context passed to Compiler.CompileContext.
+type Loader interface {
+	Load(ctx context.Context, url string) (io.ReadCloser, error)
+}
+
+// LoaderFunc is an adapter to allow the use of ordinary functions as Loader.
+type LoaderFunc func(ctx context.Context, url string) (io.ReadCloser, error)
+
+// Load calls f(ctx, url).
+func (f LoaderFunc) Load(ctx context.Context, url string) (io.ReadCloser, error) {
+	return f(ctx, url)
+}
+
+// FileLoader loads the document at file url.
+var FileLoader Loader = LoaderFunc(func(ctx context.Context, url string) (io.ReadCloser, error) {
+	return loadFileURL(url)
+})
+
+// DataLoader loads the document embedded in RFC 2397 data url.
+// It is not registered in Loaders. Use it with SchemeLoader, to
+// allow references to data urls:
+//
+//	c.Loader = jsonschema.SchemeLoader{"file": jsonschema.FileLoader, "data": jsonschema.DataLoader}
+var DataLoader Loader = LoaderFunc(func(ctx context.Context, url string) (io.ReadCloser, error) {
+	return loadDataURL(url)
+})
+
+// SchemeLoader is a Loader, which delegates to the Loader registered
+// for the url scheme. Key is scheme.
+//
+// It returns LoaderNotFoundError, if no Loader is registered for the scheme.
+type SchemeLoader map[string]Loader
+
+// Load implements Loader.
+func (l SchemeLoader) Load(ctx context.Context, s string) (io.ReadCloser, error) {
+	u, err := url.Parse(s)
+	if err != nil {
+		return nil, err
+	}
+	loader, ok := l[u.Scheme]
+	if !ok {
+		return nil, LoaderNotFoundError(s)
+	}
+	return loader.Load(ctx, s)
+}
+
+// CacheLoader is a Loader, which caches the documents loaded by
+// another Loader in memory. It can be shared by compilers, to avoid
+// loading same documents again.
+//
+// CacheLoader is safe for concurrent use.
+type CacheLoader struct {
+	loader Loader
+	mu     sync.Mutex
+	docs   map[string][]byte // key is url
+}
+
+// NewCacheLoader returns a CacheLoader which caches documents
+// loaded by l.
+func NewCacheLoader(l Loader) *CacheLoader {
+	return &CacheLoader{loader: l, docs: make(map[string][]byte)}
+}
+
+// Load implements Loader.
+func (l *CacheLoader) Load(ctx context.Context, url string) (io.ReadCloser, error) {
+	l.mu.Lock()
+	doc, ok := l.docs[url]
+	l.mu.Unlock()
+	if !ok {
+		r, err := l.loader.Load(ctx, url)
+		if err != nil {
+			return nil, err
+		}
+		defer r.Close()
+		if doc, err = ioutil.ReadAll(r); err != nil {
+			return nil, err
+		}
+		l.mu.Lock()
+		l.docs[url] = doc
+		l.mu.Unlock()
+	}
+	return ioutil.NopCloser(bytes.NewReader(doc)), nil
+}
+
+// Forget removes the document at url from cache.
+func (l *CacheLoader) Forget(url string) {
+	l.mu.Lock()
+	delete(l.docs, url)
+	l.mu.Unlock()
+}
+
+// relPath returns the slash separated path of url relative to base.
+// The path is cleaned, so that it never refers outside base.
+func relPath(base, s string) (string, error) {
+	s, _ = split(s)
+	if !strings.HasPrefix(s, base) {
+		return "", fmt.Errorf("jsonschema: %s is not under %s", s, base)
+	}
+	name, err := url.PathUnescape(s[len(base):])
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimPrefix(path.Clean("/"+name), "/"), nil
+}
@@ -0,0 +1,25 @@
+//go:build go1.16
+// +build go1.16
+
+package jsonschema
+
+import (
+	"context"
+	"io"
+	"io/fs"
+)
+
+// FSLoader returns a Loader, which loads the urls under base from fsys.
+// The path of url relative to base is used as name in fsys.
+//
+// base must end with "/", for example "embed:///schemas/", so that
+// relative references resolve within fsys.
+func FSLoader(base string, fsys fs.FS) Loader {
+	return LoaderFunc(func(ctx context.Context, url string) (io.ReadCloser, error) {
+		name, err := relPath(base, url)
+		if err != nil {
+			return nil, err
+		}
+		return fsys.Open(name)
+	})
+}
@@ -0,0 +1,31 @@
+//go:build go1.16
+// +build go1.16
+
+package jsonschema_test
+
+import (
+	"testing"
+	"testing/fstest"
+
+	"github.com/santhosh-tekuri/jsonschema/v5"
+)
+
+func TestFSLoader(t *testing.T) {
+	fsys := fstest.MapFS{
+		"schemas/schema.json":         {Data: []byte(`{"$ref": "defs/name%20type.json"}`)},
+		"schemas/defs/name type.json": {Data: []byte(`{"$ref": "../../common.json"}`)},
+		"common.json":                 {Data: []byte(`{"type": "string"}`)},
+	}
+	c := jsonschema.NewCompiler()
+	c.Loader = jsonschema.FSLoader("embed:///", fsys)
+	sch, err := c.Compile("embed:///schemas/schema.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := sch.Validate(1); err == nil {
+		t.Fatal("error expected")
+	}
+	if _, err := c.Compile("embed:///missing.json"); err == nil {
+		t.Fatal("error expected")
+	}
+}
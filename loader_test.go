@@ -0,0 +1,248 @@
+package jsonschema_test
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/santhosh-tekuri/jsonschema/v5"
+)
+
+// mapLoader loads documents from map, and counts the loads.
+type mapLoader struct {
+	docs  map[string]string
+	loads map[string]int
+}
+
+func (l *mapLoader) Load(ctx context.Context, url string) (io.ReadCloser, error) {
+	doc, ok := l.docs[url]
+	if !ok {
+		return nil, fmt.Errorf("%s not found", url)
+	}
+	if l.loads == nil {
+		l.loads = make(map[string]int)
+	}
+	l.loads[url]++
+	return ioutil.NopCloser(strings.NewReader(doc)), nil
+}
+
+func TestSchemeLoader(t *testing.T) {
+	loader := jsonschema.SchemeLoader{
+		"map": &mapLoader{docs: map[string]string{
+			"map:///schema.json": `{"$ref": "file.json"}`,
+		}},
+		"file": jsonschema.FileLoader,
+	}
+	c := jsonschema.NewCompiler()
+	c.Loader = loader
+	if _, err := c.Compile("map:///schema.json"); err == nil {
+		t.Fatal("error expected")
+	}
+
+	_, err := loader.Load(context.Background(), "mem:///schema.json")
+	if _, ok := err.(jsonschema.LoaderNotFoundError); !ok {
+		t.Fatalf("got %v, want LoaderNotFoundError", err)
+	}
+
+	sch, err := c.Compile("testdata/person_schema.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := sch.Validate(decodeString(t, `{"firstName": 1}`)); err == nil {
+		t.Fatal("error expected")
+	}
+}
+
+func TestCacheLoader(t *testing.T) {
+	ml := &mapLoader{docs: map[string]string{
+		"map:///schema.json": `{"$ref": "base.json"}`,
+		"map:///base.json":   `{"type": "string"}`,
+	}}
+	cache := jsonschema.NewCacheLoader(ml)
+	for i := 0; i < 2; i++ {
+		c := jsonschema.NewCompiler()
+		c.Loader = cache
+		if _, err := c.Compile("map:///schema.json"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for url, n := range ml.loads {
+		if n != 1 {
+			t.Errorf("%s: loaded %d times", url, n)
+		}
+	}
+
+	cache.Forget("map:///base.json")
+	c := jsonschema.NewCompiler()
+	c.Loader = cache
+	if _, err := c.Compile("map:///schema.json"); err != nil {
+		t.Fatal(err)
+	}
+	if got := ml.loads["map:///base.json"]; got != 2 {
+		t.Errorf("got %d loads after forget, want 2", got)
+	}
+}
+
+func TestCompileContext(t *testing.T) {
+	type key struct{}
+	ml := &mapLoader{docs: map[string]string{
+		"map:///schema.json": `{"$ref": "base.json"}`,
+		"map:///base.json":   `{"type": "string"}`,
+	}}
+
+	t.Run("value", func(t *testing.T) {
+		c := jsonschema.NewCompiler()
+		c.Loader = jsonschema.LoaderFunc(func(ctx context.Context, url string) (io.ReadCloser, error) {
+			if ctx.Value(key{}) != "v" {
+				return nil, errors.New("context is not passed")
+			}
+			return ml.Load(ctx, url)
+		})
+		ctx := context.WithValue(context.Background(), key{}, "v")
+		if _, err := c.CompileContext(ctx, "map:///schema.json"); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	t.Run("cancel", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		c := jsonschema.NewCompiler()
+		c.Loader = jsonschema.LoaderFunc(func(ctx context.Context, url string) (io.ReadCloser, error) {
+			if url == "map:///schema.json" {
+				cancel()
+			}
+			return ml.Load(ctx, url)
+		})
+		_, err := c.CompileContext(ctx, "map:///schema.json")
+		if !errors.Is(err, context.Canceled) {
+			t.Fatalf("got %v, want context.Canceled", err)
+		}
+
+		// resource is not broken by cancelled compilation
+		if _, err := c.Compile("map:///schema.json"); err != nil {
+			t.Fatal(err)
+		}
+	})
+}
+
+func TestDataURL(t *testing.T) {
+	c := jsonschema.NewCompiler()
+	if _, err := c.Compile(`data:application/json,{}`); err == nil {
+		t.Fatal("data url must not be loaded by default")
+	}
+
+	c = jsonschema.NewCompiler()
+	c.Loader = jsonschema.SchemeLoader{"data": jsonschema.DataLoader}
+	sch, err := c.Compile(`data:application/json,{"$ref":"%23/$defs/s","$defs":{"s":{"type":"string"}}}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := sch.Validate(1); err == nil {
+		t.Fatal("error expected")
+	}
+
+	b64 := base64.StdEncoding.EncodeToString([]byte(`{"type": "integer"}`))
+	if sch, err = c.Compile("data:application/json;base64," + b64); err != nil {
+		t.Fatal(err)
+	}
+	if err := sch.Validate("abc"); err == nil {
+		t.Fatal("error expected")
+	}
+
+	// relative reference cannot be resolved against data url
+	if _, err := c.Compile(`data:application/json,{"$ref":"other.json"}`); err == nil {
+		t.Fatal("error expected")
+	}
+}
+
+func TestArchiveLoader(t *testing.T) {
+	files := []struct{ name, content string }{
+		{"./schemas/schema.json", `{"$ref": "defs/name.json"}`},
+		{"schemas/defs/name.json", `{"type": "string"}`},
+		{"secret.json", `{}`},
+	}
+	dir, err := ioutil.TempDir("", "archive")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	// zip
+	zipFile := filepath.Join(dir, "schemas.zip")
+	var zbuf bytes.Buffer
+	zw := zip.NewWriter(&zbuf)
+	for _, f := range files {
+		w, err := zw.Create(f.name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		io.WriteString(w, f.content)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(zipFile, zbuf.Bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// tar.gz
+	tgzFile := filepath.Join(dir, "schemas.tar.gz")
+	var tbuf bytes.Buffer
+	gw := gzip.NewWriter(&tbuf)
+	tw := tar.NewWriter(gw)
+	for _, f := range files {
+		hdr := &tar.Header{Name: f.name, Mode: 0644, Size: int64(len(f.content)), Typeflag: tar.TypeReg}
+		if err := tw.WriteHeader(hdr); err != nil {
+			t.Fatal(err)
+		}
+		io.WriteString(tw, f.content)
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := gw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(tgzFile, tbuf.Bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, file := range []string{zipFile, tgzFile} {
+		t.Run(filepath.Base(file), func(t *testing.T) {
+			loader, err := jsonschema.ArchiveLoader("pack:///v1/", file)
+			if err != nil {
+				t.Fatal(err)
+			}
+			c := jsonschema.NewCompiler()
+			c.Loader = loader
+			sch, err := c.Compile("pack:///v1/schemas/schema.json")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := sch.Validate(1); err == nil {
+				t.Fatal("error expected")
+			}
+			// ".." cannot escape archive root
+			if _, err := loader.Load(context.Background(), "pack:///v1/schemas/../../secret.json"); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := loader.Load(context.Background(), "pack:///v1/../../../secret.json"); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := loader.Load(context.Background(), "pack:///v2/secret.json"); err == nil {
+				t.Fatal("error expected")
+			}
+		})
+	}
+}
@@ -0,0 +1,109 @@
+package jsonschema
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Lockfile pins the documents used by Compiler to SHA-256 digest
+// of their content, so that changes to remote documents do not silently
+// change validation. Documents are verified, whether they are loaded,
+// added by AddResource, or already in shared Registry.
+//
+// Documents loaded from file and data urls are not pinned, because
+// they are either local or immutable.
+//
+// Lockfile is safe for concurrent use.
+type Lockfile struct {
+	// Record tells Compiler to record the digests of loaded documents,
+	// instead of verifying them.
+	Record bool
+
+	mu        sync.Mutex
+	resources map[string]string // url to digest
+}
+
+// NewLockfile returns an empty Lockfile, recording digests.
+func NewLockfile() *Lockfile {
+	return &Lockfile{Record: true, resources: make(map[string]string)}
+}
+
+type lockfileJSON struct {
+	Version   int               `json:"version"`
+	Resources map[string]string `json:"resources"`
+}
+
+// ReadLockfile reads Lockfile written by Lockfile.Write.
+func ReadLockfile(r io.Reader) (*Lockfile, error) {
+	var v lockfileJSON
+	if err := json.NewDecoder(r).Decode(&v); err != nil {
+		return nil, fmt.Errorf("jsonschema: invalid lockfile: %v", err)
+	}
+	if v.Version != 1 {
+		return nil, fmt.Errorf("jsonschema: unsupported lockfile version %d", v.Version)
+	}
+	if v.Resources == nil {
+		v.Resources = make(map[string]string)
+	}
+	return &Lockfile{resources: v.Resources}, nil
+}
+
+// Write writes the lockfile as json to w.
+func (l *Lockfile) Write(w io.Writer) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	resources := l.resources
+	if resources == nil {
+		resources = map[string]string{}
+	}
+	b, err := json.MarshalIndent(lockfileJSON{1, resources}, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(append(b, '\n'))
+	return err
+}
+
+// URLs returns the urls pinned by the lockfile, in sorted order.
+func (l *Lockfile) URLs() []string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	urls := make([]string, 0, len(l.resources))
+	for url := range l.resources {
+		urls = append(urls, url)
+	}
+	sort.Strings(urls)
+	return urls
+}
+
+// verify checks the digest of document at url against
+// the lockfile, or records it in Record mode.
+func (l *Lockfile) verify(url, digest string) error {
+	if strings.HasPrefix(url, "file:") || strings.HasPrefix(url, "data:") {
+		return nil
+	}
+	if _, ok := vocabSchemas[url]; ok {
+		return nil
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.Record {
+		if l.resources == nil {
+			l.resources = make(map[string]string)
+		}
+		l.resources[url] = digest
+		return nil
+	}
+	want, ok := l.resources[url]
+	if !ok {
+		return fmt.Errorf("jsonschema: %s is not pinned in lockfile", url)
+	}
+	if digest != want {
+		return fmt.Errorf("jsonschema: %s has changed: lockfile has %s, but got %s", url, want, digest)
+	}
+	return nil
+}
@@ -0,0 +1,85 @@
+package jsonschema_test
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/santhosh-tekuri/jsonschema/v5"
+)
+
+func TestLockfile(t *testing.T) {
+	loader := &mapLoader{docs: map[string]string{
+		"https://example.com/a.json": `{"$ref": "b.json"}`,
+		"https://example.com/b.json": `{"type": "string"}`,
+		"https://example.com/c.json": `{"$ref": "data:application/json,{}"}`,
+	}}
+	compile := func(lf *jsonschema.Lockfile, url string) error {
+		c := jsonschema.NewCompiler()
+		c.Loader = loader
+		c.Lockfile = lf
+		_, err := c.Compile(url)
+		return err
+	}
+
+	// record
+	lf := jsonschema.NewLockfile()
+	if err := compile(lf, "https://example.com/a.json"); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"https://example.com/a.json", "https://example.com/b.json"}
+	if got := lf.URLs(); !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+	var buf bytes.Buffer
+	if err := lf.Write(&buf); err != nil {
+		t.Fatal(err)
+	}
+
+	// verify
+	if lf, err := jsonschema.ReadLockfile(bytes.NewReader(buf.Bytes())); err != nil {
+		t.Fatal(err)
+	} else if err := compile(lf, "https://example.com/a.json"); err != nil {
+		t.Fatal(err)
+	}
+
+	// not pinned
+	lf, err := jsonschema.ReadLockfile(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := compile(lf, "https://example.com/c.json"); err == nil || !strings.Contains(err.Error(), "not pinned") {
+		t.Fatalf("got %v, want not pinned error", err)
+	}
+
+	// changed
+	loader.docs["https://example.com/b.json"] = `{"type": "integer"}`
+	if err := compile(lf, "https://example.com/a.json"); err == nil || !strings.Contains(err.Error(), "b.json has changed") {
+		t.Fatalf("got %v, want changed error", err)
+	}
+
+	// added by AddResource
+	c := jsonschema.NewCompiler()
+	c.Lockfile = lf
+	if err := c.AddResource("https://example.com/b.json", strings.NewReader(`{"type": "integer"}`)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.Compile("https://example.com/b.json"); err == nil || !strings.Contains(err.Error(), "b.json has changed") {
+		t.Fatalf("got %v, want changed error", err)
+	}
+
+	// already compiled in shared registry
+	other := jsonschema.NewCompiler()
+	other.Loader = loader
+	if _, err := other.Compile("https://example.com/a.json"); err != nil {
+		t.Fatal(err)
+	}
+	c = jsonschema.NewCompiler()
+	c.Loader = loader
+	c.Registry = other.Registry
+	c.Lockfile = lf
+	if _, err := c.Compile("https://example.com/a.json"); err == nil || !strings.Contains(err.Error(), "b.json has changed") {
+		t.Fatalf("got %v, want changed error", err)
+	}
+}
@@ -0,0 +1,165 @@
+package jsonschema
+
+import (
+	"reflect"
+	"sync/atomic"
+)
+
+// MemoStats captures how effective memoization of subschema results is.
+// See Compiler.Memoize.
+type MemoStats struct {
+	Validations uint64 // number of Validate calls
+	Lookups     uint64 // number of subschema applications looked up in memo
+	Hits        uint64 // number of lookups, whose memoized result is reused
+}
+
+// HitRate returns the fraction of lookups that are hits.
+func (s MemoStats) HitRate() float64 {
+	if s.Lookups == 0 {
+		return 0
+	}
+	return float64(s.Hits) / float64(s.Lookups)
+}
+
+// memoStats is MemoStats with atomic counters. It is shared by
+// concurrent validations.
+type memoStats struct {
+	validations, lookups, hits uint64
+}
+
+func (s *memoStats) add(lookups, hits uint64) {
+	atomic.AddUint64(&s.validations, 1)
+	atomic.AddUint64(&s.lookups, lookups)
+	atomic.AddUint64(&s.hits, hits)
+}
+
+// MemoStats returns memoization statistics of all validations done
+// so far with s. It returns zero value, if s is not compiled with
+// Compiler.Memoize.
+func (s *Schema) MemoStats() MemoStats {
+	if s.memo == nil {
+		return MemoStats{}
+	}
+	return MemoStats{
+		Validations: atomic.LoadUint64(&s.memo.validations),
+		Lookups:     atomic.LoadUint64(&s.memo.lookups),
+		Hits:        atomic.LoadUint64(&s.memo.hits),
+	}
+}
+
+// memoKey identifies result of applying a schema on an instance node.
+//
+// Apart from $recursiveRef and $dynamicRef, the result does not depend
+// on the dynamic scope. For these, it is enough to know the outermost
+// schema with $recursiveAnchor, and the schemas with $dynamicAnchor in
+// scope, which is captured by dynFP. see validator.fingerprint.
+type memoKey struct {
+	sch     *Schema
+	node    uintptr // pointer to map or array data
+	len     int     // length of array. distinguishes arrays sharing data
+	recRoot *Schema
+	dynFP   uint64
+}
+
+// memoEntry is memoized result. Error is not memoized, because its
+// locations depend on how the schema is reached. So failed entry is
+// reused only in quiet mode.
+type memoEntry struct {
+	ok   bool
+	eval *evaluated // nil, if evaluated properties/items are not tracked
+}
+
+// fingerprint computes the parts of dynamic scope which affect the
+// resolution of $recursiveRef and $dynamicRef, for the last frame f.
+func (vd *validator) fingerprint(f *frame) {
+	n := len(vd.scope) - 1
+	f.recRoot, f.dynFP = nil, 0
+	if n > 0 {
+		p := vd.scope[n-1]
+		f.recRoot = p.recRoot
+		if !p.discard {
+			f.dynFP = p.dynFP
+		}
+	}
+	if f.recRoot == nil && f.sch.RecursiveAnchor {
+		f.recRoot = f.sch
+	}
+	if len(f.sch.dynamicAnchors) > 0 {
+		// $dynamicRef resolves to outermost schema in scope, so
+		// schema already in scope does not change the resolution
+		for i := n - 1; i >= 0 && !vd.scope[i].discard; i-- {
+			if vd.scope[i].sch == f.sch {
+				return
+			}
+		}
+		f.dynFP = mix(f.dynFP ^ uint64(reflect.ValueOf(f.sch).Pointer()))
+	}
+}
+
+// apply applies sch on instance value of frame f, reusing memoized
+// result when possible. f must be the last frame in dynamic scope.
+func (vd *validator) apply(sch *Schema, f *frame) error {
+	if vd.stats == nil {
+		return sch.validate(vd, f)
+	}
+	var key memoKey
+	switch v := f.v.(type) {
+	case map[string]interface{}:
+		if len(v) == 0 {
+			return sch.validate(vd, f)
+		}
+		key.node = reflect.ValueOf(v).Pointer()
+	case []interface{}:
+		if len(v) == 0 {
+			return sch.validate(vd, f)
+		}
+		key.node, key.len = reflect.ValueOf(v).Pointer(), len(v)
+	default:
+		// scalars are cheap to validate
+		return sch.validate(vd, f)
+	}
+	key.sch, key.recRoot, key.dynFP = sch, f.recRoot, f.dynFP
+
+	needEval := f.track
+	vd.lookups++
+	if e, ok := vd.memo[key]; ok {
+		switch {
+		case e.ok && (!needEval || e.eval != nil):
+			vd.hits++
+			if needEval {
+				f.eval.reset()
+				f.eval.merge(e.eval)
+			}
+			return nil
+		case !e.ok && vd.quiet:
+			vd.hits++
+			return vd.quietError()
+		}
+	}
+
+	err := sch.validate(vd, f)
+	e := memoEntry{ok: err == nil}
+	if e.ok && needEval {
+		e.eval = new(evaluated)
+		e.eval.merge(&f.eval)
+	}
+	vd.memo[key] = e
+	return err
+}
+
+// startMemo enables memoization for current validation. statistics
+// are reported to stats, when the validator is put back into pool.
+func (vd *validator) startMemo(stats *memoStats) {
+	if vd.memo == nil {
+		vd.memo = make(map[memoKey]memoEntry)
+	}
+	vd.stats, vd.lookups, vd.hits = stats, 0, 0
+}
+
+func (vd *validator) stopMemo() {
+	vd.stats.add(vd.lookups, vd.hits)
+	vd.stats = nil
+	for key := range vd.memo {
+		delete(vd.memo, key)
+	}
+}
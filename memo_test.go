@@ -0,0 +1,147 @@
+package jsonschema_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/santhosh-tekuri/jsonschema/v5"
+)
+
+func compileMemo(t *testing.T, memoize bool, resources map[string]string, url string) *jsonschema.Schema {
+	t.Helper()
+	c := jsonschema.NewCompiler()
+	c.Memoize = memoize
+	for url, schema := range resources {
+		if err := c.AddResource(url, strings.NewReader(schema)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	sch, err := c.Compile(url)
+	if err != nil {
+		t.Fatalf("%#v", err)
+	}
+	return sch
+}
+
+// TestMemoDynamicScope checks that memoized results are not reused
+// across different dynamic scopes, which resolve $dynamicRef and
+// $recursiveRef differently.
+func TestMemoDynamicScope(t *testing.T) {
+	tests := []struct {
+		name      string
+		resources map[string]string
+	}{
+		{
+			name: "dynamicRef",
+			resources: map[string]string{
+				"http://example.com/tree": `{
+					"$schema": "https://json-schema.org/draft/2020-12/schema",
+					"$dynamicAnchor": "node",
+					"type": "object",
+					"properties": {
+						"data": true,
+						"children": {"type": "array", "items": {"$dynamicRef": "#node"}}
+					}
+				}`,
+				"http://example.com/strict": `{
+					"$schema": "https://json-schema.org/draft/2020-12/schema",
+					"$dynamicAnchor": "node",
+					"$ref": "tree",
+					"unevaluatedProperties": false
+				}`,
+				"http://example.com/root": `{
+					"$schema": "https://json-schema.org/draft/2020-12/schema",
+					"oneOf": [{"$ref": "strict"}, {"$ref": "tree"}]
+				}`,
+			},
+		},
+		{
+			name: "recursiveRef",
+			resources: map[string]string{
+				"http://example.com/tree": `{
+					"$schema": "https://json-schema.org/draft/2019-09/schema",
+					"$recursiveAnchor": true,
+					"type": "object",
+					"properties": {
+						"data": true,
+						"children": {"type": "array", "items": {"$recursiveRef": "#"}}
+					}
+				}`,
+				"http://example.com/strict": `{
+					"$schema": "https://json-schema.org/draft/2019-09/schema",
+					"$recursiveAnchor": true,
+					"$ref": "tree",
+					"unevaluatedProperties": false
+				}`,
+				"http://example.com/root": `{
+					"$schema": "https://json-schema.org/draft/2019-09/schema",
+					"oneOf": [{"$ref": "strict"}, {"$ref": "tree"}]
+				}`,
+			},
+		},
+	}
+	instances := map[string]bool{
+		// valid against both strict and tree
+		`{"data": 1, "children": [{"data": 2, "children": [{"data": 3}]}]}`: false,
+		// valid only against tree
+		`{"data": 1, "children": [{"data": 2, "children": [{"daat": 3}]}]}`: true,
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			for _, memoize := range []bool{false, true} {
+				sch := compileMemo(t, memoize, test.resources, "http://example.com/root")
+				for instance, valid := range instances {
+					err := sch.Validate(decodeString(t, instance))
+					if got := err == nil; got != valid {
+						t.Errorf("memoize=%v %s: got valid=%v, want %v: %v", memoize, instance, got, valid, err)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestMemoStats(t *testing.T) {
+	// each node is validated against both branches of anyOf, both of
+	// which validate the children. without memoization this takes time
+	// exponential in depth of the tree.
+	resources := map[string]string{
+		"schema.json": `{
+			"$ref": "#/$defs/node",
+			"$defs": {
+				"node": {"anyOf": [{"$ref": "#/$defs/a"}, {"$ref": "#/$defs/b"}]},
+				"a": {"type": "object", "required": ["a"], "properties": {"kids": {"items": {"$ref": "#/$defs/node"}}}},
+				"b": {"type": "object", "properties": {"kids": {"items": {"$ref": "#/$defs/node"}}}}
+			}
+		}`,
+	}
+	instance := `{}`
+	for i := 0; i < 40; i++ {
+		instance = `{"b": 1, "kids": [` + instance + `]}`
+	}
+	v := decodeString(t, instance)
+
+	sch := compileMemo(t, false, resources, "schema.json")
+	if got := sch.MemoStats(); got != (jsonschema.MemoStats{}) {
+		t.Fatalf("got %#v, want zero value without memoization", got)
+	}
+
+	sch = compileMemo(t, true, resources, "schema.json")
+	if err := sch.Validate(v); err != nil {
+		t.Fatalf("%#v", err)
+	}
+	if err := sch.Validate(decodeString(t, `{"kids": [{"kids": [1]}]}`)); err == nil {
+		t.Fatal("validation must fail")
+	}
+	stats := sch.MemoStats()
+	if stats.Validations != 2 {
+		t.Errorf("got %d validations, want 2", stats.Validations)
+	}
+	if stats.Hits == 0 || stats.Hits > stats.Lookups {
+		t.Errorf("got %d hits for %d lookups", stats.Hits, stats.Lookups)
+	}
+	if stats.Lookups > 1000 {
+		t.Errorf("got %d lookups, memoization not effective", stats.Lookups)
+	}
+	t.Logf("%+v hitRate=%.2f", stats, stats.HitRate())
+}
@@ -0,0 +1,41 @@
+package jsonschema
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Migrate compiles schema at url, and returns its document converted
+// to draft to. Only Draft2020 is supported as target.
+//
+// Keywords removed or renamed by later drafts are rewritten, and json-pointer
+// fragments in $ref are updated to match. References to other documents
+// of older drafts are updated assuming that they are migrated too.
+// Keywords ignored by older drafts, like siblings of $ref before
+// draft 2019-09, are removed.
+func (c *Compiler) Migrate(url string, to *Draft) (interface{}, error) {
+	if to != Draft2020 {
+		return nil, fmt.Errorf("jsonschema: migration to %v is not supported", to)
+	}
+	sch, err := c.Compile(url)
+	if err != nil {
+		return nil, err
+	}
+	r := c.Registry.root(sch.url())
+	if r == nil || r.doc == nil {
+		return nil, errors.New("jsonschema: source document not available")
+	}
+
+	b := &bundler{c: c}
+	up := &upgrader{draft: r.draft, ref: b.ref}
+	doc := up.doc(r.doc, r.url)
+	if m, ok := doc.(map[string]interface{}); ok {
+		if _, ok := m["$schema"]; !ok {
+			m["$schema"] = Draft2020.URL()
+		}
+	}
+	if err := Draft2020.meta.Validate(doc); err != nil {
+		return nil, fmt.Errorf("jsonschema: migrated schema is not valid: %w", err)
+	}
+	return doc, nil
+}
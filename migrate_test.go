@@ -0,0 +1,99 @@
+package jsonschema_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/santhosh-tekuri/jsonschema/v5"
+)
+
+// TestMigrate migrates schemas of JSON-Schema-Test-Suite to draft 2020-12,
+// and checks that the migrated schemas give same results.
+func TestMigrate(t *testing.T) {
+	drafts := []struct {
+		dir   string
+		draft *jsonschema.Draft
+	}{
+		{"draft3", jsonschema.Draft3},
+		{"draft4", jsonschema.Draft4},
+		{"draft6", jsonschema.Draft6},
+		{"draft7", jsonschema.Draft7},
+		{"draft2019-09", jsonschema.Draft2019},
+	}
+	for _, d := range drafts {
+		files, err := filepath.Glob(filepath.Join("testdata/JSON-Schema-Test-Suite/tests", d.dir, "*.json"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, file := range files {
+			if strings.Contains(file, "refRemote") {
+				continue // needs remote server
+			}
+			t.Run(d.dir+"/"+filepath.Base(file), func(t *testing.T) {
+				b, err := ioutil.ReadFile(file)
+				if err != nil {
+					t.Fatal(err)
+				}
+				var groups []struct {
+					Description string
+					Schema      json.RawMessage
+					Tests       []struct {
+						Description string
+						Data        json.RawMessage
+						Valid       bool
+					}
+				}
+				if err := json.Unmarshal(b, &groups); err != nil {
+					t.Fatal(err)
+				}
+				for _, group := range groups {
+					c := jsonschema.NewCompiler()
+					c.Draft = d.draft
+					if err := c.AddResource("http://test.com/schema.json", bytes.NewReader(group.Schema)); err != nil {
+						t.Fatal(err)
+					}
+					doc, err := c.Migrate("http://test.com/schema.json", jsonschema.Draft2020)
+					if err != nil {
+						t.Errorf("%s: %v", group.Description, err)
+						continue
+					}
+					migrated, err := json.Marshal(doc)
+					if err != nil {
+						t.Fatal(err)
+					}
+
+					c = jsonschema.NewCompiler()
+					c.AssertFormat = d.draft != jsonschema.Draft2019
+					if err := c.AddResource("http://test.com/schema.json", bytes.NewReader(migrated)); err != nil {
+						t.Fatal(err)
+					}
+					sch, err := c.Compile("http://test.com/schema.json")
+					if err != nil {
+						t.Errorf("%s: %v\n%s", group.Description, err, migrated)
+						continue
+					}
+					for _, test := range group.Tests {
+						v := decodeString(t, string(test.Data))
+						if err := sch.Validate(v); (err == nil) != test.Valid {
+							t.Errorf("%s/%s: got valid=%v, want %v\n%s", group.Description, test.Description, err == nil, test.Valid, migrated)
+						}
+					}
+				}
+			})
+		}
+	}
+
+	t.Run("unsupported", func(t *testing.T) {
+		c := jsonschema.NewCompiler()
+		if err := c.AddResource("schema.json", strings.NewReader(`{}`)); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := c.Migrate("schema.json", jsonschema.Draft7); err == nil {
+			t.Fatal("want error")
+		}
+	})
+}
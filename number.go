@@ -0,0 +1,487 @@
+package jsonschema
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"math/big"
+	"math/bits"
+	"strconv"
+	"strings"
+)
+
+// decimal is an exact representation of number (-1)^neg × mant × 10^exp.
+//
+// It is used as fast path for numeric validations, instead of *big.Rat.
+// Numbers which cannot be represented as decimal (for example too many
+// significant digits) fallback to *big.Rat.
+//
+// decimal is always normalized: mant is not multiple of 10, and zero
+// is represented with zero mant, zero exp and neg false. So two decimals
+// are equal if and only if they are ==.
+type decimal struct {
+	neg  bool
+	mant uint64
+	exp  int32
+}
+
+// limits of decimal. numbers beyond these fallback to *big.Rat.
+//
+// mant is limited to 19 digits, so that aligning exponents of two
+// decimals of same magnitude never overflows uint64.
+const (
+	maxDecimalMant = 1e19 - 1
+	maxDecimalExp  = 1 << 20
+)
+
+func newDecimal(neg bool, mant uint64, exp int64) (decimal, bool) {
+	if mant == 0 {
+		return decimal{}, true
+	}
+	for mant%10 == 0 {
+		mant /= 10
+		exp++
+	}
+	if mant > maxDecimalMant || exp > maxDecimalExp || exp < -maxDecimalExp {
+		return decimal{}, false
+	}
+	return decimal{neg, mant, int32(exp)}, true
+}
+
+// parseDecimal parses number in json syntax. It also accepts '+' sign in
+// exponent and leading '+', so that it can parse numbers formatted by strconv.
+func parseDecimal(s string) (decimal, bool) {
+	i := 0
+	neg := false
+	if i < len(s) && (s[i] == '-' || s[i] == '+') {
+		neg = s[i] == '-'
+		i++
+	}
+	var mant uint64
+	var exp int64
+	var digits, zeros int // significant digits in mant, pending trailing zeros
+	dot := false
+	for ; i < len(s); i++ {
+		c := s[i]
+		if c == '.' {
+			if dot {
+				return decimal{}, false
+			}
+			dot = true
+			continue
+		}
+		if c < '0' || c > '9' {
+			break
+		}
+		digits++
+		if dot {
+			exp--
+		}
+		if c == '0' {
+			// defer zeros, so that trailing zeros do not overflow mant
+			zeros++
+			continue
+		}
+		for ; zeros > 0; zeros-- {
+			if mant > math.MaxUint64/10 {
+				return decimal{}, false
+			}
+			mant *= 10
+		}
+		if mant > (math.MaxUint64-uint64(c-'0'))/10 {
+			return decimal{}, false
+		}
+		mant = mant*10 + uint64(c-'0')
+	}
+	if digits == 0 {
+		return decimal{}, false
+	}
+	exp += int64(zeros)
+	if i < len(s) {
+		if s[i] != 'e' && s[i] != 'E' {
+			return decimal{}, false
+		}
+		i++
+		eneg := false
+		if i < len(s) && (s[i] == '-' || s[i] == '+') {
+			eneg = s[i] == '-'
+			i++
+		}
+		if i == len(s) {
+			return decimal{}, false
+		}
+		var e int64
+		for ; i < len(s); i++ {
+			c := s[i]
+			if c < '0' || c > '9' {
+				return decimal{}, false
+			}
+			if e > maxDecimalExp {
+				return decimal{}, false
+			}
+			e = e*10 + int64(c-'0')
+		}
+		if eneg {
+			e = -e
+		}
+		exp += e
+	}
+	return newDecimal(neg, mant, exp)
+}
+
+// toDecimal converts json number v into decimal. It returns false
+// if v cannot be represented as decimal.
+func toDecimal(v interface{}) (decimal, bool) {
+	switch v := v.(type) {
+	case json.Number:
+		return parseDecimal(string(v))
+	case float64:
+		return floatDecimal(v, 64)
+	case float32:
+		return floatDecimal(float64(v), 32)
+	case int:
+		return intDecimal(int64(v))
+	case int8:
+		return intDecimal(int64(v))
+	case int32:
+		return intDecimal(int64(v))
+	case int64:
+		return intDecimal(v)
+	case uint:
+		return newDecimal(false, uint64(v), 0)
+	case uint8:
+		return newDecimal(false, uint64(v), 0)
+	case uint32:
+		return newDecimal(false, uint64(v), 0)
+	case uint64:
+		return newDecimal(false, v, 0)
+	}
+	return decimal{}, false
+}
+
+func intDecimal(i int64) (decimal, bool) {
+	if i < 0 {
+		return newDecimal(true, uint64(-(i+1))+1, 0)
+	}
+	return newDecimal(false, uint64(i), 0)
+}
+
+// floatDecimal converts f to decimal, using shortest decimal representation
+// that rounds to f. This is consistent with fmt.Sprint(f).
+func floatDecimal(f float64, bitSize int) (decimal, bool) {
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return decimal{}, false
+	}
+	// integers below 2^53, or 2^24 for float32, are same as their
+	// shortest representation, given by fmt.Sprint
+	exact := float64(1 << 53)
+	if bitSize == 32 {
+		exact = 1 << 24
+	}
+	if f == math.Trunc(f) && math.Abs(f) < exact {
+		return intDecimal(int64(f))
+	}
+	var buf [32]byte
+	return parseDecimal(string(strconv.AppendFloat(buf[:0], f, 'e', -1, bitSize)))
+}
+
+// ratDecimal converts r to decimal, if possible.
+func ratDecimal(r *big.Rat) (decimal, bool) {
+	k, ok := decimalPlaces(r.Denom())
+	if !ok {
+		return decimal{}, false
+	}
+	// mant = |num| × 10^k / denom
+	m := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(k)), nil)
+	m.Mul(m, new(big.Int).Abs(r.Num()))
+	m.Quo(m, r.Denom())
+	if !m.IsUint64() {
+		return decimal{}, false
+	}
+	return newDecimal(r.Sign() < 0, m.Uint64(), -int64(k))
+}
+
+// decimalPlaces returns k such that 10^k is multiple of d.
+// it returns false, if d has prime factors other than 2 and 5.
+func decimalPlaces(d *big.Int) (int, bool) {
+	d = new(big.Int).Set(d)
+	var twos, fives int
+	two, five, rem := big.NewInt(2), big.NewInt(5), new(big.Int)
+	for {
+		if q, _ := new(big.Int).QuoRem(d, two, rem); rem.Sign() == 0 {
+			d, twos = q, twos+1
+			continue
+		}
+		if q, _ := new(big.Int).QuoRem(d, five, rem); rem.Sign() == 0 {
+			d, fives = q, fives+1
+			continue
+		}
+		break
+	}
+	if !d.IsInt64() || d.Int64() != 1 {
+		return 0, false
+	}
+	if twos > fives {
+		return twos, true
+	}
+	return fives, true
+}
+
+func (d decimal) sign() int {
+	switch {
+	case d.mant == 0:
+		return 0
+	case d.neg:
+		return -1
+	default:
+		return 1
+	}
+}
+
+// isInt tells whether d has no fractional part.
+func (d decimal) isInt() bool {
+	return d.exp >= 0 || d.mant == 0
+}
+
+// cmp compares d and o and returns -1, 0, +1.
+func (d decimal) cmp(o decimal) int {
+	ds, os := d.sign(), o.sign()
+	switch {
+	case ds < os:
+		return -1
+	case ds > os:
+		return 1
+	case ds == 0:
+		return 0
+	}
+	c := cmpAbs(d, o)
+	if d.neg {
+		return -c
+	}
+	return c
+}
+
+// cmpAbs compares magnitudes of non-zero decimals.
+func cmpAbs(d, o decimal) int {
+	// compare position of most significant digit
+	dm, om := int64(numDigits(d.mant))+int64(d.exp), int64(numDigits(o.mant))+int64(o.exp)
+	if dm != om {
+		if dm < om {
+			return -1
+		}
+		return 1
+	}
+	// same magnitude, so scaling to same exponent cannot overflow uint64
+	dmant, omant := d.mant, o.mant
+	if d.exp > o.exp {
+		dmant *= pow10(int(d.exp - o.exp))
+	} else if o.exp > d.exp {
+		omant *= pow10(int(o.exp - d.exp))
+	}
+	switch {
+	case dmant < omant:
+		return -1
+	case dmant > omant:
+		return 1
+	}
+	return 0
+}
+
+// isMultipleOf tells whether d/m is an integer. m must not be zero.
+func (d decimal) isMultipleOf(m decimal) bool {
+	if d.mant == 0 {
+		return true
+	}
+	if d.exp >= m.exp {
+		// check d.mant × 10^(d.exp-m.exp) % m.mant == 0
+		r := mulMod(d.mant%m.mant, powMod(10, int64(d.exp)-int64(m.exp), m.mant), m.mant)
+		return r == 0
+	}
+	// check d.mant % (m.mant × 10^(m.exp-d.exp)) == 0
+	n := int64(m.exp) - int64(d.exp)
+	if n >= 20 {
+		return false // divisor has more digits than d.mant
+	}
+	hi, q := bits.Mul64(m.mant, pow10(int(n)))
+	if hi != 0 {
+		return false // divisor is larger than d.mant
+	}
+	return d.mant%q == 0
+}
+
+func mulMod(a, b, m uint64) uint64 {
+	hi, lo := bits.Mul64(a, b)
+	return bits.Rem64(hi, lo, m)
+}
+
+// powMod returns b^n % m.
+func powMod(b uint64, n int64, m uint64) uint64 {
+	r := 1 % m
+	b %= m
+	for ; n > 0; n >>= 1 {
+		if n&1 == 1 {
+			r = mulMod(r, b, m)
+		}
+		b = mulMod(b, b, m)
+	}
+	return r
+}
+
+func numDigits(u uint64) int {
+	n := 1
+	for u >= 10 {
+		u /= 10
+		n++
+	}
+	return n
+}
+
+func pow10(n int) uint64 {
+	p := uint64(1)
+	for ; n > 0; n-- {
+		p *= 10
+	}
+	return p
+}
+
+// String returns exact decimal representation of d. Like fmt.Sprint
+// of float64, exponent notation is used for large and small exponents.
+func (d decimal) String() string {
+	return formatDecimal(d.neg, strconv.FormatUint(d.mant, 10), int(d.exp))
+}
+
+// formatDecimal formats (-1)^neg × digits × 10^exp. digits must not have
+// leading or trailing zeros, except for zero.
+func formatDecimal(neg bool, digits string, exp int) string {
+	var sb strings.Builder
+	if neg {
+		sb.WriteByte('-')
+	}
+	x := len(digits) - 1 + exp // exponent in scientific notation
+	switch {
+	case x < -4 || x >= 21:
+		sb.WriteByte(digits[0])
+		if len(digits) > 1 {
+			sb.WriteByte('.')
+			sb.WriteString(digits[1:])
+		}
+		sb.WriteByte('e')
+		if x < 0 {
+			sb.WriteByte('-')
+			x = -x
+		} else {
+			sb.WriteByte('+')
+		}
+		if x < 10 {
+			sb.WriteByte('0')
+		}
+		sb.WriteString(strconv.Itoa(x))
+	case exp >= 0:
+		sb.WriteString(digits)
+		sb.WriteString(strings.Repeat("0", exp))
+	case x >= 0:
+		sb.WriteString(digits[:x+1])
+		sb.WriteByte('.')
+		sb.WriteString(digits[x+1:])
+	default:
+		sb.WriteString("0.")
+		sb.WriteString(strings.Repeat("0", -x-1))
+		sb.WriteString(digits)
+	}
+	return sb.String()
+}
+
+// ratString returns exact decimal representation of r, if r has finite
+// decimal expansion. Otherwise it returns r as fraction.
+func ratString(r *big.Rat) string {
+	k, ok := decimalPlaces(r.Denom())
+	if !ok {
+		return r.RatString()
+	}
+	if r.Sign() == 0 {
+		return "0"
+	}
+	m := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(k)), nil)
+	m.Mul(m, new(big.Int).Abs(r.Num()))
+	m.Quo(m, r.Denom())
+	digits := m.String()
+	exp := -k
+	trimmed := strings.TrimRight(digits, "0")
+	exp += len(digits) - len(trimmed)
+	return formatDecimal(r.Sign() < 0, trimmed, exp)
+}
+
+// number is json number being validated. It converts to *big.Rat only if
+// it cannot be represented as decimal.
+type number struct {
+	v   interface{}
+	d   decimal
+	ok  bool // whether d is valid
+	rat *big.Rat
+}
+
+func newNumber(v interface{}) number {
+	d, ok := toDecimal(v)
+	return number{v: v, d: d, ok: ok}
+}
+
+func (n *number) bigRat() *big.Rat {
+	if n.rat == nil {
+		if n.ok {
+			n.rat = decimalRat(n.d)
+		} else {
+			n.rat, _ = new(big.Rat).SetString(fmt.Sprint(n.v))
+		}
+	}
+	return n.rat
+}
+
+func decimalRat(d decimal) *big.Rat {
+	r, _ := new(big.Rat).SetString(d.String())
+	return r
+}
+
+func (n *number) isInt() bool {
+	if n.ok {
+		return n.d.isInt()
+	}
+	return n.bigRat().IsInt()
+}
+
+// cmp compares n with limit r, whose decimal representation is d.
+// d is nil if r cannot be represented as decimal.
+func (n *number) cmp(r *big.Rat, d *decimal) int {
+	if n.ok && d != nil {
+		return n.d.cmp(*d)
+	}
+	return n.bigRat().Cmp(r)
+}
+
+func (n *number) isMultipleOf(r *big.Rat, d *decimal) bool {
+	if n.ok && d != nil {
+		return n.d.isMultipleOf(*d)
+	}
+	return new(big.Rat).Quo(n.bigRat(), r).IsInt()
+}
+
+// limitDecimal returns decimal representation of r, if possible.
+func limitDecimal(r *big.Rat) *decimal {
+	if r == nil {
+		return nil
+	}
+	if d, ok := ratDecimal(r); ok {
+		return &d
+	}
+	return nil
+}
+
+// numEquals tells whether the json numbers v1 and v2 are equal.
+func numEquals(v1, v2 interface{}) bool {
+	d1, ok1 := toDecimal(v1)
+	d2, ok2 := toDecimal(v2)
+	if ok1 && ok2 {
+		return d1 == d2
+	}
+	n1, n2 := number{v: v1, d: d1, ok: ok1}, number{v: v2, d: d2, ok: ok2}
+	return n1.bigRat().Cmp(n2.bigRat()) == 0
+}
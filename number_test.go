@@ -0,0 +1,182 @@
+package jsonschema
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"math/rand"
+	"strings"
+	"testing"
+)
+
+func TestParseDecimal(t *testing.T) {
+	tests := []struct {
+		str string
+		d   decimal
+		ok  bool
+	}{
+		{"0", decimal{}, true},
+		{"-0", decimal{}, true},
+		{"0.000", decimal{}, true},
+		{"1", decimal{false, 1, 0}, true},
+		{"-12.50", decimal{true, 125, -1}, true},
+		{"100", decimal{false, 1, 2}, true},
+		{"0.05", decimal{false, 5, -2}, true},
+		{"1.0e3", decimal{false, 1, 3}, true},
+		{"1.5E-3", decimal{false, 15, -4}, true},
+		{"1e+21", decimal{false, 1, 21}, true},
+		{"9999999999999999999", decimal{false, 9999999999999999999, 0}, true},
+		{"10000000000000000000000000000", decimal{false, 1, 28}, true},
+		{"12345678901234567891", decimal{}, false}, // too many significant digits
+		{"1e99999999", decimal{}, false},
+		{"", decimal{}, false},
+		{"-", decimal{}, false},
+		{"1e", decimal{}, false},
+		{"1.2.3", decimal{}, false},
+		{"abc", decimal{}, false},
+	}
+	for _, test := range tests {
+		d, ok := parseDecimal(test.str)
+		if ok != test.ok || (ok && d != test.d) {
+			t.Errorf("parseDecimal(%q): got %v %v, want %v %v", test.str, d, ok, test.d, test.ok)
+		}
+	}
+}
+
+func TestDecimalString(t *testing.T) {
+	tests := []struct {
+		str, want string
+	}{
+		{"0", "0"},
+		{"1.5", "1.5"},
+		{"-100", "-100"},
+		{"0.0001", "0.0001"},
+		{"0.00001", "1e-05"},
+		{"0.000011", "1.1e-05"},
+		{"1e20", "100000000000000000000"},
+		{"1e21", "1e+21"},
+		{"123.456", "123.456"},
+		{"18446744073709551616", "18446744073709551616"},
+		{"0.1000000000000000000000000001", "0.1000000000000000000000000001"},
+	}
+	for _, test := range tests {
+		r, _ := new(big.Rat).SetString(test.str)
+		if got := ratString(r); got != test.want {
+			t.Errorf("ratString(%s): got %s, want %s", test.str, got, test.want)
+		}
+		if d, ok := parseDecimal(test.str); ok {
+			if got := d.String(); got != test.want {
+				t.Errorf("decimal(%s).String(): got %s, want %s", test.str, got, test.want)
+			}
+		}
+	}
+	if got := ratString(big.NewRat(1, 3)); got != "1/3" {
+		t.Errorf("ratString(1/3): got %s, want 1/3", got)
+	}
+}
+
+// TestNumberFastPath checks that decimal fast path gives same
+// results as *big.Rat for random numbers.
+func TestNumberFastPath(t *testing.T) {
+	rnd := rand.New(rand.NewSource(1))
+	randNum := func() interface{} {
+		switch rnd.Intn(7) {
+		case 6:
+			return float32(rnd.Int63n(1<<30) - 1<<29)
+		case 0:
+			return rnd.Int63n(2000) - 1000
+		case 1:
+			return rnd.NormFloat64() * 1000
+		case 2:
+			return float64(rnd.Intn(200)-100) / 8
+		case 3:
+			return json.Number(fmt.Sprintf("%de%d", rnd.Intn(100000)-50000, rnd.Intn(10)-5))
+		case 4:
+			var sb strings.Builder
+			if rnd.Intn(2) == 0 {
+				sb.WriteByte('-')
+			}
+			sb.WriteString(fmt.Sprint(rnd.Intn(10)))
+			for i := rnd.Intn(25); i > 0; i-- {
+				sb.WriteString(fmt.Sprint(rnd.Intn(10)))
+			}
+			if rnd.Intn(2) == 0 {
+				sb.WriteByte('.')
+				for i := rnd.Intn(25) + 1; i > 0; i-- {
+					sb.WriteString(fmt.Sprint(rnd.Intn(10)))
+				}
+			}
+			return json.Number(sb.String())
+		default:
+			return json.Number(fmt.Sprintf("0.%0*d", rnd.Intn(6)+1, rnd.Intn(100)))
+		}
+	}
+	rat := func(v interface{}) *big.Rat {
+		r, ok := new(big.Rat).SetString(fmt.Sprint(v))
+		if !ok {
+			t.Fatalf("invalid number %v", v)
+		}
+		return r
+	}
+	for i := 0; i < 20000; i++ {
+		v1, v2 := randNum(), randNum()
+		r1, r2 := rat(v1), rat(v2)
+		if rnd.Intn(10) == 0 {
+			v2, r2 = v1, r1
+		}
+
+		n := newNumber(v1)
+		if got, want := n.isInt(), r1.IsInt(); got != want {
+			t.Fatalf("isInt(%v): got %v, want %v", v1, got, want)
+		}
+		if got, want := n.cmp(r2, limitDecimal(r2)), r1.Cmp(r2); got != want {
+			t.Fatalf("cmp(%v, %v): got %v, want %v", v1, v2, got, want)
+		}
+		if got, want := numEquals(v1, v2), r1.Cmp(r2) == 0; got != want {
+			t.Fatalf("numEquals(%v, %v): got %v, want %v", v1, v2, got, want)
+		}
+		if r2.Sign() != 0 {
+			r2 = new(big.Rat).Abs(r2)
+			if got, want := n.isMultipleOf(r2, limitDecimal(r2)), new(big.Rat).Quo(r1, r2).IsInt(); got != want {
+				t.Fatalf("isMultipleOf(%v, %v): got %v, want %v", v1, r2, got, want)
+			}
+		}
+	}
+}
+
+func TestFloat32Decimal(t *testing.T) {
+	// float32(123456792) is printed as 1.2345679e+08 by fmt.Sprint
+	n := newNumber(float32(123456792))
+	if r := big.NewRat(123456790, 1); n.cmp(r, limitDecimal(r)) != 0 {
+		t.Errorf("got %v, want 123456790", n)
+	}
+}
+
+func TestIsMultipleOf(t *testing.T) {
+	tests := []struct {
+		v, m string
+		want bool
+	}{
+		{"0", "0.01", true},
+		{"4.5", "1.5", true},
+		{"35", "1.5", false},
+		{"0.0075", "0.0001", true},
+		{"0.00751", "0.0001", false},
+		{"1e308", "0.123456789", false},
+		{"1e308", "1e-8", true},
+		{"12391239123", "1e-8", true},
+		{"1", "1e-400", true},
+		{"1e-400", "1", false},
+		{"9223372036854775807", "3", false},
+		{"9999999999999999999e10", "9", true},
+		{"1e1000", "7", false},
+		{"7e1000", "7", true},
+	}
+	for _, test := range tests {
+		r, _ := new(big.Rat).SetString(test.m)
+		n := newNumber(json.Number(test.v))
+		if got := n.isMultipleOf(r, limitDecimal(r)); got != test.want {
+			t.Errorf("isMultipleOf(%s, %s): got %v, want %v", test.v, test.m, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,130 @@
+package jsonschema
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"path"
+	"strings"
+)
+
+// Policy restricts the resources, a compilation may refer.
+// It is useful when compiling untrusted schemas. For example:
+//
+//	c.Policy = &jsonschema.Policy{
+//	    Refs: map[string]jsonschema.RefRule{
+//	        "http":  {Schemes: []string{"http", "https"}},
+//	        "https": {Schemes: []string{"https"}, Hosts: []string{"*.example.com"}},
+//	    },
+//	    MaxResources: 100,
+//	    MaxSize:      10 << 20,
+//	}
+//
+// prevents schemas loaded from http(s) to refer to local files.
+type Policy struct {
+	// Refs maps the scheme of url, a resource is loaded from, to the rule
+	// for the urls that resource may refer, including its $schema.
+	// Resources loaded from schemes not in Refs may refer any url.
+	Refs map[string]RefRule
+
+	// MaxResources is the maximum number of resources, single compilation
+	// may use, including the one being compiled. Zero means no limit.
+	MaxResources int
+
+	// MaxSize is the maximum total size in bytes of the resources, single
+	// compilation may use. Zero means no limit.
+	MaxSize int64
+}
+
+// RefRule lists the urls, a resource is allowed to refer.
+type RefRule struct {
+	// Schemes lists the schemes allowed. If empty, any scheme is allowed.
+	Schemes []string
+
+	// Hosts lists the hosts allowed, as patterns matched using path.Match,
+	// like "*.example.com". It is not applicable to urls without host,
+	// like file urls. If empty, any host is allowed.
+	Hosts []string
+}
+
+// allows tells whether the rule allows to refer url u.
+func (rule RefRule) allows(u *url.URL) bool {
+	if len(rule.Schemes) > 0 && !contains(rule.Schemes, u.Scheme) {
+		return false
+	}
+	if len(rule.Hosts) > 0 && u.Host != "" {
+		for _, pattern := range rule.Hosts {
+			if ok, _ := path.Match(pattern, u.Hostname()); ok {
+				return true
+			}
+		}
+		return false
+	}
+	return true
+}
+
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
+
+// check returns error, if resource loaded from url from is not
+// allowed to refer url to. chain is the resolution chain, ending with to.
+func (p *Policy) check(chain []string, from, to string) error {
+	fu, err := url.Parse(from)
+	if err != nil {
+		return err
+	}
+	rule, ok := p.Refs[fu.Scheme]
+	if !ok {
+		return nil
+	}
+	tu, err := url.Parse(to)
+	if err != nil {
+		return err
+	}
+	if !rule.allows(tu) {
+		return &PolicyError{chain, fmt.Sprintf("%s resource cannot refer %s", fu.Scheme, to)}
+	}
+	return nil
+}
+
+// PolicyError is the error returned by Compile, when a resource
+// is not allowed by Compiler.Policy.
+type PolicyError struct {
+	// Chain is the resolution chain of urls, starting with the url
+	// being compiled and ending with the url blocked.
+	Chain []string
+
+	// Reason tells why the url is blocked.
+	Reason string
+}
+
+func (e *PolicyError) Error() string {
+	return fmt.Sprintf("jsonschema: %s, resolving %s", e.Reason, strings.Join(e.Chain, " -> "))
+}
+
+// sizeReader counts the bytes read, and fails once they exceed max.
+type sizeReader struct {
+	r   io.Reader
+	n   int64
+	max int64 // zero means no limit
+}
+
+func (r *sizeReader) Read(p []byte) (int, error) {
+	n, err := r.r.Read(p)
+	r.n += int64(n)
+	if r.max > 0 && r.n > r.max {
+		return n, errTooLarge
+	}
+	return n, err
+}
+
+// errTooLarge is returned by load when the document
+// exceeds Policy.MaxSize. It is converted to PolicyError.
+var errTooLarge = errors.New("jsonschema: resource too large")
@@ -0,0 +1,99 @@
+package jsonschema_test
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/santhosh-tekuri/jsonschema/v5"
+)
+
+func TestPolicy(t *testing.T) {
+	loader := &mapLoader{docs: map[string]string{
+		"https://example.com/a.json":     `{"$ref": "b.json"}`,
+		"https://example.com/b.json":     `{"$ref": "file:///etc/passwd"}`,
+		"https://example.com/c.json":     `{"$ref": "https://internal.local/x.json"}`,
+		"https://example.com/d.json":     `{"$ref": "https://cdn.example.com/x.json"}`,
+		"https://example.com/e.json":     `{"$schema": "file:///meta.json"}`,
+		"https://example.com/big.json":   `{"description": "` + strings.Repeat("x", 100) + `"}`,
+		"https://cdn.example.com/x.json": `{"$ref": "y.json"}`,
+		"https://cdn.example.com/y.json": `{}`,
+		"file:///etc/passwd":             `{}`,
+		"file:///meta.json":              `{"$schema": "https://json-schema.org/draft/2020-12/schema"}`,
+		"file:///local.json":             `{"$ref": "file:///etc/passwd"}`,
+	}}
+	policy := &jsonschema.Policy{
+		Refs: map[string]jsonschema.RefRule{
+			"https": {Schemes: []string{"https"}, Hosts: []string{"example.com", "*.example.com"}},
+		},
+	}
+	tests := []struct {
+		url          string
+		maxResources int
+		maxSize      int64
+		chain        []string // nil means no error
+	}{
+		{url: "https://example.com/a.json", chain: []string{"https://example.com/a.json", "https://example.com/b.json", "file:///etc/passwd"}},
+		{url: "https://example.com/c.json", chain: []string{"https://example.com/c.json", "https://internal.local/x.json"}},
+		{url: "https://example.com/d.json"},
+		{url: "https://example.com/e.json", chain: []string{"https://example.com/e.json", "file:///meta.json"}},
+		{url: "file:///local.json"},
+		{url: "https://example.com/d.json", maxResources: 3},
+		{url: "https://example.com/d.json", maxResources: 2, chain: []string{"https://example.com/d.json", "https://cdn.example.com/x.json", "https://cdn.example.com/y.json"}},
+		{url: "https://example.com/big.json", maxSize: 200},
+		{url: "https://example.com/big.json", maxSize: 100, chain: []string{"https://example.com/big.json"}},
+	}
+	for _, test := range tests {
+		c := jsonschema.NewCompiler()
+		c.Loader = loader
+		p := *policy
+		p.MaxResources, p.MaxSize = test.maxResources, test.maxSize
+		c.Policy = &p
+		_, err := c.Compile(test.url)
+		if test.chain == nil {
+			if err != nil {
+				t.Errorf("%s: %v", test.url, err)
+			}
+			continue
+		}
+		var pe *jsonschema.PolicyError
+		if !errors.As(err, &pe) {
+			t.Errorf("%s: got %v, want PolicyError", test.url, err)
+			continue
+		}
+		if !reflect.DeepEqual(pe.Chain, test.chain) {
+			t.Errorf("%s: got chain %v, want %v", test.url, pe.Chain, test.chain)
+		}
+	}
+}
+
+func TestPolicySharedRegistry(t *testing.T) {
+	loader := &mapLoader{docs: map[string]string{
+		"https://example.com/a.json":   `{"$ref": "big.json"}`,
+		"https://example.com/big.json": `{"description": "` + strings.Repeat("x", 100) + `"}`,
+	}}
+	reg := jsonschema.NewRegistry()
+	if err := reg.AddResource("https://example.com/added.json", strings.NewReader(`{"description": "`+strings.Repeat("x", 100)+`"}`)); err != nil {
+		t.Fatal(err)
+	}
+	compile := func(url string, policy *jsonschema.Policy) error {
+		c := jsonschema.NewCompiler()
+		c.Loader, c.Registry, c.Policy = loader, reg, policy
+		_, err := c.Compile(url)
+		return err
+	}
+	for _, url := range []string{"https://example.com/a.json", "https://example.com/added.json"} {
+		// first compilation without policy, loads and compiles into reg
+		if err := compile(url, nil); err != nil {
+			t.Fatal(err)
+		}
+		var pe *jsonschema.PolicyError
+		if err := compile(url, &jsonschema.Policy{MaxSize: 100}); !errors.As(err, &pe) {
+			t.Errorf("%s: got %v, want PolicyError", url, err)
+		}
+		if err := compile(url, &jsonschema.Policy{MaxSize: 200}); err != nil {
+			t.Errorf("%s: %v", url, err)
+		}
+	}
+}
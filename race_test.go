@@ -0,0 +1,9 @@
+//go:build race
+// +build race
+
+package jsonschema_test
+
+// raceEnabled tells whether tests are run with race detector.
+// sync.Pool drops items randomly with race detector, so tests
+// asserting allocation counts must be skipped.
+const raceEnabled = true
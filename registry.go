@@ -0,0 +1,255 @@
+package jsonschema
+
+import (
+	"io"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Registry holds the schema resources, added or loaded by compilers,
+// along with their compiled schemas. Resources are keyed by the url
+// with which they are added or loaded, and indexed by their canonical
+// uri, which is the $id if present.
+//
+// A Registry can be shared by compilers, by setting Compiler.Registry.
+// A resource is compiled only once, so compilers with different settings,
+// like Draft, Formats or extensions, cannot use the resources compiled
+// by each other. Such Compile fails, rather than using schemas compiled
+// with other settings.
+//
+// Registry is safe for concurrent use.
+type Registry struct {
+	// MaxVersions limits the previous versions retained for each url,
+	// which are reported by Versions. Oldest versions are dropped first.
+	// NewRegistry sets it to 10.
+	MaxVersions int
+
+	mu        sync.Mutex
+	resources map[string]*resource   // latest version, key is url
+	versions  map[string][]*resource // previous versions, key is url
+	ids       map[string]*resource   // root resource, key is canonical uri
+	loads     map[string]*load       // pending loads, key is url
+	seq       int                    // last resource.seq
+}
+
+// NewRegistry returns an empty Registry.
+func NewRegistry() *Registry {
+	return &Registry{
+		MaxVersions: 10,
+		resources:   make(map[string]*resource),
+		versions:    make(map[string][]*resource),
+		ids:         make(map[string]*resource),
+		loads:       make(map[string]*load),
+	}
+}
+
+// AddResource adds in-memory resource to the registry. If a resource
+// already exists at url, the new resource becomes its latest version.
+//
+// Note that url must not have fragment
+func (reg *Registry) AddResource(url string, r io.Reader) error {
+	res, err := newResource(url, r)
+	if err != nil {
+		return err
+	}
+	reg.mu.Lock()
+	reg.add(res)
+	reg.mu.Unlock()
+	return nil
+}
+
+// addRef records that resource from refers resource to.
+func (reg *Registry) addRef(from, to *resource) {
+	if from == to {
+		return
+	}
+	reg.mu.Lock()
+	defer reg.mu.Unlock()
+	for _, r := range from.refs {
+		if r == to {
+			return
+		}
+	}
+	from.refs = append(from.refs, to)
+}
+
+// refs returns the resources referred by r.
+func (reg *Registry) refs(r *resource) []*resource {
+	reg.mu.Lock()
+	defer reg.mu.Unlock()
+	return r.refs
+}
+
+// add adds res to reg.resources. reg.mu must be held.
+func (reg *Registry) add(res *resource) {
+	reg.seq++
+	res.seq = reg.seq
+	if old, ok := reg.resources[res.key]; ok {
+		res.version = old.version + 1
+		versions := append(reg.versions[res.key], old)
+		if n := len(versions) - reg.MaxVersions; n > 0 {
+			if n > len(versions) {
+				n = len(versions)
+			}
+			versions = append(versions[:0:0], versions[n:]...)
+		}
+		reg.versions[res.key] = versions
+		reg.unindex(old)
+	} else {
+		res.version = 1
+	}
+	reg.resources[res.key] = res
+}
+
+// index indexes r and its subresources by their canonical uri.
+// called once r is initialized.
+func (reg *Registry) index(r *resource) {
+	reg.mu.Lock()
+	defer reg.mu.Unlock()
+	if reg.resources[r.key] != r {
+		// evicted or replaced
+		return
+	}
+	r.id = r.url
+	ids := []string{r.url}
+	for _, sr := range r.subresources {
+		if sr.url != "" {
+			ids = append(ids, sr.url)
+		}
+	}
+	for _, id := range ids {
+		if _, ok := reg.ids[id]; !ok {
+			reg.ids[id] = r
+		}
+	}
+}
+
+// unindex removes index entries of r. reg.mu must be held.
+func (reg *Registry) unindex(r *resource) {
+	for id, res := range reg.ids {
+		if res == r {
+			delete(reg.ids, id)
+		}
+	}
+}
+
+// has tells whether resource exists at url.
+func (reg *Registry) has(url string) bool {
+	reg.mu.Lock()
+	defer reg.mu.Unlock()
+	_, ok := reg.resources[url]
+	return ok
+}
+
+// root returns the root resource containing the resource
+// with given canonical uri.
+func (reg *Registry) root(id string) *resource {
+	reg.mu.Lock()
+	defer reg.mu.Unlock()
+	return reg.ids[id]
+}
+
+// RegistryEntry describes a resource in Registry.
+type RegistryEntry struct {
+	URL     string  // url with which resource is added or loaded.
+	ID      string  // canonical uri. empty, if resource is not yet used by compiler.
+	Version int     // starts with 1, and incremented each time resource is added at URL.
+	Schema  *Schema // compiled root schema. nil, if not yet compiled.
+}
+
+func (reg *Registry) entry(r *resource) RegistryEntry {
+	reg.mu.Lock()
+	e := RegistryEntry{URL: r.key, ID: r.id, Version: r.version}
+	reg.mu.Unlock()
+	r.mu.Lock()
+	e.Schema = r.schema
+	r.mu.Unlock()
+	return e
+}
+
+// Entries returns latest versions of all resources, sorted by URL.
+func (reg *Registry) Entries() []RegistryEntry {
+	reg.mu.Lock()
+	rr := make([]*resource, 0, len(reg.resources))
+	for _, r := range reg.resources {
+		rr = append(rr, r)
+	}
+	reg.mu.Unlock()
+
+	sort.Slice(rr, func(i, j int) bool {
+		return rr[i].key < rr[j].key
+	})
+	entries := make([]RegistryEntry, len(rr))
+	for i, r := range rr {
+		entries[i] = reg.entry(r)
+	}
+	return entries
+}
+
+// Versions returns all versions of resource at url, oldest first.
+func (reg *Registry) Versions(url string) []RegistryEntry {
+	if u, err := toAbs(url); err == nil {
+		url = u
+	}
+	reg.mu.Lock()
+	rr := append([]*resource(nil), reg.versions[url]...)
+	if r, ok := reg.resources[url]; ok {
+		rr = append(rr, r)
+	}
+	reg.mu.Unlock()
+
+	entries := make([]RegistryEntry, len(rr))
+	for i, r := range rr {
+		entries[i] = reg.entry(r)
+	}
+	return entries
+}
+
+// Lookup returns the compiled schema identified by uri. The uri is
+// canonical uri of a resource, i.e its $id, optionally followed by
+// $anchor or json-pointer fragment. It returns false if there is no
+// such schema, or the schema is not yet compiled.
+func (reg *Registry) Lookup(uri string) (*Schema, bool) {
+	u, f := split(uri)
+	r := reg.root(u)
+	if r == nil {
+		return nil, false
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	sr := r.findResource(u)
+	if sr == nil {
+		return nil, false
+	}
+	switch {
+	case f == "#":
+	case strings.HasPrefix(f, "#/"):
+		sr = r.subresources[sr.floc+f[1:]]
+	default:
+		sr = r.findAnchor(sr, f[1:])
+	}
+	if sr == nil || sr.schema == nil {
+		return nil, false
+	}
+	return sr.schema, true
+}
+
+// Evict removes the resource at url, along with its previous versions.
+// Schemas already compiled remain valid, but later compilations load
+// the resource again. It returns false if there is no resource at url.
+func (reg *Registry) Evict(url string) bool {
+	if u, err := toAbs(url); err == nil {
+		url = u
+	}
+	reg.mu.Lock()
+	defer reg.mu.Unlock()
+	r, ok := reg.resources[url]
+	if !ok {
+		return false
+	}
+	reg.unindex(r)
+	delete(reg.resources, url)
+	delete(reg.versions, url)
+	return true
+}
@@ -0,0 +1,177 @@
+package jsonschema_test
+
+import (
+	"errors"
+	"io"
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	"github.com/santhosh-tekuri/jsonschema/v5"
+)
+
+func TestRegistry(t *testing.T) {
+	const root = `{
+		"$id": "http://example.com/root.json",
+		"properties": {
+			"item": {"$ref": "item.json"},
+			"name": {"$ref": "#name"}
+		},
+		"$defs": {
+			"item": {"$id": "item.json", "type": "integer"},
+			"name": {"$anchor": "name", "type": "string"}
+		}
+	}`
+	var loads int
+	c := jsonschema.NewCompiler()
+	c.LoadURL = func(s string) (io.ReadCloser, error) {
+		if s == "map:///root.json" {
+			loads++
+			return ioutil.NopCloser(strings.NewReader(root)), nil
+		}
+		return nil, errors.New("unsupported schema")
+	}
+	sch, err := c.Compile("map:///root.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("entries", func(t *testing.T) {
+		entries := c.Registry.Entries()
+		if len(entries) != 1 {
+			t.Fatalf("got %d entries, want 1", len(entries))
+		}
+		want := jsonschema.RegistryEntry{URL: "map:///root.json", ID: "http://example.com/root.json", Version: 1, Schema: sch}
+		if entries[0] != want {
+			t.Fatalf("got %#v, want %#v", entries[0], want)
+		}
+	})
+
+	t.Run("lookup", func(t *testing.T) {
+		tests := []struct {
+			uri  string
+			want *jsonschema.Schema
+		}{
+			{"http://example.com/root.json", sch},
+			{"http://example.com/root.json#/properties/item", sch.Properties["item"]},
+			{"http://example.com/root.json#name", sch.Properties["name"].Ref},
+			{"http://example.com/item.json", sch.Properties["item"].Ref},
+			{"map:///root.json", nil},
+			{"http://example.com/root.json#/$defs/missing", nil},
+		}
+		for _, test := range tests {
+			got, ok := c.Registry.Lookup(test.uri)
+			if got != test.want || ok != (test.want != nil) {
+				t.Errorf("%s: got %v %v, want %v", test.uri, got, ok, test.want)
+			}
+		}
+	})
+
+	t.Run("shared", func(t *testing.T) {
+		// canonical uri of embedded resource is resolved without loading
+		other := jsonschema.NewCompiler()
+		other.Registry = c.Registry
+		if err := other.AddResource("test.json", strings.NewReader(`{"$ref": "http://example.com/item.json"}`)); err != nil {
+			t.Fatal(err)
+		}
+		s, err := other.Compile("test.json")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if s.Ref != sch.Properties["item"].Ref {
+			t.Fatal("compiled schema is not shared")
+		}
+	})
+
+	t.Run("settings", func(t *testing.T) {
+		other := jsonschema.NewCompiler()
+		other.Registry = c.Registry
+		other.AssertFormat = true
+		if err := other.AddResource("settings.json", strings.NewReader(`{"$ref": "http://example.com/item.json"}`)); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := other.Compile("settings.json"); err == nil || !strings.Contains(err.Error(), "different settings") {
+			t.Fatalf("got %v, want different settings error", err)
+		}
+		if _, err := other.Compile("map:///root.json"); err == nil {
+			t.Fatal("error expected")
+		}
+	})
+
+	t.Run("versions", func(t *testing.T) {
+		if err := c.AddResource("map:///root.json", strings.NewReader(`{"type": "string"}`)); err != nil {
+			t.Fatal(err)
+		}
+		s, err := c.Compile("map:///root.json")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := s.Validate("foo"); err != nil {
+			t.Fatal(err)
+		}
+		versions := c.Registry.Versions("map:///root.json")
+		if len(versions) != 2 {
+			t.Fatalf("got %d versions, want 2", len(versions))
+		}
+		if versions[0].Version != 1 || versions[0].Schema != sch {
+			t.Errorf("got %#v for version 1", versions[0])
+		}
+		if versions[1].Version != 2 || versions[1].Schema != s {
+			t.Errorf("got %#v for version 2", versions[1])
+		}
+		if _, ok := c.Registry.Lookup("http://example.com/root.json"); ok {
+			t.Error("previous version must not be indexed")
+		}
+
+		reg := jsonschema.NewRegistry()
+		reg.MaxVersions = 2
+		for i := 0; i < 5; i++ {
+			if err := reg.AddResource("map:///v.json", strings.NewReader(`{}`)); err != nil {
+				t.Fatal(err)
+			}
+		}
+		versions = reg.Versions("map:///v.json")
+		if len(versions) != 3 || versions[0].Version != 3 || versions[2].Version != 5 {
+			t.Errorf("got %#v, want versions 3 to 5", versions)
+		}
+	})
+
+	t.Run("evict", func(t *testing.T) {
+		if !c.Registry.Evict("map:///root.json") {
+			t.Fatal("evict must return true")
+		}
+		if c.Registry.Evict("map:///root.json") {
+			t.Fatal("evict must return false")
+		}
+		if len(c.Registry.Versions("map:///root.json")) != 0 {
+			t.Fatal("versions must be evicted")
+		}
+		s, err := c.Compile("map:///root.json")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if s == sch || loads != 2 {
+			t.Fatalf("evicted resource must be loaded again: loads=%d", loads)
+		}
+	})
+
+	t.Run("urlBeforeID", func(t *testing.T) {
+		c := jsonschema.NewCompiler()
+		if err := c.AddResource("map:///a.json", strings.NewReader(`{"type": "string"}`)); err != nil {
+			t.Fatal(err)
+		}
+		if err := c.AddResource("map:///b.json", strings.NewReader(`{"$id": "map:///a.json", "type": "integer"}`)); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := c.Compile("map:///b.json"); err != nil {
+			t.Fatal(err)
+		}
+		s, err := c.Compile("map:///a.json")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := s.Validate("foo"); err != nil {
+			t.Fatalf("resource at url must not be hidden by $id of other resource: %v", err)
+		}
+	})
+}
@@ -1,6 +1,8 @@
 package jsonschema
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -9,6 +11,7 @@ import (
 	"runtime"
 	"strconv"
 	"strings"
+	"sync"
 )
 
 type resource struct {
@@ -18,6 +21,26 @@ type resource struct {
 	draft        *Draft
 	subresources map[string]*resource // key is floc. only applicable for root resource
 	schema       *Schema
+
+	// only applicable for root resource
+	key         string      // url with which resource is added to registry
+	id          string      // canonical uri, guarded by Registry.mu
+	version     int         // version of resource at key
+	seq         int         // order in which resource is added to registry
+	size        int64       // size of document in bytes
+	digest      string      // sha256 digest of document, verified by Lockfile
+	refs        []*resource // root resources referred while compiling, guarded by Registry.mu
+	mu          sync.Mutex  // held by compilation, compiling into this resource
+	initMu      sync.Mutex  // guards initialized
+	initialized bool        // whether draft, subresources are initialized
+	settings    string      // Compiler.settings of compiler which initialized
+}
+
+// load represents pending load of resource.
+type load struct {
+	done chan struct{} // closed when load is complete
+	res  *resource
+	err  error
 }
 
 func (r *resource) String() string {
@@ -28,7 +51,9 @@ func newResource(url string, r io.Reader) (*resource, error) {
 	if strings.IndexByte(url, '#') != -1 {
 		panic(fmt.Sprintf("BUG: newResource(%q)", url))
 	}
-	doc, err := unmarshal(r)
+	h := sha256.New()
+	sr := &sizeReader{r: io.TeeReader(r, h)}
+	doc, err := unmarshal(sr)
 	if err != nil {
 		return nil, fmt.Errorf("jsonschema: invalid json %s: %v", url, err)
 	}
@@ -37,9 +62,12 @@ func newResource(url string, r io.Reader) (*resource, error) {
 		return nil, err
 	}
 	return &resource{
-		url:  url,
-		floc: "#",
-		doc:  doc,
+		url:    url,
+		floc:   "#",
+		doc:    doc,
+		key:    url,
+		size:   sr.n,
+		digest: "sha256:" + hex.EncodeToString(h.Sum(nil)),
 	}, nil
 }
 
@@ -102,25 +130,7 @@ func (r *resource) resolveFragment(c *Compiler, sr *resource, f string) (*resour
 
 	// resolve by anchor
 	if !strings.HasPrefix(f, "#/") {
-		// check in given resource
-		for _, anchor := range r.draft.anchors(sr.doc) {
-			if anchor == f[1:] {
-				return sr, nil
-			}
-		}
-
-		// check in subresources that has same base url
-		prefix := sr.floc + "/"
-		for _, res := range r.subresources {
-			if strings.HasPrefix(res.floc, prefix) && r.baseURL(res.floc) == sr.url {
-				for _, anchor := range r.draft.anchors(res.doc) {
-					if anchor == f[1:] {
-						return res, nil
-					}
-				}
-			}
-		}
-		return nil, nil
+		return r.findAnchor(sr, f[1:]), nil
 	}
 
 	// resolve by ptr
@@ -170,6 +180,29 @@ func (r *resource) resolveFragment(c *Compiler, sr *resource, f string) (*resour
 	return res, nil
 }
 
+// findAnchor finds the resource with given anchor, with sr as base
+func (r *resource) findAnchor(sr *resource, anchor string) *resource {
+	// check in given resource
+	for _, a := range r.draft.anchors(sr.doc) {
+		if a == anchor {
+			return sr
+		}
+	}
+
+	// check in subresources that has same base url
+	prefix := sr.floc + "/"
+	for _, res := range r.subresources {
+		if strings.HasPrefix(res.floc, prefix) && r.baseURL(res.floc) == sr.url {
+			for _, a := range r.draft.anchors(res.doc) {
+				if a == anchor {
+					return res
+				}
+			}
+		}
+	}
+	return nil
+}
+
 func (r *resource) baseURL(floc string) string {
 	for {
 		if sr, ok := r.subresources[floc]; ok {
@@ -237,6 +270,15 @@ func resolveURL(base, ref string) (string, error) {
 		return base + ref, nil
 	}
 
+	if strings.HasPrefix(base, "data:") {
+		// data url is opaque, only fragment can be resolved against it
+		if !strings.HasPrefix(ref, "#") {
+			return "", fmt.Errorf("jsonschema: cannot resolve %q against data url", ref)
+		}
+		base, _ = split(base)
+		return base + ref, nil
+	}
+
 	baseURL, err := url.Parse(base)
 	if err != nil {
 		return "", err
@@ -34,11 +34,15 @@ type Schema struct {
 	Types            []string      // allowed types.
 	Constant         []interface{} // first element in slice is constant value. note: slice is used to capture nil constant.
 	Enum             []interface{} // allowed values.
+	enumIndex        valueIndex    // hash index of Enum. nil if Enum is small.
 	enumError        string        // error message for enum fail. captured here to avoid constructing error message every time.
-	Not              *Schema
-	AllOf            []*Schema
-	AnyOf            []*Schema
+	Not              *Schema       // draft3 "disallow" is loaded here.
+	AllOf            []*Schema     // draft3 "extends" is loaded here.
+	AnyOf            []*Schema     // draft3 "type" with schemas is loaded here.
 	OneOf            []*Schema
+	extendsSchema    bool  // draft3 "extends" is schema, rather than array of schemas.
+	typeIndex        []int // index in draft3 "type" or "disallow" of each schema in AnyOf. -1 for schema with type names.
+	disallow         bool  // schema is draft3 "disallow" of parent.
 	If               *Schema
 	Then             *Schema // nil, when If is nil.
 	Else             *Schema // nil, when If is nil.
@@ -83,10 +87,15 @@ type Schema struct {
 
 	// number validators
 	Minimum          *big.Rat
+	minimum          *decimal // nil if Minimum cannot be represented as decimal
 	ExclusiveMinimum *big.Rat
+	exclusiveMinimum *decimal
 	Maximum          *big.Rat
+	maximum          *decimal
 	ExclusiveMaximum *big.Rat
+	exclusiveMaximum *decimal
 	MultipleOf       *big.Rat
+	multipleOf       *decimal
 
 	// annotations. captured only when Compiler.ExtractAnnotations is true.
 	Title       string
@@ -100,12 +109,61 @@ type Schema struct {
 
 	// user defined extensions
 	Extensions map[string]ExtSchema
+	extDoc     map[string]interface{} // schema doc, used to recompile extensions from snapshot
+	extSchemas map[string]*Schema     // schemas compiled by extensions. key is schPath
+	extRefs    map[string]*Schema     // schemas referenced by extensions. key is ref
+
+	memo *memoStats  // non-nil, if compiled with Compiler.Memoize
+	doc  interface{} // source document. nil, if loaded from snapshot
 }
 
 func (s *Schema) String() string {
 	return s.Location
 }
 
+// draft3Keywords maps keywords to the draft3 keywords, which are loaded
+// into their fields.
+var draft3Keywords = map[string]string{
+	"not":        "disallow",
+	"allOf":      "extends",
+	"anyOf":      "type",
+	"multipleOf": "divisibleBy",
+}
+
+// keyword returns the keyword in s, which is loaded into field of kw.
+func (s *Schema) keyword(kw string) string {
+	if s.Draft != nil && s.Draft.version == 3 {
+		if d3, ok := draft3Keywords[kw]; ok {
+			return d3
+		}
+	}
+	return kw
+}
+
+// subschemaPath returns the keyword and token of i-th schema in
+// AllOf, AnyOf or OneOf field named kw. Keyword is empty, if the schema
+// is at keyword location of s, like draft3 "type" names.
+func (s *Schema) subschemaPath(kw string, i int) (string, token) {
+	if s.Draft != nil && s.Draft.version == 3 {
+		switch kw {
+		case "allOf":
+			if s.extendsSchema {
+				return "extends", token{}
+			}
+		case "anyOf":
+			switch {
+			case s.typeIndex[i] == -1:
+				return "", token{}
+			case s.disallow:
+				return strconv.Itoa(s.typeIndex[i]), token{}
+			default:
+				return "type", indexToken(s.typeIndex[i])
+			}
+		}
+	}
+	return s.keyword(kw), indexToken(i)
+}
+
 func newSchema(url, floc string, draft *Draft, doc interface{}) *Schema {
 	// fill with default values
 	s := &Schema{
@@ -119,6 +177,7 @@ func newSchema(url, floc string, draft *Draft, doc interface{}) *Schema {
 		MaxContains:   -1,
 		MinLength:     -1,
 		MaxLength:     -1,
+		doc:           doc,
 	}
 
 	if doc, ok := doc.(map[string]interface{}); ok {
@@ -167,6 +226,10 @@ func (s *Schema) Validate(v interface{}) (err error) {
 }
 
 func (s *Schema) validateValue(v interface{}, vloc string) (err error) {
+	vd := getValidator(vloc)
+	if s.memo != nil {
+		vd.startMemo(s.memo)
+	}
 	defer func() {
 		if r := recover(); r != nil {
 			switch r := r.(type) {
@@ -177,82 +240,45 @@ func (s *Schema) validateValue(v interface{}, vloc string) (err error) {
 			}
 		}
 	}()
-	if _, err := s.validate(nil, 0, "", v, vloc); err != nil {
+	f := vd.push(s, "", token{}, v, token{}, false)
+	if err := s.validate(vd, f); err != nil {
 		ve := ValidationError{
 			KeywordLocation:         "",
 			AbsoluteKeywordLocation: s.Location,
 			InstanceLocation:        vloc,
 			Message:                 fmt.Sprintf("doesn't validate with %s", s.Location),
 		}
+		putValidator(vd)
 		return ve.causes(err)
 	}
+	putValidator(vd)
 	return nil
 }
 
-// validate validates given value v with this schema.
-func (s *Schema) validate(scope []schemaRef, vscope int, spath string, v interface{}, vloc string) (result validationResult, err error) {
-	validationError := func(keywordPath string, format string, a ...interface{}) *ValidationError {
-		return &ValidationError{
-			KeywordLocation:         keywordLocation(scope, keywordPath),
-			AbsoluteKeywordLocation: joinPtr(s.Location, keywordPath),
-			InstanceLocation:        vloc,
-			Message:                 fmt.Sprintf(format, a...),
-		}
-	}
-
-	sref := schemaRef{spath, s, false}
-	if err := checkLoop(scope[len(scope)-vscope:], sref); err != nil {
-		panic(err)
-	}
-	scope = append(scope, sref)
-	vscope++
+// validate validates instance value of frame f with this schema.
+// f must be the last frame in dynamic scope of vd.
+func (s *Schema) validate(vd *validator, f *frame) error {
+	v := f.v
 
-	// populate result
-	switch v := v.(type) {
+	// track evaluated properties/items only if some schema in
+	// dynamic scope of this instance has unevaluatedXXX keyword
+	switch v.(type) {
 	case map[string]interface{}:
-		result.unevalProps = make(map[string]struct{})
-		for pname := range v {
-			result.unevalProps[pname] = struct{}{}
-		}
+		f.track = f.track || s.UnevaluatedProperties != nil
 	case []interface{}:
-		result.unevalItems = make(map[int]struct{})
-		for i := range v {
-			result.unevalItems[i] = struct{}{}
-		}
+		f.track = f.track || s.UnevaluatedItems != nil
+	default:
+		f.track = false
 	}
-
-	validate := func(sch *Schema, schPath string, v interface{}, vpath string) error {
-		vloc := vloc
-		if vpath != "" {
-			vloc += "/" + vpath
-		}
-		_, err := sch.validate(scope, 0, schPath, v, vloc)
-		return err
-	}
-
-	validateInplace := func(sch *Schema, schPath string) error {
-		vr, err := sch.validate(scope, vscope, schPath, v, vloc)
-		if err == nil {
-			// update result
-			for pname := range result.unevalProps {
-				if _, ok := vr.unevalProps[pname]; !ok {
-					delete(result.unevalProps, pname)
-				}
-			}
-			for i := range result.unevalItems {
-				if _, ok := vr.unevalItems[i]; !ok {
-					delete(result.unevalItems, i)
-				}
-			}
-		}
-		return err
+	if f.track {
+		f.eval.reset()
 	}
 
 	if s.Always != nil {
 		if !*s.Always {
-			return result, validationError("", "not allowed")
+			return vd.error("", "not allowed")
 		}
-		return result, nil
+		return nil
 	}
 
 	if len(s.Types) > 0 {
@@ -263,41 +289,44 @@ func (s *Schema) validate(scope []schemaRef, vscope int, spath string, v interfa
 				matched = true
 				break
 			} else if t == "integer" && vType == "number" {
-				num, _ := new(big.Rat).SetString(fmt.Sprint(v))
-				if num.IsInt() {
+				if num := newNumber(v); num.isInt() {
 					matched = true
 					break
 				}
 			}
 		}
 		if !matched {
-			return result, validationError("type", "expected %s, but got %s", strings.Join(s.Types, " or "), vType)
+			return vd.error("type", "expected %s, but got %s", strings.Join(s.Types, " or "), vType)
 		}
 	}
 
-	var errors []error
+	errors := f.errs[:0]
 
 	if len(s.Constant) > 0 {
 		if !equals(v, s.Constant[0]) {
 			switch jsonType(s.Constant[0]) {
 			case "object", "array":
-				errors = append(errors, validationError("const", "const failed"))
+				errors = append(errors, vd.error("const", "const failed"))
 			default:
-				errors = append(errors, validationError("const", "value must be %#v", s.Constant[0]))
+				errors = append(errors, vd.error("const", "value must be %#v", s.Constant[0]))
 			}
 		}
 	}
 
 	if len(s.Enum) > 0 {
 		matched := false
-		for _, item := range s.Enum {
-			if equals(v, item) {
-				matched = true
-				break
+		if s.enumIndex != nil {
+			matched = s.enumIndex.contains(s.Enum, v)
+		} else {
+			for _, item := range s.Enum {
+				if equals(v, item) {
+					matched = true
+					break
+				}
 			}
 		}
 		if !matched {
-			errors = append(errors, validationError("enum", s.enumError))
+			errors = append(errors, vd.error("enum", s.enumError))
 		}
 	}
 
@@ -306,312 +335,38 @@ func (s *Schema) validate(scope []schemaRef, vscope int, spath string, v interfa
 		if v, ok := v.(string); ok {
 			val = quote(v)
 		}
-		errors = append(errors, validationError("format", "%v is not valid %s", val, quote(s.Format)))
+		errors = append(errors, vd.error("format", "%v is not valid %s", val, quote(s.Format)))
 	}
 
 	switch v := v.(type) {
 	case map[string]interface{}:
-		if s.MinProperties != -1 && len(v) < s.MinProperties {
-			errors = append(errors, validationError("minProperties", "minimum %d properties allowed, but found %d properties", s.MinProperties, len(v)))
-		}
-		if s.MaxProperties != -1 && len(v) > s.MaxProperties {
-			errors = append(errors, validationError("maxProperties", "maximum %d properties allowed, but found %d properties", s.MaxProperties, len(v)))
-		}
-		if len(s.Required) > 0 {
-			var missing []string
-			for _, pname := range s.Required {
-				if _, ok := v[pname]; !ok {
-					missing = append(missing, quote(pname))
-				}
-			}
-			if len(missing) > 0 {
-				errors = append(errors, validationError("required", "missing properties: %s", strings.Join(missing, ", ")))
-			}
-		}
-
-		for pname, sch := range s.Properties {
-			if pvalue, ok := v[pname]; ok {
-				delete(result.unevalProps, pname)
-				if err := validate(sch, "properties/"+escape(pname), pvalue, escape(pname)); err != nil {
-					errors = append(errors, err)
-				}
-			}
-		}
-
-		if s.PropertyNames != nil {
-			for pname := range v {
-				if err := validate(s.PropertyNames, "propertyNames", pname, escape(pname)); err != nil {
-					errors = append(errors, err)
-				}
-			}
-		}
-
-		if s.RegexProperties {
-			for pname := range v {
-				if !isRegex(pname) {
-					errors = append(errors, validationError("", "patternProperty %s is not valid regex", quote(pname)))
-				}
-			}
-		}
-		for pattern, sch := range s.PatternProperties {
-			for pname, pvalue := range v {
-				if pattern.MatchString(pname) {
-					delete(result.unevalProps, pname)
-					if err := validate(sch, "patternProperties/"+escape(pattern.String()), pvalue, escape(pname)); err != nil {
-						errors = append(errors, err)
-					}
-				}
-			}
-		}
-		if s.AdditionalProperties != nil {
-			if allowed, ok := s.AdditionalProperties.(bool); ok {
-				if !allowed && len(result.unevalProps) > 0 {
-					errors = append(errors, validationError("additionalProperties", "additionalProperties %s not allowed", result.unevalPnames()))
-				}
-			} else {
-				schema := s.AdditionalProperties.(*Schema)
-				for pname := range result.unevalProps {
-					if pvalue, ok := v[pname]; ok {
-						if err := validate(schema, "additionalProperties", pvalue, escape(pname)); err != nil {
-							errors = append(errors, err)
-						}
-					}
-				}
-			}
-			result.unevalProps = nil
-		}
-		for dname, dvalue := range s.Dependencies {
-			if _, ok := v[dname]; ok {
-				switch dvalue := dvalue.(type) {
-				case *Schema:
-					if err := validateInplace(dvalue, "dependencies/"+escape(dname)); err != nil {
-						errors = append(errors, err)
-					}
-				case []string:
-					for i, pname := range dvalue {
-						if _, ok := v[pname]; !ok {
-							errors = append(errors, validationError("dependencies/"+escape(dname)+"/"+strconv.Itoa(i), "property %s is required, if %s property exists", quote(pname), quote(dname)))
-						}
-					}
-				}
-			}
-		}
-		for dname, dvalue := range s.DependentRequired {
-			if _, ok := v[dname]; ok {
-				for i, pname := range dvalue {
-					if _, ok := v[pname]; !ok {
-						errors = append(errors, validationError("dependentRequired/"+escape(dname)+"/"+strconv.Itoa(i), "property %s is required, if %s property exists", quote(pname), quote(dname)))
-					}
-				}
-			}
-		}
-		for dname, sch := range s.DependentSchemas {
-			if _, ok := v[dname]; ok {
-				if err := validateInplace(sch, "dependentSchemas/"+escape(dname)); err != nil {
-					errors = append(errors, err)
-				}
-			}
-		}
-
+		errors = s.validateObject(vd, f, v, errors)
 	case []interface{}:
-		if s.MinItems != -1 && len(v) < s.MinItems {
-			errors = append(errors, validationError("minItems", "minimum %d items required, but found %d items", s.MinItems, len(v)))
-		}
-		if s.MaxItems != -1 && len(v) > s.MaxItems {
-			errors = append(errors, validationError("maxItems", "maximum %d items required, but found %d items", s.MaxItems, len(v)))
-		}
-		if s.UniqueItems {
-			for i := 1; i < len(v); i++ {
-				for j := 0; j < i; j++ {
-					if equals(v[i], v[j]) {
-						errors = append(errors, validationError("uniqueItems", "items at index %d and %d are equal", j, i))
-					}
-				}
-			}
-		}
-
-		// items + additionalItems
-		switch items := s.Items.(type) {
-		case *Schema:
-			for i, item := range v {
-				if err := validate(items, "items", item, strconv.Itoa(i)); err != nil {
-					errors = append(errors, err)
-				}
-			}
-			result.unevalItems = nil
-		case []*Schema:
-			for i, item := range v {
-				if i < len(items) {
-					delete(result.unevalItems, i)
-					if err := validate(items[i], "items/"+strconv.Itoa(i), item, strconv.Itoa(i)); err != nil {
-						errors = append(errors, err)
-					}
-				} else if sch, ok := s.AdditionalItems.(*Schema); ok {
-					delete(result.unevalItems, i)
-					if err := validate(sch, "additionalItems", item, strconv.Itoa(i)); err != nil {
-						errors = append(errors, err)
-					}
-				} else {
-					break
-				}
-			}
-			if additionalItems, ok := s.AdditionalItems.(bool); ok {
-				if additionalItems {
-					result.unevalItems = nil
-				} else if len(v) > len(items) {
-					errors = append(errors, validationError("additionalItems", "only %d items are allowed, but found %d items", len(items), len(v)))
-				}
-			}
-		}
-
-		// prefixItems + items
-		for i, item := range v {
-			if i < len(s.PrefixItems) {
-				delete(result.unevalItems, i)
-				if err := validate(s.PrefixItems[i], "prefixItems/"+strconv.Itoa(i), item, strconv.Itoa(i)); err != nil {
-					errors = append(errors, err)
-				}
-			} else if s.Items2020 != nil {
-				delete(result.unevalItems, i)
-				if err := validate(s.Items2020, "items", item, strconv.Itoa(i)); err != nil {
-					errors = append(errors, err)
-				}
-			} else {
-				break
-			}
-		}
-
-		// contains + minContains + maxContains
-		if s.Contains != nil && (s.MinContains != -1 || s.MaxContains != -1) {
-			matched := 0
-			var causes []error
-			for i, item := range v {
-				if err := validate(s.Contains, "contains", item, strconv.Itoa(i)); err != nil {
-					causes = append(causes, err)
-				} else {
-					matched++
-					if s.ContainsEval {
-						delete(result.unevalItems, i)
-					}
-				}
-			}
-			if s.MinContains != -1 && matched < s.MinContains {
-				errors = append(errors, validationError("minContains", "valid must be >= %d, but got %d", s.MinContains, matched).add(causes...))
-			}
-			if s.MaxContains != -1 && matched > s.MaxContains {
-				errors = append(errors, validationError("maxContains", "valid must be <= %d, but got %d", s.MaxContains, matched))
-			}
-		}
-
+		errors = s.validateArray(vd, f, v, errors)
 	case string:
-		// minLength + maxLength
-		if s.MinLength != -1 || s.MaxLength != -1 {
-			length := utf8.RuneCount([]byte(v))
-			if s.MinLength != -1 && length < s.MinLength {
-				errors = append(errors, validationError("minLength", "length must be >= %d, but got %d", s.MinLength, length))
-			}
-			if s.MaxLength != -1 && length > s.MaxLength {
-				errors = append(errors, validationError("maxLength", "length must be <= %d, but got %d", s.MaxLength, length))
-			}
-		}
-
-		if s.Pattern != nil && !s.Pattern.MatchString(v) {
-			errors = append(errors, validationError("pattern", "does not match pattern %s", quote(s.Pattern.String())))
-		}
-
-		// contentEncoding + contentMediaType
-		if s.decoder != nil || s.mediaType != nil {
-			decoded := s.ContentEncoding == ""
-			var content []byte
-			if s.decoder != nil {
-				b, err := s.decoder(v)
-				if err != nil {
-					errors = append(errors, validationError("contentEncoding", "value is not %s encoded", s.ContentEncoding))
-				} else {
-					content, decoded = b, true
-				}
-			}
-			if decoded && s.mediaType != nil {
-				if s.decoder == nil {
-					content = []byte(v)
-				}
-				if err := s.mediaType(content); err != nil {
-					errors = append(errors, validationError("contentMediaType", "value is not of mediatype %s", quote(s.ContentMediaType)))
-				}
-			}
-			if decoded && s.ContentSchema != nil {
-				contentJSON, err := unmarshal(bytes.NewReader(content))
-				if err != nil {
-					errors = append(errors, validationError("contentSchema", "value is not valid json"))
-				} else {
-					err := validate(s.ContentSchema, "contentSchema", contentJSON, "")
-					if err != nil {
-						errors = append(errors, err)
-					}
-				}
-			}
-		}
-
+		errors = s.validateString(vd, v, errors)
 	case json.Number, float32, float64, int, int8, int32, int64, uint, uint8, uint32, uint64:
-		// lazy convert to *big.Rat to avoid allocation
-		var numVal *big.Rat
-		num := func() *big.Rat {
-			if numVal == nil {
-				numVal, _ = new(big.Rat).SetString(fmt.Sprint(v))
-			}
-			return numVal
-		}
-		f64 := func(r *big.Rat) float64 {
-			f, _ := r.Float64()
-			return f
-		}
-		if s.Minimum != nil && num().Cmp(s.Minimum) < 0 {
-			errors = append(errors, validationError("minimum", "must be >= %v but found %v", f64(s.Minimum), v))
-		}
-		if s.ExclusiveMinimum != nil && num().Cmp(s.ExclusiveMinimum) <= 0 {
-			errors = append(errors, validationError("exclusiveMinimum", "must be > %v but found %v", f64(s.ExclusiveMinimum), v))
-		}
-		if s.Maximum != nil && num().Cmp(s.Maximum) > 0 {
-			errors = append(errors, validationError("maximum", "must be <= %v but found %v", f64(s.Maximum), v))
-		}
-		if s.ExclusiveMaximum != nil && num().Cmp(s.ExclusiveMaximum) >= 0 {
-			errors = append(errors, validationError("exclusiveMaximum", "must be < %v but found %v", f64(s.ExclusiveMaximum), v))
-		}
-		if s.MultipleOf != nil {
-			if q := new(big.Rat).Quo(num(), s.MultipleOf); !q.IsInt() {
-				errors = append(errors, validationError("multipleOf", "%v not multipleOf %v", v, f64(s.MultipleOf)))
-			}
-		}
+		errors = s.validateNumber(vd, v, errors)
 	}
 
 	// $ref + $recursiveRef + $dynamicRef
-	validateRef := func(sch *Schema, refPath string) error {
-		if sch != nil {
-			if err := validateInplace(sch, refPath); err != nil {
-				var url = sch.Location
-				if s.url() == sch.url() {
-					url = sch.loc()
-				}
-				return validationError(refPath, "doesn't validate with %s", quote(url)).causes(err)
-			}
+	if s.Ref != nil {
+		if err := s.validateRef(vd, f, s.Ref, "$ref"); err != nil {
+			errors = append(errors, err)
 		}
-		return nil
-	}
-	if err := validateRef(s.Ref, "$ref"); err != nil {
-		errors = append(errors, err)
 	}
 	if s.RecursiveRef != nil {
 		sch := s.RecursiveRef
 		if sch.RecursiveAnchor {
 			// recursiveRef based on scope
-			for _, e := range scope {
-				if e.schema.RecursiveAnchor {
-					sch = e.schema
+			for _, e := range vd.scope {
+				if e.sch.RecursiveAnchor {
+					sch = e.sch
 					break
 				}
 			}
 		}
-		if err := validateRef(sch, "$recursiveRef"); err != nil {
+		if err := s.validateRef(vd, f, sch, "$recursiveRef"); err != nil {
 			errors = append(errors, err)
 		}
 	}
@@ -619,12 +374,12 @@ func (s *Schema) validate(scope []schemaRef, vscope int, spath string, v interfa
 		sch := s.DynamicRef
 		if s.dynamicRefAnchor != "" && sch.DynamicAnchor == s.dynamicRefAnchor {
 			// dynamicRef based on scope
-			for i := len(scope) - 1; i >= 0; i-- {
-				sr := scope[i]
-				if sr.discard {
+			for i := len(vd.scope) - 1; i >= 0; i-- {
+				sf := vd.scope[i]
+				if sf.discard {
 					break
 				}
-				for _, da := range sr.schema.dynamicAnchors {
+				for _, da := range sf.sch.dynamicAnchors {
 					if da.DynamicAnchor == s.DynamicRef.DynamicAnchor && da != s.DynamicRef {
 						sch = da
 						break
@@ -632,81 +387,80 @@ func (s *Schema) validate(scope []schemaRef, vscope int, spath string, v interfa
 				}
 			}
 		}
-		if err := validateRef(sch, "$dynamicRef"); err != nil {
+		if err := s.validateRef(vd, f, sch, "$dynamicRef"); err != nil {
 			errors = append(errors, err)
 		}
 	}
 
-	if s.Not != nil && validateInplace(s.Not, "not") == nil {
-		errors = append(errors, validationError("not", "not failed"))
+	if s.Not != nil {
+		kw := s.keyword("not")
+		if vd.validateQuiet(f, s.Not, kw, token{}) == nil {
+			errors = append(errors, vd.error(kw, "%s failed", kw))
+		}
 	}
 
 	for i, sch := range s.AllOf {
-		schPath := "allOf/" + strconv.Itoa(i)
-		if err := validateInplace(sch, schPath); err != nil {
-			errors = append(errors, validationError(schPath, "allOf failed").add(err))
+		kw, tok := s.subschemaPath("allOf", i)
+		if err := vd.validateInplace(f, sch, kw, tok); err != nil {
+			errors = append(errors, vd.error(joinToken(kw, tok), "%s failed", kw).add(err))
 		}
 	}
 
 	if len(s.AnyOf) > 0 {
 		matched := false
-		var causes []error
 		for i, sch := range s.AnyOf {
-			if err := validateInplace(sch, "anyOf/"+strconv.Itoa(i)); err == nil {
+			kw, tok := s.subschemaPath("anyOf", i)
+			if vd.validateQuiet(f, sch, kw, tok) == nil {
 				matched = true
-			} else {
-				causes = append(causes, err)
 			}
 		}
 		if !matched {
-			errors = append(errors, validationError("anyOf", "anyOf failed").add(causes...))
+			kw := s.keyword("anyOf")
+			errors = append(errors, vd.error(kw, "%s failed", kw).add(s.causes(vd, f, "anyOf", s.AnyOf)...))
 		}
 	}
 
 	if len(s.OneOf) > 0 {
 		matched := -1
-		var causes []error
 		for i, sch := range s.OneOf {
-			if err := validateInplace(sch, "oneOf/"+strconv.Itoa(i)); err == nil {
+			if vd.validateQuiet(f, sch, "oneOf", indexToken(i)) == nil {
 				if matched == -1 {
 					matched = i
 				} else {
-					errors = append(errors, validationError("oneOf", "valid against schemas at indexes %d and %d", matched, i))
+					errors = append(errors, vd.error("oneOf", "valid against schemas at indexes %d and %d", matched, i))
 					break
 				}
-			} else {
-				causes = append(causes, err)
 			}
 		}
 		if matched == -1 {
-			errors = append(errors, validationError("oneOf", "oneOf failed").add(causes...))
+			errors = append(errors, vd.error("oneOf", "oneOf failed").add(s.causes(vd, f, "oneOf", s.OneOf)...))
 		}
 	}
 
 	// if + then + else
 	if s.If != nil {
-		err := validateInplace(s.If, "if")
+		err := vd.validateQuiet(f, s.If, "if", token{})
 		// "if" leaves dynamic scope
-		scope[len(scope)-1].discard = true
+		f.discard = true
 		if err == nil {
 			if s.Then != nil {
-				if err := validateInplace(s.Then, "then"); err != nil {
-					errors = append(errors, validationError("then", "if-then failed").add(err))
+				if err := vd.validateInplace(f, s.Then, "then", token{}); err != nil {
+					errors = append(errors, vd.error("then", "if-then failed").add(err))
 				}
 			}
 		} else {
 			if s.Else != nil {
-				if err := validateInplace(s.Else, "else"); err != nil {
-					errors = append(errors, validationError("else", "if-else failed").add(err))
+				if err := vd.validateInplace(f, s.Else, "else", token{}); err != nil {
+					errors = append(errors, vd.error("else", "if-else failed").add(err))
 				}
 			}
 		}
 		// restore dynamic scope
-		scope[len(scope)-1].discard = false
+		f.discard = false
 	}
 
 	for _, ext := range s.Extensions {
-		if err := ext.Validate(ValidationContext{result, validate, validateInplace, validationError}, v); err != nil {
+		if err := ext.Validate(ValidationContext{vd, f}, v); err != nil {
 			errors = append(errors, err)
 		}
 	}
@@ -715,49 +469,397 @@ func (s *Schema) validate(scope []schemaRef, vscope int, spath string, v interfa
 	switch v := v.(type) {
 	case map[string]interface{}:
 		if s.UnevaluatedProperties != nil {
-			for pname := range result.unevalProps {
-				if pvalue, ok := v[pname]; ok {
-					if err := validate(s.UnevaluatedProperties, "unevaluatedProperties", pvalue, escape(pname)); err != nil {
+			for pname, pvalue := range v {
+				if !f.eval.hasProp(pname) {
+					if err := vd.validate(s.UnevaluatedProperties, "unevaluatedProperties", token{}, pvalue, propToken(pname)); err != nil {
 						errors = append(errors, err)
 					}
 				}
 			}
-			result.unevalProps = nil
+			f.eval.allProps = true
 		}
 	case []interface{}:
 		if s.UnevaluatedItems != nil {
-			for i := range result.unevalItems {
-				if err := validate(s.UnevaluatedItems, "unevaluatedItems", v[i], strconv.Itoa(i)); err != nil {
-					errors = append(errors, err)
+			for i, item := range v {
+				if !f.eval.hasItem(i) {
+					if err := vd.validate(s.UnevaluatedItems, "unevaluatedItems", token{}, item, indexToken(i)); err != nil {
+						errors = append(errors, err)
+					}
 				}
 			}
-			result.unevalItems = nil
+			f.eval.allItems = true
 		}
 	}
 
+	var err error
 	switch len(errors) {
 	case 0:
-		return result, nil
 	case 1:
-		return result, errors[0]
+		err = errors[0]
 	default:
-		return result, validationError("", "").add(errors...) // empty message, used just for wrapping
+		err = vd.error("", "").add(errors...) // empty message, used just for wrapping
 	}
+	f.errs = errors[:0]
+	return err
 }
 
-type validationResult struct {
-	unevalProps map[string]struct{}
-	unevalItems map[int]struct{}
+func (s *Schema) validateObject(vd *validator, f *frame, v map[string]interface{}, errors []error) []error {
+	if s.MinProperties != -1 && len(v) < s.MinProperties {
+		errors = append(errors, vd.error("minProperties", "minimum %d properties allowed, but found %d properties", s.MinProperties, len(v)))
+	}
+	if s.MaxProperties != -1 && len(v) > s.MaxProperties {
+		errors = append(errors, vd.error("maxProperties", "maximum %d properties allowed, but found %d properties", s.MaxProperties, len(v)))
+	}
+	if len(s.Required) > 0 {
+		var missing []string
+		for _, pname := range s.Required {
+			if _, ok := v[pname]; !ok {
+				if s.Draft.version == 3 {
+					// "required" is in property schema
+					errors = append(errors, vd.error("properties/"+escape(pname)+"/required", "missing properties: %s", quote(pname)))
+					continue
+				}
+				missing = append(missing, quote(pname))
+			}
+		}
+		if len(missing) > 0 {
+			errors = append(errors, vd.error("required", "missing properties: %s", strings.Join(missing, ", ")))
+		}
+	}
+
+	for pname, sch := range s.Properties {
+		if pvalue, ok := v[pname]; ok {
+			if f.track {
+				f.eval.addProp(pname)
+			}
+			if err := vd.validate(sch, "properties", propToken(pname), pvalue, propToken(pname)); err != nil {
+				errors = append(errors, err)
+			}
+		}
+	}
+
+	if s.PropertyNames != nil {
+		for pname := range v {
+			if err := vd.validate(s.PropertyNames, "propertyNames", token{}, pname, propToken(pname)); err != nil {
+				errors = append(errors, err)
+			}
+		}
+	}
+
+	if s.RegexProperties {
+		for pname := range v {
+			if !isRegex(pname) {
+				errors = append(errors, vd.error("", "patternProperty %s is not valid regex", quote(pname)))
+			}
+		}
+	}
+
+	if len(s.PatternProperties) > 0 || s.AdditionalProperties != nil {
+		var additional []string
+		for pname, pvalue := range v {
+			matched := false
+			if _, ok := s.Properties[pname]; ok {
+				matched = true
+			}
+			for pattern, sch := range s.PatternProperties {
+				if pattern.MatchString(pname) {
+					matched = true
+					if f.track {
+						f.eval.addProp(pname)
+					}
+					if err := vd.validate(sch, "patternProperties", propToken(pattern.String()), pvalue, propToken(pname)); err != nil {
+						errors = append(errors, err)
+					}
+				}
+			}
+			if matched {
+				continue
+			}
+			switch additionalProps := s.AdditionalProperties.(type) {
+			case bool:
+				if !additionalProps {
+					additional = append(additional, quote(pname))
+				}
+			case *Schema:
+				if err := vd.validate(additionalProps, "additionalProperties", token{}, pvalue, propToken(pname)); err != nil {
+					errors = append(errors, err)
+				}
+			}
+		}
+		if len(additional) > 0 {
+			errors = append(errors, vd.error("additionalProperties", "additionalProperties %s not allowed", strings.Join(additional, ", ")))
+		}
+		if s.AdditionalProperties != nil {
+			f.eval.allProps = true
+		}
+	}
+
+	for dname, dvalue := range s.Dependencies {
+		if _, ok := v[dname]; ok {
+			switch dvalue := dvalue.(type) {
+			case *Schema:
+				if err := vd.validateInplace(f, dvalue, "dependencies", propToken(dname)); err != nil {
+					errors = append(errors, err)
+				}
+			case []string:
+				for i, pname := range dvalue {
+					if _, ok := v[pname]; !ok {
+						errors = append(errors, vd.error("dependencies/"+escape(dname)+"/"+strconv.Itoa(i), "property %s is required, if %s property exists", quote(pname), quote(dname)))
+					}
+				}
+			}
+		}
+	}
+	for dname, dvalue := range s.DependentRequired {
+		if _, ok := v[dname]; ok {
+			for i, pname := range dvalue {
+				if _, ok := v[pname]; !ok {
+					errors = append(errors, vd.error("dependentRequired/"+escape(dname)+"/"+strconv.Itoa(i), "property %s is required, if %s property exists", quote(pname), quote(dname)))
+				}
+			}
+		}
+	}
+	for dname, sch := range s.DependentSchemas {
+		if _, ok := v[dname]; ok {
+			if err := vd.validateInplace(f, sch, "dependentSchemas", propToken(dname)); err != nil {
+				errors = append(errors, err)
+			}
+		}
+	}
+	return errors
 }
 
-func (vr validationResult) unevalPnames() string {
-	pnames := make([]string, 0, len(vr.unevalProps))
-	for pname := range vr.unevalProps {
-		pnames = append(pnames, quote(pname))
+func (s *Schema) validateArray(vd *validator, f *frame, v []interface{}, errors []error) []error {
+	if s.MinItems != -1 && len(v) < s.MinItems {
+		errors = append(errors, vd.error("minItems", "minimum %d items required, but found %d items", s.MinItems, len(v)))
 	}
-	return strings.Join(pnames, ", ")
+	if s.MaxItems != -1 && len(v) > s.MaxItems {
+		errors = append(errors, vd.error("maxItems", "maximum %d items required, but found %d items", s.MaxItems, len(v)))
+	}
+	if s.UniqueItems && len(v) > 1 {
+		i, j := -1, -1
+		if len(v) <= smallArrayLen {
+			// pairwise comparison is cheaper than hashing for small arrays
+		loop:
+			for j = 1; j < len(v); j++ {
+				for i = 0; i < j; i++ {
+					if equals(v[i], v[j]) {
+						break loop
+					}
+				}
+			}
+			if j == len(v) {
+				i, j = -1, -1
+			}
+		} else {
+			i, j = vd.duplicates(v)
+		}
+		if j != -1 {
+			errors = append(errors, vd.error("uniqueItems", "items at index %d and %d are equal", i, j))
+		}
+	}
+
+	// items + additionalItems
+	switch items := s.Items.(type) {
+	case *Schema:
+		for i, item := range v {
+			if err := vd.validate(items, "items", token{}, item, indexToken(i)); err != nil {
+				errors = append(errors, err)
+			}
+		}
+		f.eval.allItems = true
+	case []*Schema:
+		for i, item := range v {
+			if i < len(items) {
+				if f.track {
+					f.eval.items.set(i)
+				}
+				if err := vd.validate(items[i], "items", indexToken(i), item, indexToken(i)); err != nil {
+					errors = append(errors, err)
+				}
+			} else if sch, ok := s.AdditionalItems.(*Schema); ok {
+				if f.track {
+					f.eval.items.set(i)
+				}
+				if err := vd.validate(sch, "additionalItems", token{}, item, indexToken(i)); err != nil {
+					errors = append(errors, err)
+				}
+			} else {
+				break
+			}
+		}
+		if additionalItems, ok := s.AdditionalItems.(bool); ok {
+			if additionalItems {
+				f.eval.allItems = true
+			} else if len(v) > len(items) {
+				errors = append(errors, vd.error("additionalItems", "only %d items are allowed, but found %d items", len(items), len(v)))
+			}
+		}
+	}
+
+	// prefixItems + items
+	for i, item := range v {
+		if i < len(s.PrefixItems) {
+			if f.track {
+				f.eval.items.set(i)
+			}
+			if err := vd.validate(s.PrefixItems[i], "prefixItems", indexToken(i), item, indexToken(i)); err != nil {
+				errors = append(errors, err)
+			}
+		} else if s.Items2020 != nil {
+			if err := vd.validate(s.Items2020, "items", token{}, item, indexToken(i)); err != nil {
+				errors = append(errors, err)
+			}
+		} else {
+			break
+		}
+	}
+	if s.Items2020 != nil {
+		f.eval.allItems = true
+	}
+
+	// contains + minContains + maxContains
+	if s.Contains != nil && (s.MinContains != -1 || s.MaxContains != -1) {
+		matched := 0
+		quiet := vd.quiet
+		vd.quiet = true
+		for i, item := range v {
+			if vd.validate(s.Contains, "contains", token{}, item, indexToken(i)) == nil {
+				matched++
+				if s.ContainsEval && f.track {
+					f.eval.items.set(i)
+				}
+			}
+		}
+		vd.quiet = quiet
+		if s.MinContains != -1 && matched < s.MinContains {
+			var causes []error
+			if !vd.quiet {
+				// validate again to collect the errors
+				for i, item := range v {
+					if err := vd.validate(s.Contains, "contains", token{}, item, indexToken(i)); err != nil {
+						causes = append(causes, err)
+					}
+				}
+			}
+			errors = append(errors, vd.error("minContains", "valid must be >= %d, but got %d", s.MinContains, matched).add(causes...))
+		}
+		if s.MaxContains != -1 && matched > s.MaxContains {
+			errors = append(errors, vd.error("maxContains", "valid must be <= %d, but got %d", s.MaxContains, matched))
+		}
+	}
+	return errors
 }
 
+func (s *Schema) validateString(vd *validator, v string, errors []error) []error {
+	// minLength + maxLength
+	if s.MinLength != -1 || s.MaxLength != -1 {
+		length := utf8.RuneCountInString(v)
+		if s.MinLength != -1 && length < s.MinLength {
+			errors = append(errors, vd.error("minLength", "length must be >= %d, but got %d", s.MinLength, length))
+		}
+		if s.MaxLength != -1 && length > s.MaxLength {
+			errors = append(errors, vd.error("maxLength", "length must be <= %d, but got %d", s.MaxLength, length))
+		}
+	}
+
+	if s.Pattern != nil && !s.Pattern.MatchString(v) {
+		errors = append(errors, vd.error("pattern", "does not match pattern %s", quote(s.Pattern.String())))
+	}
+
+	// contentEncoding + contentMediaType
+	if s.decoder != nil || s.mediaType != nil {
+		decoded := s.ContentEncoding == ""
+		var content []byte
+		if s.decoder != nil {
+			b, err := s.decoder(v)
+			if err != nil {
+				errors = append(errors, vd.error("contentEncoding", "value is not %s encoded", s.ContentEncoding))
+			} else {
+				content, decoded = b, true
+			}
+		}
+		if decoded && s.mediaType != nil {
+			if s.decoder == nil {
+				content = []byte(v)
+			}
+			if err := s.mediaType(content); err != nil {
+				errors = append(errors, vd.error("contentMediaType", "value is not of mediatype %s", quote(s.ContentMediaType)))
+			}
+		}
+		if decoded && s.ContentSchema != nil {
+			contentJSON, err := unmarshal(bytes.NewReader(content))
+			if err != nil {
+				errors = append(errors, vd.error("contentSchema", "value is not valid json"))
+			} else {
+				err := vd.validate(s.ContentSchema, "contentSchema", token{}, contentJSON, token{})
+				if err != nil {
+					errors = append(errors, err)
+				}
+			}
+		}
+	}
+	return errors
+}
+
+func (s *Schema) validateNumber(vd *validator, v interface{}, errors []error) []error {
+	if s.Minimum == nil && s.ExclusiveMinimum == nil && s.Maximum == nil && s.ExclusiveMaximum == nil && s.MultipleOf == nil {
+		return errors
+	}
+	num := newNumber(v)
+	if s.Minimum != nil && num.cmp(s.Minimum, s.minimum) < 0 {
+		errors = append(errors, vd.error("minimum", "must be >= %v but found %v", ratString(s.Minimum), v))
+	}
+	if s.ExclusiveMinimum != nil && num.cmp(s.ExclusiveMinimum, s.exclusiveMinimum) <= 0 {
+		errors = append(errors, vd.error("exclusiveMinimum", "must be > %v but found %v", ratString(s.ExclusiveMinimum), v))
+	}
+	if s.Maximum != nil && num.cmp(s.Maximum, s.maximum) > 0 {
+		errors = append(errors, vd.error("maximum", "must be <= %v but found %v", ratString(s.Maximum), v))
+	}
+	if s.ExclusiveMaximum != nil && num.cmp(s.ExclusiveMaximum, s.exclusiveMaximum) >= 0 {
+		errors = append(errors, vd.error("exclusiveMaximum", "must be < %v but found %v", ratString(s.ExclusiveMaximum), v))
+	}
+	if s.MultipleOf != nil && !num.isMultipleOf(s.MultipleOf, s.multipleOf) {
+		kw := s.keyword("multipleOf")
+		errors = append(errors, vd.error(kw, "%v not %s %v", v, kw, ratString(s.MultipleOf)))
+	}
+	return errors
+}
+
+// causes validates instance value of frame f with each of the schemas
+// of combinator keyword kw, and returns the errors. It is used only when
+// kw failed, since combinators are first checked in quiet mode.
+func (s *Schema) causes(vd *validator, f *frame, kw string, schemas []*Schema) []error {
+	if vd.quiet {
+		return nil
+	}
+	var causes []error
+	for i, sch := range schemas {
+		kw, tok := s.subschemaPath(kw, i)
+		if err := vd.validateInplace(f, sch, kw, tok); err != nil {
+			causes = append(causes, err)
+		}
+	}
+	return causes
+}
+
+// validateRef validates instance value of frame f with sch, which
+// is target of refKeyword in s.
+func (s *Schema) validateRef(vd *validator, f *frame, sch *Schema, refKeyword string) error {
+	if err := vd.validateInplace(f, sch, refKeyword, token{}); err != nil {
+		var url = sch.Location
+		if s.url() == sch.url() {
+			url = sch.loc()
+		}
+		return vd.error(refKeyword, "doesn't validate with %s", quote(url)).causes(err)
+	}
+	return nil
+}
+
+// smallArrayLen is the length of enum/array up to which linear comparisons
+// are used instead of hashing.
+const smallArrayLen = 8
+
 // jsonType returns the json type of given value v.
 //
 // It panics if the given value is not valid json value
@@ -813,9 +915,7 @@ func equals(v1, v2 interface{}) bool {
 		}
 		return true
 	case "number":
-		num1, _ := new(big.Rat).SetString(fmt.Sprint(v1))
-		num2, _ := new(big.Rat).SetString(fmt.Sprint(v2))
-		return num1.Cmp(num2) == 0
+		return numEquals(v1, v2)
 	default:
 		return v1 == v2
 	}
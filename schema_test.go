@@ -14,7 +14,9 @@ import (
 	"os"
 	"path"
 	"path/filepath"
+	"reflect"
 	"runtime"
+	"sort"
 	"strings"
 	"testing"
 
@@ -23,6 +25,20 @@ import (
 )
 
 var skipTests = map[string]map[string][]string{
+	"TestDraft3/ref.json": {
+		"$ref prevents a sibling id from changing the base uri": {}, // uses allOf, which is not in draft3
+	},
+	"TestDraft3/optional/zeroTerminatedFloats.json": {
+		"some languages do not distinguish between different types of numeric value": {}, // this behavior is changed in new drafts
+	},
+	"TestDraft3/optional/ecmascript-regex.json": {
+		"ECMA 262 regex dialect recognition": {"[^] is a valid regex"}, // invalid regex "[^]"
+	},
+	"TestDraft3/optional/format/color.json": {}, // css colors are not supported
+	"TestDraft3/optional/format/time.json": {
+		"validation of time strings": {"a valid time string"}, // draft3 time has no time-offset
+	},
+	//
 	"TestDraft4/optional/zeroTerminatedFloats.json": {
 		"some languages do not distinguish between different types of numeric value": {}, // this behavior is changed in new drafts
 	},
@@ -149,32 +165,36 @@ var skipTests = map[string]map[string][]string{
 	},
 }
 
+func TestDraft3(t *testing.T) {
+	testSuite(t, "testdata/JSON-Schema-Test-Suite/tests/draft3", jsonschema.Draft3)
+}
+
 func TestDraft4(t *testing.T) {
-	testFolder(t, "testdata/JSON-Schema-Test-Suite/tests/draft4", jsonschema.Draft4)
+	testSuite(t, "testdata/JSON-Schema-Test-Suite/tests/draft4", jsonschema.Draft4)
 }
 
 func TestDraft6(t *testing.T) {
-	testFolder(t, "testdata/JSON-Schema-Test-Suite/tests/draft6", jsonschema.Draft6)
+	testSuite(t, "testdata/JSON-Schema-Test-Suite/tests/draft6", jsonschema.Draft6)
 }
 
 func TestDraft7(t *testing.T) {
-	testFolder(t, "testdata/JSON-Schema-Test-Suite/tests/draft7", jsonschema.Draft7)
+	testSuite(t, "testdata/JSON-Schema-Test-Suite/tests/draft7", jsonschema.Draft7)
 }
 
 func TestDraft2019(t *testing.T) {
-	testFolder(t, "testdata/JSON-Schema-Test-Suite/tests/draft2019-09", jsonschema.Draft2019)
+	testSuite(t, "testdata/JSON-Schema-Test-Suite/tests/draft2019-09", jsonschema.Draft2019)
 }
 
 func TestDraft2020(t *testing.T) {
-	testFolder(t, "testdata/JSON-Schema-Test-Suite/tests/draft2020-12", jsonschema.Draft2020)
+	testSuite(t, "testdata/JSON-Schema-Test-Suite/tests/draft2020-12", jsonschema.Draft2020)
 }
 
 func TestExtra(t *testing.T) {
 	t.Run("draft7", func(t *testing.T) {
-		testFolder(t, "testdata/tests/draft7", jsonschema.Draft7)
+		testSuite(t, "testdata/tests/draft7", jsonschema.Draft7)
 	})
 	t.Run("draft2020", func(t *testing.T) {
-		testFolder(t, "testdata/tests/draft2020", jsonschema.Draft2020)
+		testSuite(t, "testdata/tests/draft2020", jsonschema.Draft2020)
 	})
 }
 
@@ -189,6 +209,61 @@ type testGroup struct {
 	}
 }
 
+// walkSuite compiles the schema of each test group in JSON-Schema-Test-Suite
+// and testdata/tests, and calls fn with it. Groups whose schema fail to
+// compile, like invalid regex, are skipped.
+func walkSuite(t *testing.T, fn func(path string, group testGroup, sch *jsonschema.Schema)) {
+	t.Helper()
+	drafts := []struct {
+		folder string
+		draft  *jsonschema.Draft
+	}{
+		{"testdata/JSON-Schema-Test-Suite/tests/draft3", jsonschema.Draft3},
+		{"testdata/JSON-Schema-Test-Suite/tests/draft4", jsonschema.Draft4},
+		{"testdata/JSON-Schema-Test-Suite/tests/draft6", jsonschema.Draft6},
+		{"testdata/JSON-Schema-Test-Suite/tests/draft7", jsonschema.Draft7},
+		{"testdata/JSON-Schema-Test-Suite/tests/draft2019-09", jsonschema.Draft2019},
+		{"testdata/JSON-Schema-Test-Suite/tests/draft2020-12", jsonschema.Draft2020},
+		{"testdata/tests/draft7", jsonschema.Draft7},
+		{"testdata/tests/draft2020", jsonschema.Draft2020},
+	}
+	for _, d := range drafts {
+		err := filepath.Walk(d.folder, func(path string, info os.FileInfo, err error) error {
+			if err != nil || info.IsDir() || filepath.Ext(path) != ".json" {
+				return err
+			}
+			b, err := ioutil.ReadFile(path)
+			if err != nil {
+				return err
+			}
+			var tg []testGroup
+			if err := json.Unmarshal(b, &tg); err != nil {
+				return err
+			}
+			for _, group := range tg {
+				c := jsonschema.NewCompiler()
+				c.Draft = d.draft
+				if strings.Contains(path, "optional") {
+					c.AssertFormat = true
+					c.AssertContent = true
+				}
+				if err := c.AddResource("schema.json", bytes.NewReader(group.Schema)); err != nil {
+					return err
+				}
+				sch, err := c.Compile("schema.json")
+				if err != nil {
+					continue
+				}
+				fn(path, group, sch)
+			}
+			return nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
 func TestMain(m *testing.M) {
 	server1 := &http.Server{Addr: "localhost:1234", Handler: http.FileServer(http.Dir("testdata/JSON-Schema-Test-Suite/remotes"))}
 	go func() {
@@ -205,7 +280,15 @@ func TestMain(m *testing.M) {
 	os.Exit(m.Run())
 }
 
-func testFolder(t *testing.T, folder string, draft *jsonschema.Draft) {
+// testSuite runs the tests in folder, with and without Compiler.Memoize.
+func testSuite(t *testing.T, folder string, draft *jsonschema.Draft) {
+	testFolder(t, folder, draft, false)
+	t.Run("memoize", func(t *testing.T) {
+		testFolder(t, folder, draft, true)
+	})
+}
+
+func testFolder(t *testing.T, folder string, draft *jsonschema.Draft, memoize bool) {
 	fis, err := ioutil.ReadDir(folder)
 	if err != nil {
 		t.Fatal(err)
@@ -213,7 +296,7 @@ func testFolder(t *testing.T, folder string, draft *jsonschema.Draft) {
 	for _, fi := range fis {
 		if fi.IsDir() {
 			t.Run(fi.Name(), func(t *testing.T) {
-				testFolder(t, path.Join(folder, fi.Name()), draft)
+				testFolder(t, path.Join(folder, fi.Name()), draft, memoize)
 			})
 			continue
 		}
@@ -221,7 +304,7 @@ func testFolder(t *testing.T, folder string, draft *jsonschema.Draft) {
 			continue
 		}
 		t.Run(fi.Name(), func(t *testing.T) {
-			skip := skipTests[t.Name()]
+			skip := skipTests[strings.Replace(t.Name(), "/memoize", "", 1)]
 			if skip != nil && len(skip) == 0 {
 				t.Skip()
 			}
@@ -252,6 +335,7 @@ func testFolder(t *testing.T, folder string, draft *jsonschema.Draft) {
 					}
 					c := jsonschema.NewCompiler()
 					c.Draft = draft
+					c.Memoize = memoize
 					if strings.Index(folder, "optional") != -1 {
 						c.AssertFormat = true
 						c.AssertContent = true
@@ -703,6 +787,70 @@ func TestSchemaDraftFeild(t *testing.T) {
 	}
 }
 
+func TestDraft3KeywordLocations(t *testing.T) {
+	tests := []struct {
+		schema, instance string
+		want             []string // keywordLocation of errors
+	}{
+		{`{"extends": {"minimum": 5}}`, `3`, []string{"", "/extends", "/extends/minimum"}},
+		{`{"extends": [{"minimum": 5}]}`, `3`, []string{"", "/extends/0", "/extends/0/minimum"}},
+		{`{"disallow": "integer"}`, `3`, []string{"", "/disallow"}},
+		{`{"disallow": ["string", {"minimum": 2}]}`, `3`, []string{"", "/disallow"}},
+		{`{"type": ["string", {"minimum": 5}]}`, `3`, []string{"", "/type", "/type", "/type/1/minimum"}},
+		{`{"properties": {"a": {"required": true}}}`, `{}`, []string{"", "/properties/a/required"}},
+		{`{"divisibleBy": 2}`, `3`, []string{"", "/divisibleBy"}},
+	}
+	for i, test := range tests {
+		c := jsonschema.NewCompiler()
+		c.Draft = jsonschema.Draft3
+		url := fmt.Sprintf("test%d.json", i)
+		if err := c.AddResource(url, strings.NewReader(test.schema)); err != nil {
+			t.Fatal(err)
+		}
+		sch, err := c.Compile(url)
+		if err != nil {
+			t.Fatalf("%s: %v", test.schema, err)
+		}
+		err = sch.Validate(decodeString(t, test.instance))
+		ve, ok := err.(*jsonschema.ValidationError)
+		if !ok {
+			t.Fatalf("%s: got %v, want ValidationError", test.schema, err)
+		}
+		var got []string
+		for _, e := range ve.BasicOutput().Errors {
+			got = append(got, e.KeywordLocation)
+			if want := sch.Location + e.KeywordLocation; e.AbsoluteKeywordLocation != want {
+				t.Errorf("%s: absoluteKeywordLocation: got %q, want %q", test.schema, e.AbsoluteKeywordLocation, want)
+			}
+		}
+		sort.Strings(got)
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%s: keywordLocations:\n got %q\nwant %q", test.schema, got, test.want)
+		}
+	}
+}
+
+func TestDraft3HostName(t *testing.T) {
+	for _, test := range []struct {
+		draft *jsonschema.Draft
+		valid bool
+	}{
+		{jsonschema.Draft3, false},
+		{jsonschema.Draft4, true},
+		{jsonschema.Draft7, true},
+	} {
+		c := jsonschema.NewCompiler()
+		c.Draft = test.draft
+		if err := c.AddResource("schema.json", strings.NewReader(`{"format": "host-name"}`)); err != nil {
+			t.Fatal(err)
+		}
+		sch := c.MustCompile("schema.json")
+		if err := sch.Validate("-invalid-"); (err == nil) != test.valid {
+			t.Errorf("%s: got %v, want valid=%v", test.draft, err, test.valid)
+		}
+	}
+}
+
 func runHTTPServers() (httpURL, httpsURL string, cleanup func()) {
 	tr := http.DefaultTransport.(*http.Transport)
 	if tr.TLSClientConfig == nil {
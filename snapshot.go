@@ -0,0 +1,649 @@
+package jsonschema
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/big"
+	"regexp"
+)
+
+// snapshotVersion is the version of snapshot format written by WriteSnapshot.
+const snapshotVersion = 1
+
+// snapshot is the serialized form of compiled schemas.
+//
+// Schemas are stored in a table. References to schemas are 1-based
+// indexes into the table, 0 means nil.
+type snapshot struct {
+	Version int           `json:"version"`
+	Roots   []int         `json:"roots"`
+	Schemas []*snapSchema `json:"schemas"`
+}
+
+type snapSchema struct {
+	DraftMeta int `json:"draftMeta,omitempty"` // draft version, if this is metaschema of that draft
+
+	Location       string   `json:"location,omitempty"`
+	Draft          int      `json:"draft,omitempty"`
+	Meta           int      `json:"meta,omitempty"`
+	Vocab          []string `json:"vocab,omitempty"`
+	DynamicAnchors []int    `json:"dynamicAnchors,omitempty"`
+
+	Format           string        `json:"format,omitempty"`
+	AssertFormat     bool          `json:"assertFormat,omitempty"`
+	Always           *bool         `json:"always,omitempty"`
+	Ref              int           `json:"ref,omitempty"`
+	RecursiveAnchor  bool          `json:"recursiveAnchor,omitempty"`
+	RecursiveRef     int           `json:"recursiveRef,omitempty"`
+	DynamicAnchor    string        `json:"dynamicAnchor,omitempty"`
+	DynamicRef       int           `json:"dynamicRef,omitempty"`
+	DynamicRefAnchor string        `json:"dynamicRefAnchor,omitempty"`
+	Types            []string      `json:"types,omitempty"`
+	Constant         []interface{} `json:"const,omitempty"`
+	Enum             []interface{} `json:"enum,omitempty"`
+	EnumError        string        `json:"enumError,omitempty"`
+	Not              int           `json:"not,omitempty"`
+	AllOf            []int         `json:"allOf,omitempty"`
+	AnyOf            []int         `json:"anyOf,omitempty"`
+	OneOf            []int         `json:"oneOf,omitempty"`
+	ExtendsSchema    bool          `json:"extendsSchema,omitempty"`
+	TypeIndex        []int         `json:"typeIndex,omitempty"`
+	Disallow         bool          `json:"disallow,omitempty"`
+	If               int           `json:"if,omitempty"`
+	Then             int           `json:"then,omitempty"`
+	Else             int           `json:"else,omitempty"`
+
+	MinProperties         int                    `json:"minProperties,omitempty"`
+	MaxProperties         int                    `json:"maxProperties,omitempty"`
+	Required              []string               `json:"required,omitempty"`
+	Properties            map[string]int         `json:"properties,omitempty"`
+	PropertyNames         int                    `json:"propertyNames,omitempty"`
+	RegexProperties       bool                   `json:"regexProperties,omitempty"`
+	PatternProperties     map[string]int         `json:"patternProperties,omitempty"`
+	AdditionalProperties  interface{}            `json:"additionalProperties,omitempty"` // bool or ref
+	Dependencies          map[string]interface{} `json:"dependencies,omitempty"`         // ref or []string
+	DependentRequired     map[string][]string    `json:"dependentRequired,omitempty"`
+	DependentSchemas      map[string]int         `json:"dependentSchemas,omitempty"`
+	UnevaluatedProperties int                    `json:"unevaluatedProperties,omitempty"`
+
+	MinItems         int         `json:"minItems,omitempty"`
+	MaxItems         int         `json:"maxItems,omitempty"`
+	UniqueItems      bool        `json:"uniqueItems,omitempty"`
+	Items            interface{} `json:"items,omitempty"`           // ref or []ref
+	AdditionalItems  interface{} `json:"additionalItems,omitempty"` // bool or ref
+	PrefixItems      []int       `json:"prefixItems,omitempty"`
+	Items2020        int         `json:"items2020,omitempty"`
+	Contains         int         `json:"contains,omitempty"`
+	ContainsEval     bool        `json:"containsEval,omitempty"`
+	MinContains      int         `json:"minContains,omitempty"`
+	MaxContains      int         `json:"maxContains,omitempty"`
+	UnevaluatedItems int         `json:"unevaluatedItems,omitempty"`
+
+	MinLength        int    `json:"minLength,omitempty"`
+	MaxLength        int    `json:"maxLength,omitempty"`
+	Pattern          string `json:"pattern,omitempty"`
+	ContentEncoding  string `json:"contentEncoding,omitempty"`
+	Decode           bool   `json:"decode,omitempty"`
+	ContentMediaType string `json:"contentMediaType,omitempty"`
+	CheckMediaType   bool   `json:"checkMediaType,omitempty"`
+	ContentSchema    int    `json:"contentSchema,omitempty"`
+
+	Minimum          string `json:"minimum,omitempty"`
+	ExclusiveMinimum string `json:"exclusiveMinimum,omitempty"`
+	Maximum          string `json:"maximum,omitempty"`
+	ExclusiveMaximum string `json:"exclusiveMaximum,omitempty"`
+	MultipleOf       string `json:"multipleOf,omitempty"`
+
+	Title       string        `json:"title,omitempty"`
+	Description string        `json:"description,omitempty"`
+	Default     interface{}   `json:"default,omitempty"`
+	Comment     string        `json:"comment,omitempty"`
+	ReadOnly    bool          `json:"readOnly,omitempty"`
+	WriteOnly   bool          `json:"writeOnly,omitempty"`
+	Examples    []interface{} `json:"examples,omitempty"`
+	Deprecated  bool          `json:"deprecated,omitempty"`
+
+	Extensions []string               `json:"extensions,omitempty"`
+	ExtDoc     map[string]interface{} `json:"extDoc,omitempty"`
+	ExtSchemas map[string]int         `json:"extSchemas,omitempty"`
+	ExtRefs    map[string]int         `json:"extRefs,omitempty"`
+}
+
+var drafts = []*Draft{Draft3, Draft4, Draft6, Draft7, Draft2019, Draft2020}
+
+func draftByVersion(version int) *Draft {
+	for _, d := range drafts {
+		if d.version == version {
+			return d
+		}
+	}
+	return nil
+}
+
+// WriteSnapshot writes the given schemas, along with all schemas
+// reachable from them, to w. The snapshot can be loaded using
+// Compiler.LoadSnapshot, without reparsing and revalidating the
+// schema documents.
+//
+// Formats, decoders, mediaTypes and extensions are written by name.
+func WriteSnapshot(w io.Writer, schemas ...*Schema) error {
+	sw := &snapshotWriter{index: make(map[*Schema]int)}
+	snap := snapshot{Version: snapshotVersion}
+	for _, s := range schemas {
+		snap.Roots = append(snap.Roots, sw.ref(s))
+	}
+	snap.Schemas = sw.schemas
+	return json.NewEncoder(w).Encode(snap)
+}
+
+type snapshotWriter struct {
+	index   map[*Schema]int
+	schemas []*snapSchema
+}
+
+func (sw *snapshotWriter) ref(s *Schema) int {
+	if s == nil {
+		return 0
+	}
+	if i, ok := sw.index[s]; ok {
+		return i
+	}
+	ss := &snapSchema{}
+	sw.schemas = append(sw.schemas, ss)
+	i := len(sw.schemas)
+	sw.index[s] = i
+	for _, d := range drafts {
+		if s == d.meta {
+			ss.DraftMeta = d.version
+			return i
+		}
+	}
+	sw.fill(ss, s)
+	return i
+}
+
+func (sw *snapshotWriter) refs(schemas []*Schema) []int {
+	if schemas == nil {
+		return nil
+	}
+	refs := make([]int, len(schemas))
+	for i, s := range schemas {
+		refs[i] = sw.ref(s)
+	}
+	return refs
+}
+
+func (sw *snapshotWriter) refMap(schemas map[string]*Schema) map[string]int {
+	if schemas == nil {
+		return nil
+	}
+	refs := make(map[string]int, len(schemas))
+	for k, s := range schemas {
+		refs[k] = sw.ref(s)
+	}
+	return refs
+}
+
+// boolOrRef is used for fields which hold nil or bool or *Schema.
+func (sw *snapshotWriter) boolOrRef(v interface{}) interface{} {
+	switch v := v.(type) {
+	case bool:
+		return v
+	case *Schema:
+		return sw.ref(v)
+	}
+	return nil
+}
+
+func snapRat(r *big.Rat) string {
+	if r == nil {
+		return ""
+	}
+	return r.RatString()
+}
+
+func (sw *snapshotWriter) fill(ss *snapSchema, s *Schema) {
+	ss.Location = s.Location
+	if s.Draft != nil {
+		ss.Draft = s.Draft.version
+	}
+	ss.Meta = sw.ref(s.meta)
+	ss.Vocab = s.vocab
+	ss.DynamicAnchors = sw.refs(s.dynamicAnchors)
+
+	ss.Format, ss.AssertFormat = s.Format, s.format != nil
+	ss.Always = s.Always
+	ss.Ref = sw.ref(s.Ref)
+	ss.RecursiveAnchor = s.RecursiveAnchor
+	ss.RecursiveRef = sw.ref(s.RecursiveRef)
+	ss.DynamicAnchor = s.DynamicAnchor
+	ss.DynamicRef = sw.ref(s.DynamicRef)
+	ss.DynamicRefAnchor = s.dynamicRefAnchor
+	ss.Types = s.Types
+	ss.Constant = s.Constant
+	ss.Enum, ss.EnumError = s.Enum, s.enumError
+	ss.Not = sw.ref(s.Not)
+	ss.AllOf, ss.AnyOf, ss.OneOf = sw.refs(s.AllOf), sw.refs(s.AnyOf), sw.refs(s.OneOf)
+	ss.ExtendsSchema, ss.TypeIndex, ss.Disallow = s.extendsSchema, s.typeIndex, s.disallow
+	ss.If, ss.Then, ss.Else = sw.ref(s.If), sw.ref(s.Then), sw.ref(s.Else)
+
+	ss.MinProperties, ss.MaxProperties = s.MinProperties, s.MaxProperties
+	ss.Required = s.Required
+	ss.Properties = sw.refMap(s.Properties)
+	ss.PropertyNames = sw.ref(s.PropertyNames)
+	ss.RegexProperties = s.RegexProperties
+	if s.PatternProperties != nil {
+		ss.PatternProperties = make(map[string]int, len(s.PatternProperties))
+		for re, sch := range s.PatternProperties {
+			ss.PatternProperties[re.String()] = sw.ref(sch)
+		}
+	}
+	ss.AdditionalProperties = sw.boolOrRef(s.AdditionalProperties)
+	if s.Dependencies != nil {
+		ss.Dependencies = make(map[string]interface{}, len(s.Dependencies))
+		for pname, dep := range s.Dependencies {
+			switch dep := dep.(type) {
+			case *Schema:
+				ss.Dependencies[pname] = sw.ref(dep)
+			case []string:
+				ss.Dependencies[pname] = dep
+			}
+		}
+	}
+	ss.DependentRequired = s.DependentRequired
+	ss.DependentSchemas = sw.refMap(s.DependentSchemas)
+	ss.UnevaluatedProperties = sw.ref(s.UnevaluatedProperties)
+
+	ss.MinItems, ss.MaxItems = s.MinItems, s.MaxItems
+	ss.UniqueItems = s.UniqueItems
+	switch items := s.Items.(type) {
+	case *Schema:
+		ss.Items = sw.ref(items)
+	case []*Schema:
+		ss.Items = sw.refs(items)
+	}
+	ss.AdditionalItems = sw.boolOrRef(s.AdditionalItems)
+	ss.PrefixItems = sw.refs(s.PrefixItems)
+	ss.Items2020 = sw.ref(s.Items2020)
+	ss.Contains = sw.ref(s.Contains)
+	ss.ContainsEval = s.ContainsEval
+	ss.MinContains, ss.MaxContains = s.MinContains, s.MaxContains
+	ss.UnevaluatedItems = sw.ref(s.UnevaluatedItems)
+
+	ss.MinLength, ss.MaxLength = s.MinLength, s.MaxLength
+	if s.Pattern != nil {
+		ss.Pattern = s.Pattern.String()
+	}
+	ss.ContentEncoding, ss.Decode = s.ContentEncoding, s.decoder != nil
+	ss.ContentMediaType, ss.CheckMediaType = s.ContentMediaType, s.mediaType != nil
+	ss.ContentSchema = sw.ref(s.ContentSchema)
+
+	ss.Minimum, ss.ExclusiveMinimum = snapRat(s.Minimum), snapRat(s.ExclusiveMinimum)
+	ss.Maximum, ss.ExclusiveMaximum = snapRat(s.Maximum), snapRat(s.ExclusiveMaximum)
+	ss.MultipleOf = snapRat(s.MultipleOf)
+
+	ss.Title, ss.Description, ss.Default, ss.Comment = s.Title, s.Description, s.Default, s.Comment
+	ss.ReadOnly, ss.WriteOnly, ss.Examples, ss.Deprecated = s.ReadOnly, s.WriteOnly, s.Examples, s.Deprecated
+
+	for name := range s.Extensions {
+		ss.Extensions = append(ss.Extensions, name)
+	}
+	ss.ExtDoc = s.extDoc
+	ss.ExtSchemas, ss.ExtRefs = sw.refMap(s.extSchemas), sw.refMap(s.extRefs)
+}
+
+// LoadSnapshot loads the schemas written by WriteSnapshot, in the same
+// order they are passed to WriteSnapshot.
+//
+// Formats, decoders, mediaTypes and extensions are bound by name, looking
+// up c and then package globals. It is an error if any of them is not
+// registered. c.Memoize is honored; other compiler settings are captured
+// in snapshot.
+func (c *Compiler) LoadSnapshot(r io.Reader) ([]*Schema, error) {
+	decoder := json.NewDecoder(r)
+	decoder.UseNumber()
+	var snap snapshot
+	if err := decoder.Decode(&snap); err != nil {
+		return nil, fmt.Errorf("jsonschema: invalid snapshot: %v", err)
+	}
+	if snap.Version != snapshotVersion {
+		return nil, fmt.Errorf("jsonschema: unsupported snapshot version %d", snap.Version)
+	}
+
+	sl := &snapshotLoader{c: c, schemas: make([]*Schema, len(snap.Schemas))}
+	for i, ss := range snap.Schemas {
+		if ss.DraftMeta != 0 {
+			d := draftByVersion(ss.DraftMeta)
+			if d == nil {
+				return nil, fmt.Errorf("jsonschema: unsupported draft %d in snapshot", ss.DraftMeta)
+			}
+			sl.schemas[i] = d.meta
+		} else {
+			sl.schemas[i] = new(Schema)
+		}
+	}
+	for i, ss := range snap.Schemas {
+		if ss.DraftMeta == 0 {
+			if err := sl.fill(sl.schemas[i], ss); err != nil {
+				return nil, err
+			}
+		}
+	}
+	// extensions are compiled after all schemas are filled,
+	// because they may refer to other schemas
+	for i, ss := range snap.Schemas {
+		for _, name := range ss.Extensions {
+			if err := sl.compileExt(sl.schemas[i], name); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	roots := make([]*Schema, len(snap.Roots))
+	for i, ref := range snap.Roots {
+		s, err := sl.ref(ref)
+		if err != nil {
+			return nil, err
+		}
+		roots[i] = s
+	}
+	return roots, nil
+}
+
+type snapshotLoader struct {
+	c       *Compiler
+	schemas []*Schema
+}
+
+func (sl *snapshotLoader) ref(i int) (*Schema, error) {
+	if i < 0 || i > len(sl.schemas) {
+		return nil, fmt.Errorf("jsonschema: invalid schema reference %d in snapshot", i)
+	}
+	if i == 0 {
+		return nil, nil
+	}
+	return sl.schemas[i-1], nil
+}
+
+func (sl *snapshotLoader) refs(refs []int) ([]*Schema, error) {
+	if refs == nil {
+		return nil, nil
+	}
+	schemas := make([]*Schema, len(refs))
+	for i, ref := range refs {
+		s, err := sl.ref(ref)
+		if err != nil {
+			return nil, err
+		}
+		schemas[i] = s
+	}
+	return schemas, nil
+}
+
+func (sl *snapshotLoader) refMap(refs map[string]int) (map[string]*Schema, error) {
+	if refs == nil {
+		return nil, nil
+	}
+	schemas := make(map[string]*Schema, len(refs))
+	for k, ref := range refs {
+		s, err := sl.ref(ref)
+		if err != nil {
+			return nil, err
+		}
+		schemas[k] = s
+	}
+	return schemas, nil
+}
+
+// anyRef loads the reference decoded into interface{}.
+func (sl *snapshotLoader) anyRef(v interface{}) (*Schema, error) {
+	num, ok := v.(json.Number)
+	if !ok {
+		return nil, fmt.Errorf("jsonschema: invalid schema reference %v in snapshot", v)
+	}
+	i, err := num.Int64()
+	if err != nil {
+		return nil, fmt.Errorf("jsonschema: invalid schema reference %v in snapshot", v)
+	}
+	return sl.ref(int(i))
+}
+
+func (sl *snapshotLoader) boolOrRef(v interface{}) (interface{}, error) {
+	switch v := v.(type) {
+	case nil:
+		return nil, nil
+	case bool:
+		return v, nil
+	}
+	return sl.anyRef(v)
+}
+
+func parseSnapRat(s string) (*big.Rat, error) {
+	if s == "" {
+		return nil, nil
+	}
+	r, ok := new(big.Rat).SetString(s)
+	if !ok {
+		return nil, fmt.Errorf("jsonschema: invalid number %q in snapshot", s)
+	}
+	return r, nil
+}
+
+func (sl *snapshotLoader) fill(s *Schema, ss *snapSchema) error {
+	// errors of reference lookups are collected in err,
+	// to avoid checking each of them
+	var err error
+	ref := func(i int) *Schema {
+		sch, e := sl.ref(i)
+		if err == nil {
+			err = e
+		}
+		return sch
+	}
+	refs := func(refs []int) []*Schema {
+		schemas, e := sl.refs(refs)
+		if err == nil {
+			err = e
+		}
+		return schemas
+	}
+	refMap := func(refs map[string]int) map[string]*Schema {
+		schemas, e := sl.refMap(refs)
+		if err == nil {
+			err = e
+		}
+		return schemas
+	}
+	boolOrRef := func(v interface{}) interface{} {
+		v, e := sl.boolOrRef(v)
+		if err == nil {
+			err = e
+		}
+		return v
+	}
+	rat := func(str string) *big.Rat {
+		r, e := parseSnapRat(str)
+		if err == nil {
+			err = e
+		}
+		return r
+	}
+
+	s.Location = ss.Location
+	if ss.Draft != 0 {
+		if s.Draft = draftByVersion(ss.Draft); s.Draft == nil {
+			return fmt.Errorf("jsonschema: unsupported draft %d in snapshot", ss.Draft)
+		}
+	}
+	s.meta = ref(ss.Meta)
+	s.vocab = ss.Vocab
+	s.dynamicAnchors = refs(ss.DynamicAnchors)
+
+	s.Format = ss.Format
+	if ss.AssertFormat {
+		name := s.Draft.format(s.Format)
+		if format, ok := sl.c.Formats[name]; ok {
+			s.format = format
+		} else if format, ok := Formats[name]; ok {
+			s.format = format
+		} else {
+			return fmt.Errorf("jsonschema: format %q is not registered", s.Format)
+		}
+	}
+	s.Always = ss.Always
+	s.Ref = ref(ss.Ref)
+	s.RecursiveAnchor = ss.RecursiveAnchor
+	s.RecursiveRef = ref(ss.RecursiveRef)
+	s.DynamicAnchor = ss.DynamicAnchor
+	s.DynamicRef = ref(ss.DynamicRef)
+	s.dynamicRefAnchor = ss.DynamicRefAnchor
+	s.Types = ss.Types
+	s.Constant = ss.Constant
+	s.Enum, s.enumError = ss.Enum, ss.EnumError
+	if len(s.Enum) > smallArrayLen {
+		s.enumIndex = newValueIndex(s.Enum)
+	}
+	s.Not = ref(ss.Not)
+	s.AllOf, s.AnyOf, s.OneOf = refs(ss.AllOf), refs(ss.AnyOf), refs(ss.OneOf)
+	s.extendsSchema, s.typeIndex, s.disallow = ss.ExtendsSchema, ss.TypeIndex, ss.Disallow
+	s.If, s.Then, s.Else = ref(ss.If), ref(ss.Then), ref(ss.Else)
+
+	s.MinProperties, s.MaxProperties = ss.MinProperties, ss.MaxProperties
+	s.Required = ss.Required
+	s.Properties = refMap(ss.Properties)
+	s.PropertyNames = ref(ss.PropertyNames)
+	s.RegexProperties = ss.RegexProperties
+	if ss.PatternProperties != nil {
+		s.PatternProperties = make(map[*regexp.Regexp]*Schema, len(ss.PatternProperties))
+		for pattern, i := range ss.PatternProperties {
+			re, err := regexp.Compile(pattern)
+			if err != nil {
+				return fmt.Errorf("jsonschema: invalid pattern %q in snapshot: %v", pattern, err)
+			}
+			s.PatternProperties[re] = ref(i)
+		}
+	}
+	s.AdditionalProperties = boolOrRef(ss.AdditionalProperties)
+	if ss.Dependencies != nil {
+		s.Dependencies = make(map[string]interface{}, len(ss.Dependencies))
+		for pname, dep := range ss.Dependencies {
+			if arr, ok := dep.([]interface{}); ok {
+				s.Dependencies[pname] = toStrings(arr)
+			} else {
+				sch, e := sl.anyRef(dep)
+				if e != nil {
+					return e
+				}
+				s.Dependencies[pname] = sch
+			}
+		}
+	}
+	s.DependentRequired = ss.DependentRequired
+	s.DependentSchemas = refMap(ss.DependentSchemas)
+	s.UnevaluatedProperties = ref(ss.UnevaluatedProperties)
+
+	s.MinItems, s.MaxItems = ss.MinItems, ss.MaxItems
+	s.UniqueItems = ss.UniqueItems
+	switch items := ss.Items.(type) {
+	case nil:
+	case []interface{}:
+		schemas := make([]*Schema, len(items))
+		for i, item := range items {
+			sch, e := sl.anyRef(item)
+			if e != nil {
+				return e
+			}
+			schemas[i] = sch
+		}
+		s.Items = schemas
+	default:
+		sch, e := sl.anyRef(items)
+		if e != nil {
+			return e
+		}
+		s.Items = sch
+	}
+	s.AdditionalItems = boolOrRef(ss.AdditionalItems)
+	s.PrefixItems = refs(ss.PrefixItems)
+	s.Items2020 = ref(ss.Items2020)
+	s.Contains = ref(ss.Contains)
+	s.ContainsEval = ss.ContainsEval
+	s.MinContains, s.MaxContains = ss.MinContains, ss.MaxContains
+	s.UnevaluatedItems = ref(ss.UnevaluatedItems)
+
+	s.MinLength, s.MaxLength = ss.MinLength, ss.MaxLength
+	if ss.Pattern != "" {
+		re, err := regexp.Compile(ss.Pattern)
+		if err != nil {
+			return fmt.Errorf("jsonschema: invalid pattern %q in snapshot: %v", ss.Pattern, err)
+		}
+		s.Pattern = re
+	}
+	s.ContentEncoding = ss.ContentEncoding
+	if ss.Decode {
+		if decoder, ok := sl.c.Decoders[s.ContentEncoding]; ok {
+			s.decoder = decoder
+		} else if decoder, ok := Decoders[s.ContentEncoding]; ok {
+			s.decoder = decoder
+		} else {
+			return fmt.Errorf("jsonschema: decoder %q is not registered", s.ContentEncoding)
+		}
+	}
+	s.ContentMediaType = ss.ContentMediaType
+	if ss.CheckMediaType {
+		if mediaType, ok := sl.c.MediaTypes[s.ContentMediaType]; ok {
+			s.mediaType = mediaType
+		} else if mediaType, ok := MediaTypes[s.ContentMediaType]; ok {
+			s.mediaType = mediaType
+		} else {
+			return fmt.Errorf("jsonschema: mediaType %q is not registered", s.ContentMediaType)
+		}
+	}
+	s.ContentSchema = ref(ss.ContentSchema)
+
+	s.Minimum, s.ExclusiveMinimum = rat(ss.Minimum), rat(ss.ExclusiveMinimum)
+	s.Maximum, s.ExclusiveMaximum = rat(ss.Maximum), rat(ss.ExclusiveMaximum)
+	s.MultipleOf = rat(ss.MultipleOf)
+	s.minimum, s.exclusiveMinimum = limitDecimal(s.Minimum), limitDecimal(s.ExclusiveMinimum)
+	s.maximum, s.exclusiveMaximum = limitDecimal(s.Maximum), limitDecimal(s.ExclusiveMaximum)
+	s.multipleOf = limitDecimal(s.MultipleOf)
+
+	s.Title, s.Description, s.Default, s.Comment = ss.Title, ss.Description, ss.Default, ss.Comment
+	s.ReadOnly, s.WriteOnly, s.Examples, s.Deprecated = ss.ReadOnly, ss.WriteOnly, ss.Examples, ss.Deprecated
+
+	s.extDoc = ss.ExtDoc
+	s.extSchemas, s.extRefs = refMap(ss.ExtSchemas), refMap(ss.ExtRefs)
+
+	if sl.c.Memoize {
+		s.memo = new(memoStats)
+	}
+	return err
+}
+
+func (sl *snapshotLoader) compileExt(s *Schema, name string) error {
+	ext, ok := sl.c.extensions[name]
+	if !ok {
+		return fmt.Errorf("jsonschema: extension %q is not registered", name)
+	}
+	es, err := ext.compiler.Compile(CompilerContext{sch: s}, s.extDoc)
+	if err != nil {
+		return err
+	}
+	if es != nil {
+		if s.Extensions == nil {
+			s.Extensions = make(map[string]ExtSchema)
+		}
+		s.Extensions[name] = es
+	}
+	return nil
+}
+
+// extSchema returns the schema compiled by extension, when
+// loading from snapshot.
+func (s *Schema) extSchema(schemas map[string]*Schema, key string) (*Schema, error) {
+	if sch, ok := schemas[key]; ok {
+		return sch, nil
+	}
+	return nil, fmt.Errorf("jsonschema: %s not found in snapshot of %s", key, s.Location)
+}
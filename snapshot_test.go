@@ -0,0 +1,146 @@
+package jsonschema_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/santhosh-tekuri/jsonschema/v5"
+)
+
+// TestSnapshotConformance checks that schemas loaded from snapshot
+// report same errors as the compiled schemas for all test instances
+// in JSON-Schema-Test-Suite.
+func TestSnapshotConformance(t *testing.T) {
+	var schemas []*jsonschema.Schema
+	var groups []testGroup
+	var paths []string
+	walkSuite(t, func(path string, group testGroup, sch *jsonschema.Schema) {
+		schemas = append(schemas, sch)
+		groups = append(groups, group)
+		paths = append(paths, path)
+	})
+
+	var buf bytes.Buffer
+	if err := jsonschema.WriteSnapshot(&buf, schemas...); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := jsonschema.NewCompiler().LoadSnapshot(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(loaded) != len(schemas) {
+		t.Fatalf("got %d schemas, want %d", len(loaded), len(schemas))
+	}
+	for i, group := range groups {
+		for _, test := range group.Tests {
+			v := decodeString(t, string(test.Data))
+			want := normalizeErr(schemas[i].Validate(v))
+			if got := normalizeErr(loaded[i].Validate(v)); got != want {
+				t.Errorf("%s: %s: %s:\n got: %s\nwant: %s", paths[i], group.Description, test.Description, got, want)
+			}
+		}
+	}
+}
+
+// andThenCompiler compiles "andThen" keyword, whose value is a schema
+// applied on the same instance.
+type andThenCompiler struct{}
+
+func (andThenCompiler) Compile(ctx jsonschema.CompilerContext, m map[string]interface{}) (jsonschema.ExtSchema, error) {
+	if _, ok := m["andThen"]; ok {
+		sch, err := ctx.Compile("andThen", true)
+		return andThenSchema{sch}, err
+	}
+	return nil, nil
+}
+
+type andThenSchema struct {
+	sch *jsonschema.Schema
+}
+
+func (s andThenSchema) Validate(ctx jsonschema.ValidationContext, v interface{}) error {
+	return ctx.Validate(s.sch, "andThen", v, "")
+}
+
+func TestSnapshotBindings(t *testing.T) {
+	odd := func(v interface{}) bool {
+		s, ok := v.(string)
+		return !ok || len(s)%2 == 1
+	}
+	newCompiler := func() *jsonschema.Compiler {
+		c := jsonschema.NewCompiler()
+		c.AssertFormat = true
+		return c
+	}
+
+	c := newCompiler()
+	c.Formats["odd-length"] = odd
+	c.RegisterExtension("powerOf", powerOfMeta, powerOfCompiler{})
+	c.RegisterExtension("andThen", nil, andThenCompiler{})
+	schema := `{
+		"properties": {
+			"n": {"powerOf": 10},
+			"s": {"andThen": {"format": "odd-length", "andThen": {"pattern": "^a"}}}
+		}
+	}`
+	if err := c.AddResource("schema.json", strings.NewReader(schema)); err != nil {
+		t.Fatal(err)
+	}
+	sch, err := c.Compile("schema.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if err := jsonschema.WriteSnapshot(&buf, sch); err != nil {
+		t.Fatal(err)
+	}
+	snapshot := buf.String()
+
+	t.Run("unregistered", func(t *testing.T) {
+		c := newCompiler()
+		c.RegisterExtension("powerOf", powerOfMeta, powerOfCompiler{})
+		c.RegisterExtension("andThen", nil, andThenCompiler{})
+		if _, err := c.LoadSnapshot(strings.NewReader(snapshot)); err == nil {
+			t.Fatal("error expected for unregistered format")
+		}
+
+		c = newCompiler()
+		c.Formats["odd-length"] = odd
+		c.RegisterExtension("andThen", nil, andThenCompiler{})
+		if _, err := c.LoadSnapshot(strings.NewReader(snapshot)); err == nil {
+			t.Fatal("error expected for unregistered extension")
+		}
+	})
+
+	t.Run("registered", func(t *testing.T) {
+		c := newCompiler()
+		c.Formats["odd-length"] = odd
+		c.RegisterExtension("powerOf", powerOfMeta, powerOfCompiler{})
+		c.RegisterExtension("andThen", nil, andThenCompiler{})
+		loaded, err := c.LoadSnapshot(strings.NewReader(snapshot))
+		if err != nil {
+			t.Fatal(err)
+		}
+		tests := []struct {
+			instance string
+			valid    bool
+		}{
+			{`{"n": 100, "s": "abc"}`, true},
+			{`{"n": 110}`, false},
+			{`{"s": "ab"}`, false},
+			{`{"s": "bcd"}`, false},
+		}
+		for _, test := range tests {
+			v := decodeString(t, test.instance)
+			want := normalizeErr(sch.Validate(v))
+			got := normalizeErr(loaded[0].Validate(v))
+			if got != want {
+				t.Errorf("%s:\n got: %s\nwant: %s", test.instance, got, want)
+			}
+			if valid := got == "valid"; valid != test.valid {
+				t.Errorf("%s: valid: got %v, want %v", test.instance, valid, test.valid)
+			}
+		}
+	})
+}
@@ -0,0 +1,379 @@
+package jsonschema
+
+import (
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// upgrader converts schema documents of older drafts to draft 2020-12.
+type upgrader struct {
+	draft *Draft
+
+	// ref, if not nil, is called with base url and value of each
+	// $ref, $recursiveRef and $dynamicRef. It returns the value to use.
+	ref func(base, ref string) string
+}
+
+// converted is subschema, which is already converted. doc is its
+// source document. It is used by dereferencer, which inlines the
+// converted targets into the schema being converted.
+type converted struct {
+	out, doc interface{}
+}
+
+// recursiveAnchor is the $dynamicAnchor, $recursiveAnchor is converted to.
+const recursiveAnchor = "recursive"
+
+// doc returns copy of doc converted to draft 2020-12.
+func (u *upgrader) doc(doc interface{}, base string) interface{} {
+	m, _ := doc.(map[string]interface{})
+	return u.schema(doc, base, m["$recursiveAnchor"] == true)
+}
+
+// schema converts schema v. recursive tells whether the
+// resource containing v has "$recursiveAnchor": true.
+func (u *upgrader) schema(v interface{}, base string, recursive bool) interface{} {
+	if c, ok := v.(converted); ok {
+		return c.out
+	}
+	m, ok := v.(map[string]interface{})
+	if !ok {
+		return cloneJSON(v)
+	}
+	d := u.draft
+	if id, err := d.resolveID(base, m); err == nil && id != "" {
+		base = id
+		recursive = m["$recursiveAnchor"] == true
+	}
+
+	ref := func(s string) string {
+		if u.ref == nil {
+			return s
+		}
+		return u.ref(base, s)
+	}
+
+	out := make(map[string]interface{})
+	var defs map[string]interface{}
+	_, hasRef := m["$ref"]
+	items, itemsArray := m["items"].([]interface{})
+	for kw, v := range m {
+		if hasRef && d.version <= 7 && kw != "$ref" && kw != "definitions" {
+			// siblings of $ref are ignored before draft2019
+			continue
+		}
+		switch {
+		case kw == d.id && d.version <= 7:
+			id, _ := v.(string)
+			if i := strings.IndexByte(id, '#'); i != -1 {
+				if anchor := id[i+1:]; anchor != "" {
+					out["$anchor"] = anchor
+				}
+				id = id[:i]
+			}
+			if id != "" {
+				out["$id"] = id
+			}
+		case kw == "$ref" || kw == "$dynamicRef":
+			if s, ok := v.(string); ok {
+				out[kw] = ref(s)
+			} else {
+				out[kw] = v
+			}
+		case kw == "$recursiveRef" && d.version == 2019:
+			s, _ := v.(string)
+			if recursive {
+				out["$dynamicRef"] = ref(s) + recursiveAnchor
+			} else {
+				out["$ref"] = ref(s)
+			}
+		case kw == "$recursiveAnchor" && d.version == 2019:
+			if v == true {
+				out["$dynamicAnchor"] = recursiveAnchor
+			}
+		case kw == "definitions" || kw == "$defs":
+			if defs == nil {
+				defs = make(map[string]interface{})
+			}
+			if m, ok := u.props(v, base, recursive).(map[string]interface{}); ok {
+				for name, sch := range m {
+					defs[name] = sch
+				}
+			}
+		case kw == "items" && itemsArray && d.version < 2020:
+			prefix := make([]interface{}, len(items))
+			for i, item := range items {
+				prefix[i] = u.schema(item, base, recursive)
+			}
+			if len(prefix) > 0 { // draft3 allows empty array
+				out["prefixItems"] = prefix
+			}
+			if additional, ok := m["additionalItems"]; ok {
+				out["items"] = u.schema(additional, base, recursive)
+			}
+		case kw == "additionalItems" && d.version < 2020:
+			// converted along with items
+		case kw == "dependencies" && d.version <= 7:
+			deps, ok := v.(map[string]interface{})
+			if !ok {
+				break
+			}
+			required := make(map[string]interface{})
+			schemas := make(map[string]interface{})
+			for name, dep := range deps {
+				switch dep := dep.(type) {
+				case string: // draft3
+					required[name] = []interface{}{dep}
+				case []interface{}:
+					required[name] = cloneJSON(dep)
+				default:
+					schemas[name] = u.schema(dep, base, recursive)
+				}
+			}
+			if len(required) > 0 {
+				out["dependentRequired"] = required
+			}
+			if len(schemas) > 0 {
+				out["dependentSchemas"] = schemas
+			}
+		case (kw == "exclusiveMaximum" || kw == "exclusiveMinimum") && d.version <= 4:
+			limit := "maximum"
+			if kw == "exclusiveMinimum" {
+				limit = "minimum"
+			}
+			if v == true {
+				if n, ok := m[limit]; ok {
+					out[kw] = n
+				}
+			}
+		case kw == "maximum" && d.version <= 4:
+			if m["exclusiveMaximum"] != true {
+				out[kw] = v
+			}
+		case kw == "minimum" && d.version <= 4:
+			if m["exclusiveMinimum"] != true {
+				out[kw] = v
+			}
+		case kw == "extends" && d.version == 3:
+			if _, ok := v.([]interface{}); ok {
+				out["allOf"] = u.position(v, item, base, recursive)
+			} else {
+				out["allOf"] = []interface{}{u.schema(v, base, recursive)}
+			}
+		case kw == "divisibleBy" && d.version == 3:
+			out["multipleOf"] = v
+		case kw == "required" && d.version == 3:
+			// converted along with properties of parent
+		case kw == "type" && d.version == 3:
+			for k, v := range u.union(v, base, recursive) {
+				out[k] = v
+			}
+		case kw == "disallow" && d.version == 3:
+			out["not"] = u.union(v, base, recursive)
+		case kw == "$schema":
+			if s, ok := v.(string); ok && findDraft(s) == d {
+				out[kw] = Draft2020.URL()
+			} else {
+				out[kw] = v
+			}
+		default:
+			pos, ok := d.subschemas[kw]
+			if !ok {
+				out[kw] = cloneJSON(v)
+				break
+			}
+			out[kw] = u.position(v, pos, base, recursive)
+		}
+	}
+	if defs != nil {
+		out["$defs"] = defs
+	}
+	if props, ok := m["properties"].(map[string]interface{}); ok && d.version == 3 && !hasRef {
+		var required []interface{}
+		for _, name := range sortedKeys(props) {
+			p := props[name]
+			if c, ok := p.(converted); ok {
+				p = c.doc
+			}
+			if p, ok := p.(map[string]interface{}); ok && p["required"] == true {
+				required = append(required, name)
+			}
+		}
+		if len(required) > 0 {
+			out["required"] = required
+		}
+	}
+	return out
+}
+
+// union converts draft3 type union v, into keywords of equivalent schema.
+func (u *upgrader) union(v interface{}, base string, recursive bool) map[string]interface{} {
+	items, ok := v.([]interface{})
+	if !ok {
+		items = []interface{}{v}
+	}
+	var types, schemas []interface{}
+	for _, item := range items {
+		switch item := item.(type) {
+		case string:
+			if item == "any" {
+				return map[string]interface{}{}
+			}
+			types = append(types, item)
+		default:
+			schemas = append(schemas, u.schema(item, base, recursive))
+		}
+	}
+	if len(schemas) == 0 {
+		if _, ok := v.(string); ok {
+			return map[string]interface{}{"type": v}
+		}
+		return map[string]interface{}{"type": types}
+	}
+	if len(types) > 0 {
+		schemas = append([]interface{}{map[string]interface{}{"type": types}}, schemas...)
+	}
+	return map[string]interface{}{"anyOf": schemas}
+}
+
+// position converts the subschemas in v at given position.
+func (u *upgrader) position(v interface{}, pos position, base string, recursive bool) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		if pos&self != 0 {
+			return u.schema(v, base, recursive)
+		}
+		if pos&prop != 0 {
+			return u.props(v, base, recursive)
+		}
+	case []interface{}:
+		if pos&item != 0 {
+			arr := make([]interface{}, len(v))
+			for i, item := range v {
+				arr[i] = u.schema(item, base, recursive)
+			}
+			return arr
+		}
+	case bool:
+		if pos&self != 0 {
+			return v
+		}
+	case converted:
+		return v.out
+	}
+	return cloneJSON(v)
+}
+
+func (u *upgrader) props(v interface{}, base string, recursive bool) interface{} {
+	m, ok := v.(map[string]interface{})
+	if !ok {
+		return cloneJSON(v)
+	}
+	out := make(map[string]interface{}, len(m))
+	for name, sch := range m {
+		out[name] = u.schema(sch, base, recursive)
+	}
+	return out
+}
+
+// ptr translates json-pointer ptr into doc, to the json-pointer
+// of same location in the converted doc.
+func (u *upgrader) ptr(doc interface{}, ptr string) string {
+	if ptr == "" {
+		return ""
+	}
+	d := u.draft
+	tokens := strings.Split(ptr[1:], "/")
+	var out []string
+	v := doc
+	for i := 0; i < len(tokens); i++ {
+		m, ok := v.(map[string]interface{})
+		if !ok {
+			return "/" + strings.Join(append(out, tokens[i:]...), "/")
+		}
+		kw := tokens[i]
+		pos, ok := d.subschemas[kw]
+		if !ok {
+			return "/" + strings.Join(append(out, tokens[i:]...), "/")
+		}
+		v = m[kw]
+		_, itemsArray := m["items"].([]interface{})
+		switch {
+		case kw == "definitions":
+			out = append(out, "$defs")
+			pos = prop
+		case kw == "items" && itemsArray && d.version < 2020:
+			out = append(out, "prefixItems")
+			pos = item
+		case kw == "additionalItems" && d.version < 2020:
+			out = append(out, "items")
+			pos = self
+		case kw == "dependencies" && d.version <= 7:
+			out = append(out, "dependentSchemas")
+			pos = prop
+		case kw == "extends" && d.version == 3:
+			out = append(out, "allOf")
+			if _, ok := v.([]interface{}); ok {
+				pos = item
+			} else {
+				out = append(out, "0")
+				pos = self
+			}
+		default:
+			out = append(out, kw)
+			if _, ok := v.([]interface{}); ok {
+				pos = item
+			} else if pos&prop != 0 {
+				pos = prop
+			} else {
+				pos = self
+			}
+		}
+		if pos == self || i+1 == len(tokens) {
+			continue
+		}
+		i++
+		out = append(out, tokens[i])
+		switch vv := v.(type) {
+		case map[string]interface{}:
+			v = vv[unescapeToken(tokens[i])]
+		case []interface{}:
+			index, err := strconv.Atoi(tokens[i])
+			if err != nil || index < 0 || index >= len(vv) {
+				return "/" + strings.Join(append(out, tokens[i+1:]...), "/")
+			}
+			v = vv[index]
+		}
+	}
+	return "/" + strings.Join(out, "/")
+}
+
+func unescapeToken(token string) string {
+	token = strings.Replace(token, "~1", "/", -1)
+	token = strings.Replace(token, "~0", "~", -1)
+	if s, err := url.PathUnescape(token); err == nil {
+		token = s
+	}
+	return token
+}
+
+// cloneJSON returns deep copy of json value v.
+func cloneJSON(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		m := make(map[string]interface{}, len(v))
+		for k, val := range v {
+			m[k] = cloneJSON(val)
+		}
+		return m
+	case []interface{}:
+		arr := make([]interface{}, len(v))
+		for i, item := range v {
+			arr[i] = cloneJSON(item)
+		}
+		return arr
+	default:
+		return v
+	}
+}
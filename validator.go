@@ -0,0 +1,362 @@
+package jsonschema
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// validator holds the scratch state of a single Schema.Validate call.
+//
+// validators are pooled, so that the dynamic scope and the buffers used
+// for tracking evaluated properties/items are reused across calls.
+type validator struct {
+	vloc  string   // instance location of root value
+	scope []*frame // dynamic scope. last frame is the one being evaluated
+	quiet bool     // errors are not reported, only success/failure matters
+
+	// error reported in quiet mode. it is reset for each validation,
+	// so that changes to it do not affect other validations.
+	errQuiet ValidationError
+
+	// scratch buffers for uniqueItems
+	hashes []uint64
+	seen   map[uint64]int
+
+	// memoization of subschema results. enabled only if stats is non-nil
+	stats         *memoStats
+	memo          map[memoKey]memoEntry
+	lookups, hits uint64
+}
+
+var validatorPool = sync.Pool{
+	New: func() interface{} {
+		return &validator{scope: make([]*frame, 0, 16)}
+	},
+}
+
+func getValidator(vloc string) *validator {
+	vd := validatorPool.Get().(*validator)
+	vd.vloc, vd.quiet = vloc, false
+	vd.errQuiet = ValidationError{Message: "validation failed", quiet: true}
+	vd.scope = vd.scope[:0]
+	return vd
+}
+
+func putValidator(vd *validator) {
+	if vd.stats != nil {
+		vd.stopMemo()
+	}
+	for _, f := range vd.scope[:cap(vd.scope)] {
+		if f != nil {
+			// do not retain instance and errors
+			f.v = nil
+			errs := f.errs[:cap(f.errs)]
+			for i := range errs {
+				errs[i] = nil
+			}
+		}
+	}
+	validatorPool.Put(vd)
+}
+
+// frame is an entry in dynamic scope. It captures the schema being applied,
+// the instance value it is applied on, and how it is reached from the parent
+// frame. Locations are computed from frames only when an error is reported.
+type frame struct {
+	sch     *Schema
+	v       interface{}
+	kw      string // keyword in parent schema which applied sch. empty for root
+	ktok    token  // token following kw. for example property name in properties
+	vtok    token  // token of v in parent instance. empty if inplace
+	inplace bool   // whether v is same as that of parent frame
+	discard bool   // true when dynamic scope is left. used by "if"
+	track   bool   // whether evaluated properties/items are tracked
+	eval    evaluated
+	errs    []error // scratch buffer to collect errors of sch
+
+	// fingerprint of dynamic scope. computed only if memoization is enabled
+	recRoot *Schema
+	dynFP   uint64
+}
+
+// schemaPath returns relative-json-pointer to sch from parent schema.
+// It is empty, if sch is at keyword location of parent, like the type
+// names of draft3 "type".
+func (f *frame) schemaPath() string {
+	return joinToken(f.kw, f.ktok)
+}
+
+// token is json-pointer token. It avoids allocating strings for property
+// names and array indexes, until the location is actually needed.
+//
+// zero value is empty token.
+type token struct {
+	kind tokenKind
+	s    string // property name if tokProp, escaped token if tokRaw
+	i    int    // array index if tokIndex
+}
+
+type tokenKind uint8
+
+const (
+	tokRaw tokenKind = iota
+	tokProp
+	tokIndex
+)
+
+func propToken(name string) token { return token{kind: tokProp, s: name} }
+func indexToken(i int) token      { return token{kind: tokIndex, i: i} }
+func rawToken(s string) token     { return token{kind: tokRaw, s: s} }
+
+func joinToken(path string, t token) string {
+	if tok := t.String(); tok != "" {
+		return path + "/" + tok
+	}
+	return path
+}
+
+func (t token) String() string {
+	switch t.kind {
+	case tokIndex:
+		return strconv.Itoa(t.i)
+	case tokProp:
+		return escape(t.s)
+	default:
+		return t.s
+	}
+}
+
+// push adds new frame to the dynamic scope. For inplace frames, it panics
+// with InfiniteLoopError if sch is already applied on same instance.
+func (vd *validator) push(sch *Schema, kw string, ktok token, v interface{}, vtok token, inplace bool) *frame {
+	if inplace {
+		vd.checkLoop(sch, kw, ktok)
+	}
+	n := len(vd.scope)
+	if n < cap(vd.scope) {
+		vd.scope = vd.scope[:n+1]
+	} else {
+		vd.scope = append(vd.scope, nil)
+	}
+	f := vd.scope[n]
+	if f == nil {
+		f = new(frame)
+		vd.scope[n] = f
+	}
+	f.sch, f.v = sch, v
+	f.kw, f.ktok, f.vtok = kw, ktok, vtok
+	f.inplace, f.discard = inplace, false
+	f.track = false
+	if vd.stats != nil {
+		vd.fingerprint(f)
+	}
+	return f
+}
+
+func (vd *validator) pop() {
+	vd.scope = vd.scope[:len(vd.scope)-1]
+}
+
+// checkLoop panics with InfiniteLoopError, if sch is already
+// applied in current dynamic scope on same instance value.
+func (vd *validator) checkLoop(sch *Schema, kw string, ktok token) {
+	i := len(vd.scope) - 1
+	for ; i >= 0; i-- {
+		if vd.scope[i].sch == sch {
+			break
+		}
+		if !vd.scope[i].inplace {
+			return
+		}
+	}
+	if i < 0 {
+		return
+	}
+	for i > 0 && vd.scope[i].inplace {
+		i--
+	}
+	path := vd.scope[i].sch.Location
+	for _, f := range vd.scope[i+1:] {
+		if p := f.schemaPath(); p != "" {
+			path += "/" + p
+		}
+	}
+	if p := joinToken(kw, ktok); p != "" {
+		path += "/" + p
+	}
+	panic(InfiniteLoopError(path))
+}
+
+// validate applies sch on v, which is child of the current instance value.
+func (vd *validator) validate(sch *Schema, kw string, ktok token, v interface{}, vtok token) error {
+	f := vd.push(sch, kw, ktok, v, vtok, false)
+	err := vd.apply(sch, f)
+	vd.pop()
+	return err
+}
+
+// validateInplace applies sch on the instance value of frame f.
+// properties/items evaluated by sch are reported to f, if successful.
+func (vd *validator) validateInplace(f *frame, sch *Schema, kw string, ktok token) error {
+	sf := vd.push(sch, kw, ktok, f.v, token{}, true)
+	sf.track = f.track
+	err := vd.apply(sch, sf)
+	if err == nil && f.track {
+		f.eval.merge(&sf.eval)
+	}
+	vd.pop()
+	return err
+}
+
+// duplicates returns indexes of first pair of equal items in arr.
+// see duplicates function for details.
+func (vd *validator) duplicates(arr []interface{}) (int, int) {
+	if vd.seen == nil {
+		vd.seen = make(map[uint64]int)
+	}
+	i, j, hashes := duplicates(arr, vd.hashes, vd.seen)
+	vd.hashes = hashes[:0]
+	for h := range vd.seen {
+		delete(vd.seen, h)
+	}
+	return i, j
+}
+
+// validateQuiet is same as validateInplace, but errors are not reported.
+// It is used when only the success/failure of sch is required.
+func (vd *validator) validateQuiet(f *frame, sch *Schema, kw string, ktok token) error {
+	quiet := vd.quiet
+	vd.quiet = true
+	err := vd.validateInplace(f, sch, kw, ktok)
+	vd.quiet = quiet
+	return err
+}
+
+// quietError returns the error reported in quiet mode, to avoid
+// constructing errors which are discarded anyway.
+func (vd *validator) quietError() *ValidationError {
+	return &vd.errQuiet
+}
+
+// error creates ValidationError for the keyword at keywordPath
+// in schema of current frame.
+func (vd *validator) error(keywordPath string, format string, a ...interface{}) *ValidationError {
+	if vd.quiet {
+		return vd.quietError()
+	}
+	f := vd.scope[len(vd.scope)-1]
+	return &ValidationError{
+		KeywordLocation:         vd.keywordLocation(keywordPath),
+		AbsoluteKeywordLocation: joinPtr(f.sch.Location, keywordPath),
+		InstanceLocation:        vd.instanceLocation(),
+		Message:                 fmt.Sprintf(format, a...),
+	}
+}
+
+func (vd *validator) keywordLocation(path string) string {
+	var sb strings.Builder
+	for _, f := range vd.scope[1:] {
+		if p := f.schemaPath(); p != "" {
+			sb.WriteByte('/')
+			sb.WriteString(p)
+		}
+	}
+	if path != "" {
+		sb.WriteByte('/')
+		sb.WriteString(path)
+	}
+	return sb.String()
+}
+
+func (vd *validator) instanceLocation() string {
+	var sb strings.Builder
+	sb.WriteString(vd.vloc)
+	for _, f := range vd.scope[1:] {
+		if !f.inplace {
+			if tok := f.vtok.String(); tok != "" {
+				sb.WriteByte('/')
+				sb.WriteString(tok)
+			}
+		}
+	}
+	return sb.String()
+}
+
+// evaluated captures properties/items of instance that are evaluated
+// by a schema and its inplace applicators. This is required only to
+// implement unevaluatedProperties and unevaluatedItems keywords.
+type evaluated struct {
+	allProps bool
+	props    map[string]struct{}
+	allItems bool
+	items    bitset
+}
+
+func (e *evaluated) reset() {
+	e.allProps, e.allItems = false, false
+	for pname := range e.props {
+		delete(e.props, pname)
+	}
+	e.items = e.items[:0]
+}
+
+func (e *evaluated) addProp(pname string) {
+	if e.props == nil {
+		e.props = make(map[string]struct{})
+	}
+	e.props[pname] = struct{}{}
+}
+
+func (e *evaluated) hasProp(pname string) bool {
+	if e.allProps {
+		return true
+	}
+	_, ok := e.props[pname]
+	return ok
+}
+
+func (e *evaluated) hasItem(i int) bool {
+	return e.allItems || e.items.has(i)
+}
+
+func (e *evaluated) merge(o *evaluated) {
+	if o.allProps {
+		e.allProps = true
+	} else if !e.allProps {
+		for pname := range o.props {
+			e.addProp(pname)
+		}
+	}
+	if o.allItems {
+		e.allItems = true
+	} else if !e.allItems {
+		e.items.or(o.items)
+	}
+}
+
+// bitset is a set of non-negative integers.
+type bitset []uint64
+
+func (b *bitset) set(i int) {
+	w := i / 64
+	for len(*b) <= w {
+		*b = append(*b, 0)
+	}
+	(*b)[w] |= 1 << uint(i%64)
+}
+
+func (b bitset) has(i int) bool {
+	w := i / 64
+	return w < len(b) && b[w]&(1<<uint(i%64)) != 0
+}
+
+func (b *bitset) or(o bitset) {
+	for len(*b) < len(o) {
+		*b = append(*b, 0)
+	}
+	for i, w := range o {
+		(*b)[i] |= w
+	}
+}
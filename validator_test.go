@@ -0,0 +1,168 @@
+package jsonschema_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/santhosh-tekuri/jsonschema/v5"
+)
+
+var benchSchemas = map[string]string{
+	"object": `{
+		"type": "object",
+		"required": ["id", "name", "tags"],
+		"properties": {
+			"id": {"type": "string", "minLength": 1},
+			"name": {"type": "string", "maxLength": 64},
+			"tags": {"type": "array", "items": {"type": "string"}},
+			"meta": {"$ref": "#/$defs/meta"}
+		},
+		"patternProperties": {"^x-": true},
+		"additionalProperties": false,
+		"$defs": {
+			"meta": {
+				"type": "object",
+				"properties": {"created": {"type": "string"}, "deleted": {"type": "boolean"}},
+				"additionalProperties": {"type": "string"}
+			}
+		}
+	}`,
+	"combinators": `{
+		"allOf": [{"type": "object"}, {"required": ["kind"]}],
+		"oneOf": [
+			{"properties": {"kind": {"const": "a"}, "a": {"type": "string"}}},
+			{"properties": {"kind": {"const": "b"}, "b": {"type": "boolean"}}}
+		],
+		"if": {"properties": {"kind": {"const": "a"}}},
+		"then": {"required": ["a"]},
+		"else": {"required": ["b"]}
+	}`,
+	"numbers": `{
+		"type": "array",
+		"items": {
+			"type": "object",
+			"properties": {
+				"count": {"type": "integer", "minimum": 0, "maximum": 1000000},
+				"ratio": {"type": "number", "exclusiveMinimum": 0, "exclusiveMaximum": 1},
+				"amount": {"type": "number", "multipleOf": 0.01},
+				"level": {"enum": [1, 2, 3, 4.5]}
+			}
+		}
+	}`,
+	"unevaluated": `{
+		"type": "object",
+		"properties": {"kind": {"type": "string"}, "list": {"$ref": "#/$defs/list"}},
+		"anyOf": [
+			{"properties": {"a": {"type": "string"}}},
+			{"properties": {"b": {"type": "boolean"}}}
+		],
+		"unevaluatedProperties": false,
+		"$defs": {
+			"list": {"prefixItems": [{"type": "string"}], "contains": {"type": "string"}, "unevaluatedItems": false}
+		}
+	}`,
+}
+
+var benchInstances = map[string]string{
+	"object":      `{"id": "1", "name": "gopher", "tags": ["a", "b", "c"], "meta": {"created": "now", "deleted": false, "owner": "me"}, "x-trace": 1}`,
+	"combinators": `{"kind": "a", "a": "value"}`,
+	"numbers": `[
+		{"count": 10, "ratio": 0.25, "amount": 12.34, "level": 1},
+		{"count": 999999, "ratio": 0.5, "amount": 100, "level": 4.50},
+		{"count": 1.0e3, "ratio": 1e-3, "amount": 0.07, "level": 3}
+	]`,
+	"unevaluated": `{"kind": "a", "a": "value", "list": ["x", "y", "z"]}`,
+}
+
+func compileBenchSchema(tb testing.TB, name string) (*jsonschema.Schema, interface{}) {
+	tb.Helper()
+	c := jsonschema.NewCompiler()
+	if err := c.AddResource("schema.json", strings.NewReader(benchSchemas[name])); err != nil {
+		tb.Fatal(err)
+	}
+	sch, err := c.Compile("schema.json")
+	if err != nil {
+		tb.Fatalf("%#v", err)
+	}
+	decoder := json.NewDecoder(strings.NewReader(benchInstances[name]))
+	decoder.UseNumber()
+	var v interface{}
+	if err := decoder.Decode(&v); err != nil {
+		tb.Fatal(err)
+	}
+	if err := sch.Validate(v); err != nil {
+		tb.Fatalf("%#v", err)
+	}
+	return sch, v
+}
+
+func TestValidateAllocs(t *testing.T) {
+	if raceEnabled {
+		t.Skip("sync.Pool is not deterministic with race detector")
+	}
+	for _, name := range []string{"object", "combinators", "numbers"} {
+		t.Run(name, func(t *testing.T) {
+			sch, v := compileBenchSchema(t, name)
+			allocs := testing.AllocsPerRun(100, func() {
+				_ = sch.Validate(v)
+			})
+			if allocs != 0 {
+				t.Errorf("got %v allocs, want 0", allocs)
+			}
+		})
+	}
+}
+
+func benchmarkValidate(b *testing.B, name string) {
+	sch, v := compileBenchSchema(b, name)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if err := sch.Validate(v); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkValidateObject(b *testing.B)      { benchmarkValidate(b, "object") }
+func BenchmarkValidateCombinators(b *testing.B) { benchmarkValidate(b, "combinators") }
+func BenchmarkValidateNumbers(b *testing.B)     { benchmarkValidate(b, "numbers") }
+func BenchmarkValidateUnevaluated(b *testing.B) { benchmarkValidate(b, "unevaluated") }
+
+// BenchmarkValidateUniqueItems validates large arrays against uniqueItems
+// and large enum, which are quadratic if done naively.
+func BenchmarkValidateUniqueItems(b *testing.B) {
+	const n = 50000
+	ids := make([]string, n)
+	for i := range ids {
+		ids[i] = fmt.Sprintf(`{"id": %d, "kind": "k%d"}`, i, i%100)
+	}
+	enum := make([]string, 100)
+	for i := range enum {
+		enum[i] = fmt.Sprintf(`"k%d"`, i)
+	}
+	c := jsonschema.NewCompiler()
+	schema := `{"type": "array", "uniqueItems": true, "items": {"properties": {"kind": {"enum": [` + strings.Join(enum, ",") + `]}}}}`
+	if err := c.AddResource("schema.json", strings.NewReader(schema)); err != nil {
+		b.Fatal(err)
+	}
+	sch, err := c.Compile("schema.json")
+	if err != nil {
+		b.Fatal(err)
+	}
+	decoder := json.NewDecoder(strings.NewReader("[" + strings.Join(ids, ",") + "]"))
+	decoder.UseNumber()
+	var v interface{}
+	if err := decoder.Decode(&v); err != nil {
+		b.Fatal(err)
+	}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if err := sch.Validate(v); err != nil {
+			b.Fatal(err)
+		}
+	}
+}